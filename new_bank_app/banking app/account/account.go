@@ -1,128 +1,240 @@
-package account
-
-import (
-	"banking-app/apperror"
-	"fmt"
-)
-
-type Account struct {
-	AccountID int
-	BankID    int
-	OwnerID   int
-	Balance   float64
-	IsActive  bool
-}
-
-var accounts = make(map[int]*Account)
-
-func NewAccount(accountID, ownerID, bankID int) (*Account, error) {
-	if bankID <= 0 {
-		return nil, apperror.NewValidationError("bankID", "must be greater than 0")
-	}
-	if ownerID <= 0 {
-		return nil, apperror.NewValidationError("ownerID", "must be greater than 0")
-	}
-	if _, exists := accounts[accountID]; exists {
-		return nil, apperror.NewValidationError("accountID", fmt.Sprintf("account %d already exists", accountID))
-	}
-	account := &Account{
-		AccountID: accountID,
-		BankID:    bankID,
-		OwnerID:   ownerID,
-		Balance:   1000,
-		IsActive:  true,
-	}
-	accounts[accountID] = account
-	return account, nil
-}
-
-func GetAccountById(accountID int) (*Account, error) {
-	acc, ok := accounts[accountID]
-	if !ok {
-		return nil, apperror.NewNotFoundError("account", accountID)
-	}
-	return acc, nil
-}
-
-func (a *Account) DepositMoney(callerID int, amount float64) error {
-	if !a.IsActive {
-		return apperror.NewAccountError("deposit", fmt.Sprintf("account %d is inactive", a.AccountID))
-	}
-	if a.OwnerID != callerID {
-		return apperror.NewAuthError("unauthorized access to deposit money")
-	}
-	if amount <= 0 {
-		return apperror.NewValidationError("amount", "must be greater than 0")
-	}
-	a.Balance += amount
-	return nil
-}
-
-func (a *Account) WithdrawMoney(callerID int, amount float64) error {
-	if !a.IsActive {
-		return apperror.NewAccountError("withdraw", fmt.Sprintf("account %d is inactive", a.AccountID))
-	}
-	if a.OwnerID != callerID {
-		return apperror.NewAuthError("unauthorized access to withdraw money")
-	}
-	if amount <= 0 {
-		return apperror.NewValidationError("amount", "must be greater than 0")
-	}
-	if a.Balance < amount {
-		return apperror.NewValidationError("balance", "insufficient funds")
-	}
-	a.Balance -= amount
-	return nil
-}
-
-func (acc *Account) TransferMoneyToExternal(targetAccID, fromCustomerID, toCustomerID int, amount float64) error {
-	if acc.OwnerID != fromCustomerID {
-		return apperror.NewAuthError("sender does not own the source account")
-	}
-	if !acc.IsActive {
-		return apperror.NewAccountError("transfer", fmt.Sprintf("source account %d is inactive", acc.AccountID))
-	}
-	toAcc, err := GetAccountById(targetAccID)
-	if err != nil {
-		return err
-	}
-	if !toAcc.IsActive {
-		return apperror.NewAccountError("transfer", fmt.Sprintf("target account %d is inactive", targetAccID))
-	}
-	if toAcc.OwnerID != toCustomerID {
-		return apperror.NewAuthError("receiver does not own the target account")
-	}
-	if err := acc.WithdrawMoney(fromCustomerID, amount); err != nil {
-		return err
-	}
-	if err := toAcc.DepositMoney(toCustomerID, amount); err != nil {
-		_ = acc.DepositMoney(fromCustomerID, amount) // rollback
-		return err
-	}
-	return nil
-}
-
-func TransferMoneyInternally(fromAccountID, toAccountID int, amount float64) error {
-	fromAcc, err := GetAccountById(fromAccountID)
-	if err != nil {
-		return err
-	}
-	toAcc, err := GetAccountById(toAccountID)
-	if err != nil {
-		return err
-	}
-	if fromAcc.OwnerID != toAcc.OwnerID {
-		return apperror.NewAuthError("accounts belong to different owners")
-	}
-	if !fromAcc.IsActive || !toAcc.IsActive {
-		return apperror.NewAccountError("transfer", "both accounts must be active")
-	}
-	if err := fromAcc.WithdrawMoney(fromAcc.OwnerID, amount); err != nil {
-		return err
-	}
-	if err := toAcc.DepositMoney(toAcc.OwnerID, amount); err != nil {
-		_ = fromAcc.DepositMoney(fromAcc.OwnerID, amount) // rollback
-		return err
-	}
-	return nil
-}
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/fx"
+	"banking-app/journal"
+	"banking-app/money"
+	"banking-app/unitofwork"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Account struct {
+	AccountID int
+	Number    string
+	BankID    int
+	BranchID  int
+	OwnerID   int
+	Currency  string
+	Balance   money.Money
+	Terms     Terms
+	OpenedAt  time.Time
+	IsActive  bool
+	Passbook  []Transaction
+	// AccruedInterest is interest earned since the last credit, as of the
+	// business date LastAccrual.
+	AccruedInterest money.Money
+	LastAccrual     time.Time
+	Freeze          Freeze
+	FreezeReason    string
+	Liens           []Lien
+	// JointHolders are the holders besides the owner, who may operate the
+	// account as Mode allows.
+	JointHolders    []int
+	Mode            OperatingMode
+	RequiredHolders int
+	// Nominees are paid the balance when the last holder dies.
+	Nominees []Nominee
+	books    *Books
+	mu       sync.Mutex
+}
+
+var (
+	accountsMu       sync.RWMutex
+	accounts         = make(map[int]*Account)
+	accountsByNumber = make(map[string]*Account)
+)
+
+// NewAccount opens an account numbered number at a branch in currency under
+// terms, funded with openingDeposit, and keeps it in books. The terms and
+// the deposit must be in the same currency.
+func NewAccount(books *Books, accountID int, number string, ownerID, bankID, branchID int, currency string, terms Terms, openingDeposit money.Money) (*Account, error) {
+	number, err := ParseNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	if bankID <= 0 {
+		return nil, apperror.NewValidationError("bankID", "must be greater than 0")
+	}
+	if branchID <= 0 {
+		return nil, apperror.NewValidationError("branchID", "must be greater than 0")
+	}
+	if ownerID <= 0 {
+		return nil, apperror.NewValidationError("ownerID", "must be greater than 0")
+	}
+	currency, err = money.ParseCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ParseProduct(string(terms.Product)); err != nil {
+		return nil, err
+	}
+	if err := terms.checkCurrency(currency); err != nil {
+		return nil, err
+	}
+	balance, err := money.Zero(currency).Add(openingDeposit)
+	if err != nil {
+		return nil, err
+	}
+	if err := terms.validateOpeningDeposit(balance); err != nil {
+		return nil, err
+	}
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+	if _, exists := accounts[accountID]; exists {
+		return nil, apperror.NewValidationError("accountID", fmt.Sprintf("account %d already exists", accountID))
+	}
+	if existing, exists := accountsByNumber[number]; exists {
+		return nil, apperror.NewValidationError("accountNumber", fmt.Sprintf("%s already belongs to account %d", number, existing.AccountID))
+	}
+	account := &Account{
+		AccountID: accountID,
+		Number:    number,
+		BankID:    bankID,
+		BranchID:  branchID,
+		OwnerID:   ownerID,
+		Currency:  currency,
+		Balance:   balance,
+		Terms:     terms,
+		OpenedAt:  books.currentTime(),
+		IsActive:  true,
+
+		AccruedInterest: money.Zero(currency),
+		books:           books,
+	}
+	referenceID := nextReferenceID()
+	account.recordTransaction(referenceID, TxnOpening, 0, account.Balance)
+	books.post(referenceID, TxnOpening, adjustmentLines(account, journal.Vault(bankID), account.Balance))
+	accounts[accountID] = account
+	accountsByNumber[number] = account
+	return account, nil
+}
+
+func GetAccountById(accountID int) (*Account, error) {
+	accountsMu.RLock()
+	defer accountsMu.RUnlock()
+	acc, ok := accounts[accountID]
+	if !ok {
+		return nil, apperror.NewNotFoundError("account", accountID)
+	}
+	return acc, nil
+}
+
+func (a *Account) GetBalance() money.Money {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.Balance
+}
+
+func (a *Account) IsOpen() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.IsActive
+}
+
+func (a *Account) SetActive(active bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.IsActive = active
+}
+
+// SetBalance overrides the balance. The difference is posted against the
+// bank's adjustments account so the journal still agrees with the balance.
+func (a *Account) SetBalance(balance money.Money) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delta, err := balance.Sub(a.Balance)
+	if err != nil {
+		return err
+	}
+	a.Balance = balance
+	a.books.post(nextReferenceID(), TxnAdjustment, adjustmentLines(a, journal.Adjustments(a.BankID), delta))
+	return nil
+}
+
+func (a *Account) DepositMoney(callerID int, amount money.Money) error {
+	if !a.IsHolder(callerID) {
+		return apperror.NewAuthError("unauthorized access to deposit money")
+	}
+	batch := newPostingBatch()
+	batch.credit(a, TxnDeposit, nil, amount)
+	return batch.commitAlone()
+}
+
+func (a *Account) WithdrawMoney(callerID int, amount money.Money) error {
+	if !a.IsHolder(callerID) {
+		return apperror.NewAuthError("unauthorized access to withdraw money")
+	}
+	batch := newPostingBatch()
+	batch.debit(a, TxnWithdrawal, nil, amount)
+	return batch.commitAlone()
+}
+
+func (acc *Account) TransferMoneyToExternal(targetAccID, fromCustomerID, toCustomerID int, amount money.Money, quote fx.Quote) error {
+	uow := unitofwork.New()
+	if err := acc.StageTransferToExternal(uow, targetAccID, fromCustomerID, toCustomerID, amount, quote); err != nil {
+		return err
+	}
+	return uow.Commit()
+}
+
+// StageTransferToExternal enlists both legs of the transfer in uow. Nothing
+// moves until the caller commits it. The target is credited amount
+// converted at quote.
+func (acc *Account) StageTransferToExternal(uow *unitofwork.UnitOfWork, targetAccID, fromCustomerID, toCustomerID int, amount money.Money, quote fx.Quote) error {
+	if !acc.IsHolder(fromCustomerID) {
+		return apperror.NewAuthError("sender does not hold the source account")
+	}
+	toAcc, err := GetAccountById(targetAccID)
+	if err != nil {
+		return err
+	}
+	if toAcc == acc {
+		return apperror.NewAccountError("transfer", "source and target accounts must differ")
+	}
+	if toAcc.OwnerID != toCustomerID {
+		return apperror.NewAuthError("receiver does not own the target account")
+	}
+	batch := newPostingBatch()
+	if err := batch.transfer(acc, toAcc, TxnExternalTransferOut, TxnExternalTransferIn, amount, quote); err != nil {
+		return err
+	}
+	uow.Enlist(batch)
+	return nil
+}
+
+func TransferMoneyInternally(callerID, fromAccountID, toAccountID int, amount money.Money, quote fx.Quote) error {
+	uow := unitofwork.New()
+	if err := StageTransferInternally(uow, callerID, fromAccountID, toAccountID, amount, quote); err != nil {
+		return err
+	}
+	return uow.Commit()
+}
+
+func StageTransferInternally(uow *unitofwork.UnitOfWork, callerID, fromAccountID, toAccountID int, amount money.Money, quote fx.Quote) error {
+	fromAcc, err := GetAccountById(fromAccountID)
+	if err != nil {
+		return err
+	}
+	toAcc, err := GetAccountById(toAccountID)
+	if err != nil {
+		return err
+	}
+	if fromAcc == toAcc {
+		return apperror.NewAccountError("transfer", "source and target accounts must differ")
+	}
+	if !fromAcc.IsHolder(callerID) {
+		return apperror.NewAuthError("caller does not hold the source account")
+	}
+	if !toAcc.IsHolder(callerID) {
+		return apperror.NewAuthError("caller does not hold the target account")
+	}
+	batch := newPostingBatch()
+	if err := batch.transfer(fromAcc, toAcc, TxnInternalTransferOut, TxnInternalTransferIn, amount, quote); err != nil {
+		return err
+	}
+	uow.Enlist(batch)
+	return nil
+}
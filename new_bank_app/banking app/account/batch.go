@@ -0,0 +1,278 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/fx"
+	"banking-app/journal"
+	"banking-app/money"
+	"banking-app/unitofwork"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type posting struct {
+	account      *Account
+	txnType      TransactionType
+	counterparty *Account
+	amount       money.Money
+	credit       bool
+	// quote is set on both legs of a transfer between currencies, and
+	// converted is the amount of the other leg.
+	quote     *fx.Quote
+	converted money.Money
+}
+
+func (p posting) counterpartyID() int {
+	if p.counterparty == nil {
+		return 0
+	}
+	return p.counterparty.AccountID
+}
+
+// postingBatch is the unit-of-work participant for account balances. All
+// postings in a batch share one reference ID and are applied together.
+type postingBatch struct {
+	referenceID string
+	at          time.Time
+	postings    []posting
+	locked      []*Account
+	balances    map[*Account]money.Money
+	lines       []journal.Line
+	committed   []func()
+	// closes is the account the batch empties and closes, if any. Its
+	// debits ignore limits and the product's floor.
+	closes *Account
+}
+
+func newPostingBatch() *postingBatch {
+	return &postingBatch{referenceID: nextReferenceID()}
+}
+
+func (b *postingBatch) credit(acc *Account, txnType TransactionType, counterparty *Account, amount money.Money) {
+	b.postings = append(b.postings, posting{account: acc, txnType: txnType, counterparty: counterparty, amount: amount, credit: true})
+}
+
+func (b *postingBatch) debit(acc *Account, txnType TransactionType, counterparty *Account, amount money.Money) {
+	b.postings = append(b.postings, posting{account: acc, txnType: txnType, counterparty: counterparty, amount: amount})
+}
+
+// transfer stages both legs of moving amount between two accounts. The
+// target is credited amount converted at quote, which must price the source
+// account's currency in the target's.
+func (b *postingBatch) transfer(from, to *Account, outType, inType TransactionType, amount money.Money, quote fx.Quote) error {
+	if quote.From != from.Currency || quote.To != to.Currency {
+		return apperror.NewValidationError("quote", fmt.Sprintf("is for %s to %s, not %s to %s", quote.From, quote.To, from.Currency, to.Currency))
+	}
+	if quote.IsIdentity() {
+		b.debit(from, outType, to, amount)
+		b.credit(to, inType, from, amount)
+		return nil
+	}
+	credited, err := quote.Convert(amount)
+	if err != nil {
+		return err
+	}
+	if !credited.IsPositive() {
+		return apperror.NewValidationError("amount", fmt.Sprintf("%s converts to nothing in %s", amount, quote.To))
+	}
+	b.postings = append(b.postings,
+		posting{account: from, txnType: outType, counterparty: to, amount: amount, quote: &quote, converted: credited},
+		posting{account: to, txnType: inType, counterparty: from, amount: credited, credit: true, quote: &quote, converted: amount})
+	return nil
+}
+
+// books are the books of the batch's accounts, which all share one set.
+func (b *postingBatch) books() *Books {
+	if b.closes != nil {
+		return b.closes.books
+	}
+	if len(b.postings) == 0 {
+		return nil
+	}
+	return b.postings[0].account.books
+}
+
+// onCommit runs f while the batch still holds its accounts' locks, just
+// after the postings are applied.
+func (b *postingBatch) onCommit(f func()) {
+	b.committed = append(b.committed, f)
+}
+
+func (b *postingBatch) Prepare() error {
+	b.at = b.books().currentTime()
+	if err := b.addPenalties(b.at); err != nil {
+		return err
+	}
+	b.lock()
+	b.balances = make(map[*Account]money.Money, len(b.locked))
+	for _, acc := range b.locked {
+		b.balances[acc] = acc.Balance
+	}
+	if b.closes != nil && !b.closes.IsActive {
+		b.Abort()
+		return apperror.NewInactiveError("account", b.closes.AccountID)
+	}
+
+	b.lines = nil
+	for _, p := range b.postings {
+		if err := b.apply(p); err != nil {
+			b.Abort()
+			return err
+		}
+		b.lines = append(b.lines, p.journalLines()...)
+	}
+	// Closing an empty account posts nothing.
+	if len(b.postings) > 0 {
+		if err := journal.Validate(b.lines); err != nil {
+			b.Abort()
+			return err
+		}
+	}
+	if b.closes != nil && !b.balances[b.closes].IsZero() {
+		b.Abort()
+		return apperror.NewAccountError("close", fmt.Sprintf("account %d changed while it was being closed", b.closes.AccountID))
+	}
+	return nil
+}
+
+func (b *postingBatch) apply(p posting) error {
+	if !p.account.IsActive {
+		return apperror.NewInactiveError("account", p.account.AccountID)
+	}
+	if !p.amount.IsPositive() {
+		return apperror.NewValidationError("amount", "must be greater than 0")
+	}
+	if err := p.account.checkFreeze(p.txnType); err != nil {
+		return err
+	}
+
+	if p.credit {
+		if err := p.account.checkCredit(p.txnType); err != nil {
+			return err
+		}
+		balance, err := b.balances[p.account].Add(p.amount)
+		if err != nil {
+			return err
+		}
+		b.balances[p.account] = balance
+		return nil
+	}
+
+	closing := p.account == b.closes
+	if !closing {
+		if err := p.checkLimits(b.at); err != nil {
+			return err
+		}
+	}
+	balance, err := b.balances[p.account].Sub(p.amount)
+	if err != nil {
+		return err
+	}
+	// Money held under liens is kept above the product's floor, or above
+	// zero when the account is being closed.
+	held, err := p.account.heldAt(b.at)
+	if err != nil {
+		return err
+	}
+	floor := money.Zero(p.account.Currency)
+	if !closing {
+		floor = p.account.Terms.floor()
+	}
+	if floor, err = floor.Add(held); err != nil {
+		return err
+	}
+	short, err := balance.LessThan(floor)
+	if err != nil {
+		return err
+	}
+	if short {
+		return apperror.NewInsufficientFundsError(p.account.AccountID)
+	}
+	b.balances[p.account] = balance
+	return nil
+}
+
+// addPenalties charges the premature-withdrawal penalty on every debit from
+// a fixed deposit that has not matured by at. The penalty has to fit in the
+// balance along with the debit itself. A closing account's charges are
+// already in its statement.
+func (b *postingBatch) addPenalties(at time.Time) error {
+	for _, p := range b.postings {
+		if p.credit || p.txnType == TxnPenalty || p.account == b.closes {
+			continue
+		}
+		penalty, err := p.account.prematurePenalty(p.amount, at)
+		if err != nil {
+			return err
+		}
+		if penalty.IsPositive() {
+			b.debit(p.account, TxnPenalty, nil, penalty)
+		}
+	}
+	return nil
+}
+
+func (b *postingBatch) Commit() {
+	for _, p := range b.postings {
+		if p.credit {
+			p.account.Balance, _ = p.account.Balance.Add(p.amount)
+		} else {
+			p.account.Balance, _ = p.account.Balance.Sub(p.amount)
+		}
+		p.account.recordTransaction(b.referenceID, p.txnType, p.counterpartyID(), p.amount)
+		if p.quote != nil {
+			txn := &p.account.Passbook[len(p.account.Passbook)-1]
+			txn.FX, txn.CounterpartyAmount = p.quote, p.converted
+		}
+	}
+	if b.closes != nil {
+		b.books().post(b.referenceID, TxnClosure, b.lines)
+	} else {
+		b.books().post(b.referenceID, b.postings[0].txnType, b.lines)
+	}
+	for _, f := range b.committed {
+		f()
+	}
+	b.unlock()
+}
+
+func (b *postingBatch) Abort() {
+	b.unlock()
+}
+
+// lock takes every account's mutex in ascending AccountID order so that
+// batches touching the same accounts in a different order cannot deadlock.
+func (b *postingBatch) lock() {
+	seen := make(map[*Account]bool)
+	if b.closes != nil {
+		seen[b.closes] = true
+		b.locked = append(b.locked, b.closes)
+	}
+	for _, p := range b.postings {
+		if !seen[p.account] {
+			seen[p.account] = true
+			b.locked = append(b.locked, p.account)
+		}
+	}
+	sort.Slice(b.locked, func(i, j int) bool {
+		return b.locked[i].AccountID < b.locked[j].AccountID
+	})
+	for _, acc := range b.locked {
+		acc.mu.Lock()
+	}
+}
+
+func (b *postingBatch) unlock() {
+	for i := len(b.locked) - 1; i >= 0; i-- {
+		b.locked[i].mu.Unlock()
+	}
+	b.locked = nil
+}
+
+// commitAlone runs the batch as its own unit of work.
+func (b *postingBatch) commitAlone() error {
+	uow := unitofwork.New()
+	uow.Enlist(b)
+	return uow.Commit()
+}
@@ -0,0 +1,67 @@
+package account
+
+import (
+	"banking-app/journal"
+	"sync"
+	"time"
+)
+
+// LimitResolver decides which limits apply to an account at a given time.
+// It is called with the account locked, so it must not lock accounts or
+// anything that is held while accounts are locked.
+type LimitResolver func(a *Account, at time.Time) (Limits, error)
+
+// Books are what the accounts of one bank system share: the journal every
+// change to their balances is posted to, the resolver of their limits and
+// the clock they tell time by. Each account keeps the books it was opened
+// or restored with, so two systems in one process never post to each
+// other's journal, apply each other's limits or share a clock. Accounts
+// without books post nowhere, keep their product's default limits and use
+// the system clock.
+type Books struct {
+	journal *journal.Journal
+	resolve LimitResolver
+
+	mu  sync.RWMutex
+	now func() time.Time
+}
+
+// NewBooks returns books that post to j and take limits from resolve, or
+// the product defaults when resolve is nil.
+func NewBooks(j *journal.Journal, resolve LimitResolver) *Books {
+	return &Books{journal: j, resolve: resolve, now: time.Now}
+}
+
+// SetClock replaces the time source of the books' accounts, for example
+// with a simulated clock.
+func (b *Books) SetClock(now func() time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.now = now
+}
+
+func (b *Books) currentTime() time.Time {
+	if b == nil {
+		return time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.now()
+}
+
+// post records lines under referenceID. The lines are validated before any
+// balance changes, so a failure here means the journal has been tampered
+// with and is ignored rather than undoing a committed posting.
+func (b *Books) post(referenceID string, memo TransactionType, lines []journal.Line) {
+	if b == nil || b.journal == nil || len(lines) == 0 {
+		return
+	}
+	b.journal.Post(journal.Entry{ReferenceID: referenceID, Timestamp: b.currentTime(), Memo: string(memo), Lines: lines})
+}
+
+func (b *Books) limits(a *Account, at time.Time) (Limits, error) {
+	if b == nil || b.resolve == nil {
+		return DefaultLimits(a.Terms.Product), nil
+	}
+	return b.resolve(a, at)
+}
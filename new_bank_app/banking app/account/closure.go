@@ -0,0 +1,131 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/fx"
+	"banking-app/money"
+	"banking-app/unitofwork"
+	"fmt"
+	"time"
+)
+
+// Statement is what closing an account settles. Payable is what is left to
+// pay out once the interest accrued but not yet credited is added to the
+// balance and the charges are taken off.
+type Statement struct {
+	Balance  money.Money
+	Interest money.Money
+	Charges  money.Money
+	Payable  money.Money
+}
+
+// ClosingStatement works out what closing the account at at would settle.
+// The only charge is the premature-withdrawal penalty of a fixed deposit
+// that has not matured. An account that is frozen, holds money under liens
+// or is overdrawn cannot be closed.
+func (a *Account) ClosingStatement(at time.Time) (Statement, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.IsActive {
+		return Statement{}, apperror.NewInactiveError("account", a.AccountID)
+	}
+	if a.Freeze != FreezeNone {
+		return Statement{}, apperror.NewFrozenError(a.AccountID, a.Freeze.against())
+	}
+	held, err := a.heldAt(at)
+	if err != nil {
+		return Statement{}, err
+	}
+	if held.IsPositive() {
+		return Statement{}, apperror.NewAccountError("close", fmt.Sprintf("account %d has %s held under liens", a.AccountID, held))
+	}
+	s := Statement{Balance: a.Balance, Charges: money.Zero(a.Currency)}
+	if s.Interest, err = money.Zero(a.Currency).Add(a.AccruedInterest); err != nil {
+		return Statement{}, err
+	}
+	gross, err := s.Balance.Add(s.Interest)
+	if err != nil {
+		return Statement{}, err
+	}
+	if gross.IsPositive() {
+		penalty, err := a.prematurePenalty(gross, at)
+		if err != nil {
+			return Statement{}, err
+		}
+		if penalty.IsPositive() {
+			s.Charges = penalty
+		}
+	}
+	if s.Payable, err = gross.Sub(s.Charges); err != nil {
+		return Statement{}, err
+	}
+	if s.Payable.IsNegative() {
+		return Statement{}, apperror.NewAccountError("close", fmt.Sprintf("account %d is overdrawn by %s and has to be repaid first", a.AccountID, s.Payable.Neg()))
+	}
+	return s, nil
+}
+
+// Payout is part of a closing account's money, paid into To converted at
+// Quote, or paid out in cash when To is nil.
+type Payout struct {
+	To     *Account
+	Amount money.Money
+	Quote  fx.Quote
+}
+
+// StageClosure enlists closing the account in uow. The interest in s is
+// credited, the charges taken and the payouts, which must add up to
+// s.Payable, made; neither limits nor the product's floor apply to them.
+// The account closes empty, so the unit of work fails if it moved since s
+// was worked out. It returns the reference every posting is recorded under.
+func (a *Account) StageClosure(uow *unitofwork.UnitOfWork, s Statement, payouts []Payout) (string, error) {
+	total := money.Zero(a.Currency)
+	for _, p := range payouts {
+		if p.To == a {
+			return "", apperror.NewAccountError("close", fmt.Sprintf("account %d cannot be paid out into itself", a.AccountID))
+		}
+		var err error
+		if total, err = total.Add(p.Amount); err != nil {
+			return "", err
+		}
+	}
+	if cmp, err := total.Cmp(s.Payable); err != nil {
+		return "", err
+	} else if cmp != 0 {
+		return "", apperror.NewValidationError("payouts", fmt.Sprintf("add up to %s, not the %s payable", total, s.Payable))
+	}
+
+	batch := newPostingBatch()
+	batch.closes = a
+	if s.Interest.IsPositive() {
+		batch.credit(a, TxnInterestCredit, nil, s.Interest)
+		batch.onCommit(func() {
+			a.AccruedInterest, _ = a.AccruedInterest.Sub(s.Interest)
+		})
+	}
+	if s.Charges.IsPositive() {
+		batch.debit(a, TxnPenalty, nil, s.Charges)
+	}
+	for _, p := range payouts {
+		switch {
+		case !p.Amount.IsPositive():
+			continue
+		case p.To == nil:
+			batch.debit(a, TxnClosure, nil, p.Amount)
+		default:
+			inType := TxnInternalTransferIn
+			if p.To.BankID != a.BankID {
+				inType = TxnExternalTransferIn
+			}
+			if err := batch.transfer(a, p.To, TxnClosure, inType, p.Amount, p.Quote); err != nil {
+				return "", err
+			}
+		}
+	}
+	batch.onCommit(func() {
+		a.IsActive = false
+	})
+	uow.Enlist(batch)
+	return batch.referenceID, nil
+}
@@ -0,0 +1,158 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"fmt"
+)
+
+// OperatingMode is how the holders of a joint account may debit it.
+// Deposits are always open to any holder.
+type OperatingMode string
+
+const (
+	// ModeSole is the only mode for an account with a single holder.
+	ModeSole OperatingMode = ""
+	// ModeEitherOrSurvivor lets any holder debit the account alone.
+	ModeEitherOrSurvivor OperatingMode = "either-or-survivor"
+	// ModeJointly needs every holder to agree to each debit.
+	ModeJointly OperatingMode = "jointly"
+	// ModeAnyNOfM needs RequiredHolders of the holders to agree.
+	ModeAnyNOfM OperatingMode = "any-n-of-m"
+)
+
+func ParseOperatingMode(s string) (OperatingMode, error) {
+	switch m := OperatingMode(s); m {
+	case ModeEitherOrSurvivor, ModeJointly, ModeAnyNOfM:
+		return m, nil
+	}
+	return "", apperror.NewValidationError("mode", fmt.Sprintf("unknown operating mode %q", s))
+}
+
+// Holders lists everyone who may operate the account, the owner first.
+func (a *Account) Holders() []int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]int{a.OwnerID}, a.JointHolders...)
+}
+
+func (a *Account) IsHolder(customerID int) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.isHolder(customerID)
+}
+
+// isHolder expects a.mu to be held.
+func (a *Account) isHolder(customerID int) bool {
+	if customerID == a.OwnerID {
+		return true
+	}
+	for _, id := range a.JointHolders {
+		if id == customerID {
+			return true
+		}
+	}
+	return false
+}
+
+// AddHolder makes customerID a joint holder. An account that had a single
+// holder becomes either-or-survivor.
+func (a *Account) AddHolder(customerID int) error {
+	if customerID <= 0 {
+		return apperror.NewValidationError("customerID", "must be greater than 0")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.isHolder(customerID) {
+		return apperror.NewValidationError("customerID", fmt.Sprintf("customer %d already holds account %d", customerID, a.AccountID))
+	}
+	a.JointHolders = append(append([]int(nil), a.JointHolders...), customerID)
+	if a.Mode == ModeSole {
+		a.Mode = ModeEitherOrSurvivor
+	}
+	return nil
+}
+
+// SetOperatingMode changes how a joint account is operated. required is how
+// many holders must agree under ModeAnyNOfM and is ignored otherwise.
+func (a *Account) SetOperatingMode(mode OperatingMode, required int) error {
+	if _, err := ParseOperatingMode(string(mode)); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	holders := 1 + len(a.JointHolders)
+	if holders == 1 {
+		return apperror.NewValidationError("mode", "an account with a single holder is operated by that holder alone")
+	}
+	if mode != ModeAnyNOfM {
+		required = 0
+	} else if required < 1 || required > holders {
+		return apperror.NewValidationError("requiredHolders", fmt.Sprintf("must be between 1 and the account's %d holders", holders))
+	}
+	a.Mode = mode
+	a.RequiredHolders = required
+	return nil
+}
+
+// ApprovalsRequired is how many holders, the one making it included, must
+// agree to a debit.
+func (a *Account) ApprovalsRequired() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch a.Mode {
+	case ModeJointly:
+		return 1 + len(a.JointHolders)
+	case ModeAnyNOfM:
+		return a.RequiredHolders
+	}
+	return 1
+}
+
+// RemoveHolder takes a holder who has died off a joint account, which
+// passes to the holders who survive them. If it was the owner, the first
+// joint holder becomes the owner. A single survivor operates the account
+// alone, and an any-n-of-m account never needs more holders than it has.
+func (a *Account) RemoveHolder(customerID int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, err := a.withoutHolder(customerID)
+	if err != nil {
+		return err
+	}
+	a.OwnerID, a.JointHolders = s.OwnerID, s.JointHolders
+	a.Mode, a.RequiredHolders = s.Mode, s.RequiredHolders
+	return nil
+}
+
+// WithoutHolder is the snapshot RemoveHolder would leave the account in,
+// so that the change can be saved before it is made.
+func (a *Account) WithoutHolder(customerID int) (Snapshot, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.withoutHolder(customerID)
+}
+
+// withoutHolder expects a.mu to be held.
+func (a *Account) withoutHolder(customerID int) (Snapshot, error) {
+	if !a.isHolder(customerID) {
+		return Snapshot{}, apperror.NewValidationError("customerID", fmt.Sprintf("customer %d does not hold account %d", customerID, a.AccountID))
+	}
+	if len(a.JointHolders) == 0 {
+		return Snapshot{}, apperror.NewAccountError("remove holder", fmt.Sprintf("customer %d is the only holder of account %d", customerID, a.AccountID))
+	}
+	s := a.snapshot()
+	survivors := make([]int, 0, len(a.JointHolders))
+	for _, id := range append([]int{a.OwnerID}, a.JointHolders...) {
+		if id != customerID {
+			survivors = append(survivors, id)
+		}
+	}
+	s.OwnerID, s.JointHolders = survivors[0], survivors[1:]
+	switch {
+	case len(survivors) == 1:
+		s.Mode, s.RequiredHolders = ModeSole, 0
+	case s.Mode == ModeAnyNOfM && s.RequiredHolders > len(survivors):
+		s.RequiredHolders = len(survivors)
+	}
+	return s, nil
+}
@@ -0,0 +1,68 @@
+package account
+
+import (
+	"banking-app/journal"
+	"banking-app/money"
+	"time"
+)
+
+// DaysPerYear is the day count interest is accrued over.
+const DaysPerYear = 365
+
+// AccrueInterest adds one day of interest at rateBPS a year on the current
+// balance to the interest accrued so far, and notes it in the passbook
+// without touching the balance. Only open savings accounts accrue, and each
+// at most once per business date, so repeating a date accrues nothing. Only
+// the UTC calendar day of businessDate counts, not its time of day.
+func (a *Account) AccrueInterest(businessDate time.Time, rateBPS int64) (money.Money, error) {
+	businessDate = dayStart(businessDate)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.IsActive || a.Terms.Product != ProductSavings || !businessDate.After(a.LastAccrual) {
+		return money.Money{}, nil
+	}
+	var daily money.Money
+	if a.Balance.IsPositive() && rateBPS > 0 {
+		var err error
+		daily, err = a.Balance.MulRat(rateBPS, 10000*DaysPerYear, money.RoundHalfEven)
+		if err != nil {
+			return money.Money{}, err
+		}
+	}
+	accrued, err := a.AccruedInterest.Add(daily)
+	if err != nil {
+		return money.Money{}, err
+	}
+	a.AccruedInterest = accrued
+	a.LastAccrual = businessDate
+	if daily.IsPositive() {
+		referenceID := nextReferenceID()
+		a.recordTransaction(referenceID, TxnInterestAccrual, 0, daily)
+		a.books.post(referenceID, TxnInterestAccrual, []journal.Line{
+			journal.DebitLine(journal.InterestExpense(a.BankID), daily),
+			journal.CreditLine(journal.InterestPayable(a.BankID), daily),
+		})
+	}
+	return daily, nil
+}
+
+// CreditAccruedInterest moves the interest accrued so far into the balance.
+func (a *Account) CreditAccruedInterest() (money.Money, error) {
+	a.mu.Lock()
+	accrued := a.AccruedInterest
+	a.mu.Unlock()
+	if !accrued.IsPositive() {
+		return money.Money{}, nil
+	}
+
+	batch := newPostingBatch()
+	batch.credit(a, TxnInterestCredit, nil, accrued)
+	batch.onCommit(func() {
+		a.AccruedInterest, _ = a.AccruedInterest.Sub(accrued)
+	})
+	if err := batch.commitAlone(); err != nil {
+		return money.Money{}, err
+	}
+	return accrued, nil
+}
@@ -0,0 +1,88 @@
+package account
+
+import (
+	"banking-app/journal"
+	"banking-app/money"
+)
+
+// journalLines is the double entry for one posting: the line on the
+// customer's account and the contra line it is balanced against. The two
+// legs of a transfer balance each other, except across banks, where each
+// leg is balanced against its own bank's clearing account instead, whether
+// the transfer is between two customers or between one customer's accounts
+// at different banks. The
+// sending bank converts a transfer between currencies through its FX
+// position and passes on the converted amount.
+func (p posting) journalLines() []journal.Line {
+	customer := journal.Customer(p.account.AccountID)
+	bankID := p.account.BankID
+	if !p.credit {
+		lines := []journal.Line{journal.DebitLine(customer, p.amount)}
+		sent := p.amount
+		if p.quote != nil {
+			sent = p.converted
+			lines = append(lines,
+				journal.CreditLine(journal.FXPosition(bankID), p.amount),
+				journal.DebitLine(journal.FXPosition(bankID), p.converted))
+		}
+		switch p.txnType {
+		case TxnWithdrawal:
+			lines = append(lines, journal.CreditLine(journal.Vault(bankID), p.amount))
+		case TxnPenalty:
+			lines = append(lines, journal.CreditLine(journal.FeeIncome(bankID), p.amount))
+		case TxnClosure:
+			if p.counterparty == nil {
+				lines = append(lines, journal.CreditLine(journal.Vault(bankID), p.amount))
+			} else if p.counterparty.BankID != bankID {
+				lines = append(lines, journal.CreditLine(journal.Clearing(bankID), sent))
+			}
+		case TxnInternalTransferOut, TxnExternalTransferOut:
+			if p.counterparty.BankID != bankID {
+				lines = append(lines, journal.CreditLine(journal.Clearing(bankID), sent))
+			}
+		}
+		return lines
+	}
+
+	lines := []journal.Line{journal.CreditLine(customer, p.amount)}
+	switch p.txnType {
+	case TxnDeposit:
+		lines = append(lines, journal.DebitLine(journal.Vault(bankID), p.amount))
+	case TxnInterestCredit:
+		lines = append(lines, journal.DebitLine(journal.InterestPayable(bankID), p.amount))
+	case TxnInternalTransferIn, TxnExternalTransferIn:
+		if p.counterparty.BankID != bankID {
+			lines = append(lines, journal.DebitLine(journal.Clearing(bankID), p.amount))
+		}
+	}
+	return lines
+}
+
+// adjustmentLines balances a change of delta in a customer account against
+// the bank's adjustments account, on whichever side delta falls.
+func adjustmentLines(a *Account, contra journal.Code, delta money.Money) []journal.Line {
+	customer := journal.Customer(a.AccountID)
+	switch {
+	case delta.IsPositive():
+		return []journal.Line{journal.DebitLine(contra, delta), journal.CreditLine(customer, delta)}
+	case delta.IsNegative():
+		return []journal.Line{journal.DebitLine(customer, delta.Neg()), journal.CreditLine(contra, delta.Neg())}
+	}
+	return nil
+}
+
+// BringForward posts an account's current balance, and any interest accrued
+// but not yet credited, against the bank's opening-balances account. It is
+// for accounts whose history predates the journal.
+func (a *Account) BringForward() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	opening := journal.OpeningBalances(a.BankID)
+	lines := adjustmentLines(a, opening, a.Balance)
+	if a.AccruedInterest.IsPositive() {
+		lines = append(lines,
+			journal.DebitLine(opening, a.AccruedInterest),
+			journal.CreditLine(journal.InterestPayable(a.BankID), a.AccruedInterest))
+	}
+	a.books.post(nextReferenceID(), TxnOpening, lines)
+}
@@ -0,0 +1,231 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"fmt"
+	"time"
+)
+
+// Limits cap what customers may take out of an account. A zero field means
+// no limit. Days and months are UTC calendar days and months.
+type Limits struct {
+	// PerTransaction caps a single withdrawal or outgoing transfer.
+	PerTransaction    money.Money
+	DailyWithdrawal   money.Money
+	MonthlyWithdrawal money.Money
+	// DailyTransfers caps the number of outgoing transfers, internal and
+	// external alike, and DailyTransferAmount what they add up to.
+	DailyTransfers      int
+	DailyTransferAmount money.Money
+}
+
+func DefaultLimits(product Product) Limits {
+	switch product {
+	case ProductSavings:
+		return Limits{
+			PerTransaction:      money.MustFromMajor(200000, money.INR),
+			DailyWithdrawal:     money.MustFromMajor(50000, money.INR),
+			MonthlyWithdrawal:   money.MustFromMajor(500000, money.INR),
+			DailyTransfers:      20,
+			DailyTransferAmount: money.MustFromMajor(500000, money.INR),
+		}
+	case ProductCurrent:
+		return Limits{
+			PerTransaction:      money.MustFromMajor(1000000, money.INR),
+			DailyWithdrawal:     money.MustFromMajor(200000, money.INR),
+			MonthlyWithdrawal:   money.MustFromMajor(2000000, money.INR),
+			DailyTransfers:      100,
+			DailyTransferAmount: money.MustFromMajor(5000000, money.INR),
+		}
+	}
+	return Limits{}
+}
+
+func (l Limits) Validate() error {
+	for name, m := range map[string]money.Money{
+		"perTransaction":      l.PerTransaction,
+		"dailyWithdrawal":     l.DailyWithdrawal,
+		"monthlyWithdrawal":   l.MonthlyWithdrawal,
+		"dailyTransferAmount": l.DailyTransferAmount,
+	} {
+		if m.IsNegative() {
+			return apperror.NewValidationError(name, "cannot be negative")
+		}
+	}
+	if l.DailyTransfers < 0 {
+		return apperror.NewValidationError("dailyTransfers", "cannot be negative")
+	}
+	return nil
+}
+
+// Raise returns l with every limit that increase sets lifted to it. Limits
+// are only ever raised, and a limit l does not set stays unlimited.
+func (l Limits) Raise(increase Limits) Limits {
+	raise := func(base, to money.Money) money.Money {
+		if base.IsZero() || to.IsZero() {
+			return base
+		}
+		if less, err := base.LessThan(to); err == nil && less {
+			return to
+		}
+		return base
+	}
+	l.PerTransaction = raise(l.PerTransaction, increase.PerTransaction)
+	l.DailyWithdrawal = raise(l.DailyWithdrawal, increase.DailyWithdrawal)
+	l.MonthlyWithdrawal = raise(l.MonthlyWithdrawal, increase.MonthlyWithdrawal)
+	l.DailyTransferAmount = raise(l.DailyTransferAmount, increase.DailyTransferAmount)
+	if l.DailyTransfers != 0 && increase.DailyTransfers > l.DailyTransfers {
+		l.DailyTransfers = increase.DailyTransfers
+	}
+	return l
+}
+
+// Convert reprices every limit with convert, for an account kept in another
+// currency than the one the limits were set in.
+func (l Limits) Convert(convert func(money.Money) (money.Money, error)) (Limits, error) {
+	for _, m := range []*money.Money{&l.PerTransaction, &l.DailyWithdrawal, &l.MonthlyWithdrawal, &l.DailyTransferAmount} {
+		if m.IsZero() {
+			continue
+		}
+		var err error
+		if *m, err = convert(*m); err != nil {
+			return Limits{}, err
+		}
+	}
+	return l, nil
+}
+
+// LimitIncrease temporarily raises an account's limits until ExpiresAt.
+type LimitIncrease struct {
+	AccountID int
+	Limits    Limits
+	GrantedBy int
+	GrantedAt time.Time
+	ExpiresAt time.Time
+}
+
+func (i LimitIncrease) ActiveAt(at time.Time) bool {
+	return !at.Before(i.GrantedAt) && at.Before(i.ExpiresAt)
+}
+
+// Usage is what an account has taken out so far in the current day and
+// month.
+type Usage struct {
+	WithdrawnToday     money.Money
+	WithdrawnThisMonth money.Money
+	TransfersToday     int
+	TransferredToday   money.Money
+}
+
+func (a *Account) UsageAt(at time.Time) (Usage, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.usage(at)
+}
+
+// usage walks the passbook back to the start of at's month. It expects a.mu
+// to be held.
+func (a *Account) usage(at time.Time) (Usage, error) {
+	day, month := dayStart(at), monthStart(at)
+	var u Usage
+	for i := len(a.Passbook) - 1; i >= 0; i-- {
+		txn := a.Passbook[i]
+		if txn.Timestamp.Before(month) {
+			break
+		}
+		today := !txn.Timestamp.Before(day)
+		var err error
+		switch txn.Type {
+		case TxnWithdrawal:
+			if u.WithdrawnThisMonth, err = u.WithdrawnThisMonth.Add(txn.Amount); err != nil {
+				return Usage{}, err
+			}
+			if today {
+				if u.WithdrawnToday, err = u.WithdrawnToday.Add(txn.Amount); err != nil {
+					return Usage{}, err
+				}
+			}
+		case TxnInternalTransferOut, TxnExternalTransferOut:
+			if today {
+				u.TransfersToday++
+				if u.TransferredToday, err = u.TransferredToday.Add(txn.Amount); err != nil {
+					return Usage{}, err
+				}
+			}
+		}
+	}
+	return u, nil
+}
+
+// checkLimits refuses a customer debit that would break one of the
+// account's limits. It expects p.account to be locked.
+func (p posting) checkLimits(at time.Time) error {
+	var transfer bool
+	switch p.txnType {
+	case TxnWithdrawal:
+	case TxnInternalTransferOut, TxnExternalTransferOut:
+		transfer = true
+	default:
+		return nil
+	}
+	acc := p.account
+	limits, err := acc.books.limits(acc, at)
+	if err != nil {
+		return err
+	}
+
+	if err := withinLimit(acc, p.amount, limits.PerTransaction, "per-transaction", time.Time{}); err != nil {
+		return err
+	}
+	u, err := acc.usage(at)
+	if err != nil {
+		return err
+	}
+	if transfer {
+		if limits.DailyTransfers > 0 && u.TransfersToday >= limits.DailyTransfers {
+			return apperror.NewLimitExceededError(acc.AccountID, fmt.Sprintf("daily limit of %d transfers", limits.DailyTransfers), dayStart(at).AddDate(0, 0, 1))
+		}
+		today, err := u.TransferredToday.Add(p.amount)
+		if err != nil {
+			return err
+		}
+		return withinLimit(acc, today, limits.DailyTransferAmount, "daily transfer", dayStart(at).AddDate(0, 0, 1))
+	}
+	today, err := u.WithdrawnToday.Add(p.amount)
+	if err != nil {
+		return err
+	}
+	if err := withinLimit(acc, today, limits.DailyWithdrawal, "daily withdrawal", dayStart(at).AddDate(0, 0, 1)); err != nil {
+		return err
+	}
+	month, err := u.WithdrawnThisMonth.Add(p.amount)
+	if err != nil {
+		return err
+	}
+	return withinLimit(acc, month, limits.MonthlyWithdrawal, "monthly withdrawal", monthStart(at).AddDate(0, 1, 0))
+}
+
+func withinLimit(acc *Account, total, limit money.Money, name string, resetsAt time.Time) error {
+	if limit.IsZero() {
+		return nil
+	}
+	over, err := limit.LessThan(total)
+	if err != nil {
+		return err
+	}
+	if over {
+		return apperror.NewLimitExceededError(acc.AccountID, fmt.Sprintf("%s limit of %s", name, limit), resetsAt)
+	}
+	return nil
+}
+
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,125 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckLimits(t *testing.T) {
+	limits := Limits{
+		PerTransaction:      inr(1000),
+		DailyWithdrawal:     inr(1500),
+		MonthlyWithdrawal:   inr(3000),
+		DailyTransfers:      2,
+		DailyTransferAmount: inr(2500),
+	}
+	books := NewBooks(nil, func(*Account, time.Time) (Limits, error) { return limits, nil })
+	at := time.Date(2025, time.March, 14, 15, 0, 0, 0, time.UTC)
+	tomorrow := time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC)
+	nextMonth := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+	earlierToday := at.Add(-time.Hour)
+	earlierThisMonth := at.AddDate(0, 0, -10)
+	lastMonth := at.AddDate(0, -1, 0)
+
+	tests := []struct {
+		name     string
+		txnType  TransactionType
+		amount   money.Money
+		passbook []Transaction
+		limit    string
+		resetsAt time.Time
+	}{
+		{name: "withdrawal within limits", txnType: TxnWithdrawal, amount: inr(1000)},
+		{name: "over per-transaction", txnType: TxnWithdrawal, amount: inr(1001), limit: "per-transaction"},
+		{name: "transfer over per-transaction", txnType: TxnExternalTransferOut, amount: inr(1001), limit: "per-transaction"},
+		{
+			name: "over daily withdrawal", txnType: TxnWithdrawal, amount: inr(600),
+			passbook: []Transaction{{Type: TxnWithdrawal, Timestamp: earlierToday, Amount: inr(1000)}},
+			limit:    "daily withdrawal", resetsAt: tomorrow,
+		},
+		{
+			name: "yesterday's withdrawals reset", txnType: TxnWithdrawal, amount: inr(1000),
+			passbook: []Transaction{{Type: TxnWithdrawal, Timestamp: earlierThisMonth, Amount: inr(1000)}},
+		},
+		{
+			name: "over monthly withdrawal", txnType: TxnWithdrawal, amount: inr(600),
+			passbook: []Transaction{
+				{Type: TxnWithdrawal, Timestamp: earlierThisMonth, Amount: inr(1000)},
+				{Type: TxnWithdrawal, Timestamp: earlierThisMonth, Amount: inr(1000)},
+				{Type: TxnWithdrawal, Timestamp: earlierToday, Amount: inr(500)},
+			},
+			limit: "monthly withdrawal", resetsAt: nextMonth,
+		},
+		{
+			name: "last month's withdrawals reset", txnType: TxnWithdrawal, amount: inr(1000),
+			passbook: []Transaction{{Type: TxnWithdrawal, Timestamp: lastMonth, Amount: inr(1000)}, {Type: TxnWithdrawal, Timestamp: lastMonth, Amount: inr(1000)}},
+		},
+		{
+			name: "over daily transfer count", txnType: TxnInternalTransferOut, amount: inr(1),
+			passbook: []Transaction{
+				{Type: TxnInternalTransferOut, Timestamp: earlierToday, Amount: inr(1)},
+				{Type: TxnExternalTransferOut, Timestamp: earlierToday, Amount: inr(1)},
+			},
+			limit: "transfers", resetsAt: tomorrow,
+		},
+		{
+			name: "over daily transfer amount", txnType: TxnExternalTransferOut, amount: inr(600),
+			passbook: []Transaction{
+				{Type: TxnExternalTransferOut, Timestamp: earlierToday, Amount: inr(2000)},
+				{Type: TxnWithdrawal, Timestamp: earlierToday, Amount: inr(1000)},
+			},
+			limit: "daily transfer", resetsAt: tomorrow,
+		},
+		{
+			name: "transfers do not count as withdrawals", txnType: TxnWithdrawal, amount: inr(1000),
+			passbook: []Transaction{{Type: TxnExternalTransferOut, Timestamp: earlierToday, Amount: inr(1000)}},
+		},
+		{
+			name: "credits and charges are not limited", txnType: TxnPenalty, amount: inr(5000),
+			passbook: []Transaction{{Type: TxnWithdrawal, Timestamp: earlierToday, Amount: inr(1500)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := &Account{AccountID: 1, Currency: money.INR, Terms: DefaultTerms(ProductSavings), Passbook: tt.passbook, books: books}
+			err := posting{account: acc, txnType: tt.txnType, amount: tt.amount}.checkLimits(at)
+			if tt.limit == "" {
+				if err != nil {
+					t.Fatalf("err = %v, want none", err)
+				}
+				return
+			}
+			var exceeded *apperror.LimitExceededError
+			if !errors.As(err, &exceeded) {
+				t.Fatalf("err = %v, want LimitExceededError", err)
+			}
+			if !strings.Contains(exceeded.Limit, tt.limit) {
+				t.Errorf("limit = %q, want the %s limit", exceeded.Limit, tt.limit)
+			}
+			if !exceeded.ResetsAt.Equal(tt.resetsAt) {
+				t.Errorf("resets at %s, want %s", exceeded.ResetsAt, tt.resetsAt)
+			}
+		})
+	}
+}
+
+func TestRaiseOnlyLiftsLimits(t *testing.T) {
+	base := Limits{PerTransaction: inr(1000), DailyWithdrawal: inr(5000), DailyTransfers: 10}
+	got := base.Raise(Limits{
+		PerTransaction:    inr(2000),
+		DailyWithdrawal:   inr(4000),
+		MonthlyWithdrawal: inr(9000),
+		DailyTransfers:    5,
+	})
+	want := Limits{PerTransaction: inr(2000), DailyWithdrawal: inr(5000), DailyTransfers: 10}
+	if got != want {
+		t.Errorf("Raise = %+v, want %+v", got, want)
+	}
+	if got := base.Raise(Limits{}); got != base {
+		t.Errorf("Raise by nothing = %+v, want %+v", got, base)
+	}
+}
@@ -0,0 +1,58 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"fmt"
+	"strings"
+)
+
+// MaxNominees is how many nominees one account may name.
+const MaxNominees = 4
+
+// Nominee is someone the bank pays SharePercent of an account to when its
+// last holder dies.
+type Nominee struct {
+	NomineeID    int
+	Name         string
+	Relationship string
+	SharePercent int
+}
+
+// SetNominees replaces the account's nominees, numbered in the order given.
+// Their shares must add up to 100. An empty list removes them all.
+func (a *Account) SetNominees(nominees []Nominee) ([]Nominee, error) {
+	if len(nominees) > MaxNominees {
+		return nil, apperror.NewValidationError("nominees", fmt.Sprintf("an account may name at most %d", MaxNominees))
+	}
+	set := make([]Nominee, len(nominees))
+	total := 0
+	for i, n := range nominees {
+		n.NomineeID = i + 1
+		n.Name = strings.TrimSpace(n.Name)
+		n.Relationship = strings.TrimSpace(n.Relationship)
+		if n.Name == "" {
+			return nil, apperror.NewValidationError("name", fmt.Sprintf("nominee %d needs a name", n.NomineeID))
+		}
+		if n.Relationship == "" {
+			return nil, apperror.NewValidationError("relationship", fmt.Sprintf("nominee %d needs a relationship to the holder", n.NomineeID))
+		}
+		if n.SharePercent < 1 || n.SharePercent > 100 {
+			return nil, apperror.NewValidationError("sharePercent", fmt.Sprintf("nominee %d's share must be between 1 and 100", n.NomineeID))
+		}
+		total += n.SharePercent
+		set[i] = n
+	}
+	if len(set) > 0 && total != 100 {
+		return nil, apperror.NewValidationError("sharePercent", fmt.Sprintf("shares add up to %d, not 100", total))
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Nominees = set
+	return append([]Nominee(nil), set...), nil
+}
+
+func (a *Account) GetNominees() []Nominee {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Nominee(nil), a.Nominees...)
+}
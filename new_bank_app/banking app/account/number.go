@@ -0,0 +1,77 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// An account number is the bank code, the branch code, a ten-digit serial
+// and two check digits, such as SBIN000001000000100721. The check digits
+// follow ISO 7064 mod 97-10, as in IBANs, so any single digit mistyped for
+// another digit, or letter for another letter, and almost any swapped pair
+// is caught.
+const (
+	serialDigits = 10
+	maxSerial    = 10_000_000_000
+	numberLength = 4 + 6 + serialDigits + 2
+)
+
+// FormatNumber builds the number of the account with serial at a branch.
+// The serial has to fit in ten digits.
+func FormatNumber(bankCode, branchCode string, serial int) (string, error) {
+	if serial < 0 || int64(serial) >= maxSerial {
+		return "", apperror.NewValidationError("serial", fmt.Sprintf("%d does not fit in %d digits", serial, serialDigits))
+	}
+	body := fmt.Sprintf("%s%s%0*d", bankCode, branchCode, serialDigits, serial)
+	return body + fmt.Sprintf("%02d", 98-mod97(body+"00")), nil
+}
+
+// ParseNumber normalizes a typed account number, dropping spaces and dashes,
+// and checks its layout and check digits. It does not look the account up.
+func ParseNumber(number string) (string, error) {
+	number = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(number))
+	if len(number) != numberLength {
+		return "", apperror.NewValidationError("accountNumber", fmt.Sprintf("%q is not %d characters long", number, numberLength))
+	}
+	for i, r := range number {
+		letter := r >= 'A' && r <= 'Z'
+		digit := r >= '0' && r <= '9'
+		if (i < 4 && !letter) || (i >= 10 && !digit) || !(letter || digit) {
+			return "", apperror.NewValidationError("accountNumber", fmt.Sprintf("%q is not a bank code, a branch code and digits", number))
+		}
+	}
+	if mod97(number) != 1 {
+		return "", apperror.NewValidationError("accountNumber", fmt.Sprintf("%q has the wrong check digits; check it for typos", number))
+	}
+	return number, nil
+}
+
+// mod97 is s modulo 97, with letters counting as two digits, A as 10
+// through Z as 35.
+func mod97(s string) int {
+	rem := 0
+	for _, r := range s {
+		digits := string(r)
+		if r >= 'A' && r <= 'Z' {
+			digits = strconv.Itoa(int(r-'A') + 10)
+		}
+		for _, d := range digits {
+			rem = (rem*10 + int(d-'0')) % 97
+		}
+	}
+	return rem
+}
+
+// GetAccountByNumber finds an account by a number that has already been
+// through ParseNumber.
+func GetAccountByNumber(number string) (*Account, error) {
+	accountsMu.RLock()
+	defer accountsMu.RUnlock()
+	acc, ok := accountsByNumber[number]
+	if !ok {
+		return nil, apperror.NewCodeNotFoundError("account", number)
+	}
+	return acc, nil
+}
@@ -0,0 +1,83 @@
+package account
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormattedNumberParses(t *testing.T) {
+	for _, serial := range []int{0, 1, 1007, 9_999_999_999} {
+		number, err := FormatNumber("SBIN", "000001", serial)
+		if err != nil {
+			t.Fatalf("serial %d: %v", serial, err)
+		}
+		if len(number) != numberLength {
+			t.Errorf("serial %d: %q is %d characters, want %d", serial, number, len(number), numberLength)
+		}
+		if got, err := ParseNumber(number); err != nil || got != number {
+			t.Errorf("ParseNumber(%q) = %q, %v", number, got, err)
+		}
+	}
+	if got, err := FormatNumber("SBIN", "000001", 1007); err != nil || got != "SBIN000001000000100721" {
+		t.Errorf("FormatNumber = %q, %v; want SBIN000001000000100721", got, err)
+	}
+}
+
+func TestFormatNumberRefusesSerialsOverTenDigits(t *testing.T) {
+	for _, serial := range []int{-1, 10_000_000_000} {
+		if number, err := FormatNumber("SBIN", "000001", serial); err == nil {
+			t.Errorf("serial %d formatted as %q", serial, number)
+		}
+	}
+}
+
+func TestParseNumberNormalizes(t *testing.T) {
+	for _, typed := range []string{
+		"SBIN000001000000100721",
+		"sbin000001000000100721",
+		"SBIN 0000 0100 0000 1007 21",
+		"sbin-000001-0000001007-21",
+		" SBIN000001000000100721 ",
+	} {
+		if got, err := ParseNumber(typed); err != nil || got != "SBIN000001000000100721" {
+			t.Errorf("ParseNumber(%q) = %q, %v; want SBIN000001000000100721", typed, got, err)
+		}
+	}
+}
+
+func TestParseNumberCatchesTypos(t *testing.T) {
+	number, err := FormatNumber("SBIN", "0A0001", 1007)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const letters, digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZ", "0123456789"
+	for i := range number {
+		alphabet := digits
+		if strings.IndexByte(letters, number[i]) >= 0 {
+			alphabet = letters
+		}
+		for _, r := range alphabet {
+			if byte(r) == number[i] {
+				continue
+			}
+			typo := number[:i] + string(r) + number[i+1:]
+			if _, err := ParseNumber(typo); err == nil {
+				t.Errorf("%q with character %d changed to %c parses", number, i, r)
+			}
+		}
+	}
+	for i := 0; i+1 < len(number); i++ {
+		if number[i] == number[i+1] {
+			continue
+		}
+		swapped := number[:i] + string(number[i+1]) + string(number[i]) + number[i+2:]
+		if _, err := ParseNumber(swapped); err == nil {
+			t.Errorf("%q with characters %d and %d swapped parses", number, i, i+1)
+		}
+	}
+	for _, bad := range []string{"", "SBIN00000100000010072", "SBIN0000010000001007210", "1BIN000001000000100721", "SBIN00000100000010072X"} {
+		if _, err := ParseNumber(bad); err == nil {
+			t.Errorf("ParseNumber(%q) accepted a malformed number", bad)
+		}
+	}
+}
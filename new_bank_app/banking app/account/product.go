@@ -0,0 +1,149 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"fmt"
+	"time"
+)
+
+type Product string
+
+const (
+	ProductSavings      Product = "savings"
+	ProductCurrent      Product = "current"
+	ProductFixedDeposit Product = "fixed-deposit"
+)
+
+func ParseProduct(s string) (Product, error) {
+	switch p := Product(s); p {
+	case ProductSavings, ProductCurrent, ProductFixedDeposit:
+		return p, nil
+	}
+	return "", apperror.NewValidationError("product", fmt.Sprintf("unknown account product %q", s))
+}
+
+// Terms are the rules an account was opened under. They are fixed for the
+// life of the account, so a later change to the defaults never rewrites the
+// terms of accounts already open.
+type Terms struct {
+	Product Product
+	// MinimumBalance is the balance a savings account may not be drawn below.
+	MinimumBalance money.Money
+	// OverdraftLimit is how far below zero a current account may go.
+	OverdraftLimit money.Money
+	// InterestRateBPS is the annual interest rate in basis points.
+	InterestRateBPS int64
+	// LockInDays is how long a fixed deposit is held before withdrawals are
+	// free of PenaltyBPS, which is charged on the amount withdrawn.
+	LockInDays int
+	PenaltyBPS int64
+}
+
+func DefaultTerms(product Product) Terms {
+	switch product {
+	case ProductSavings:
+		return Terms{
+			Product:         ProductSavings,
+			MinimumBalance:  money.MustFromMajor(500, money.INR),
+			InterestRateBPS: 350,
+		}
+	case ProductCurrent:
+		return Terms{
+			Product:        ProductCurrent,
+			OverdraftLimit: money.MustFromMajor(10000, money.INR),
+		}
+	case ProductFixedDeposit:
+		return Terms{
+			Product:         ProductFixedDeposit,
+			InterestRateBPS: 700,
+			LockInDays:      365,
+			PenaltyBPS:      100,
+		}
+	}
+	return Terms{Product: product}
+}
+
+// Convert reprices the terms' amounts with convert, so that defaults set in
+// one currency can open an account in another.
+func (t Terms) Convert(convert func(money.Money) (money.Money, error)) (Terms, error) {
+	for _, m := range []*money.Money{&t.MinimumBalance, &t.OverdraftLimit} {
+		if m.IsZero() {
+			continue
+		}
+		var err error
+		if *m, err = convert(*m); err != nil {
+			return Terms{}, err
+		}
+	}
+	return t, nil
+}
+
+func (t Terms) checkCurrency(currency string) error {
+	for _, m := range []money.Money{t.MinimumBalance, t.OverdraftLimit} {
+		if !m.IsZero() && m.Currency != currency {
+			return apperror.NewValidationError("terms", fmt.Sprintf("are in %s, not %s", m.Currency, currency))
+		}
+	}
+	return nil
+}
+
+// floor is the lowest balance a debit may leave behind.
+func (t Terms) floor() money.Money {
+	switch t.Product {
+	case ProductSavings:
+		return t.MinimumBalance
+	case ProductCurrent:
+		return t.OverdraftLimit.Neg()
+	}
+	return money.Money{}
+}
+
+func (t Terms) validateOpeningDeposit(deposit money.Money) error {
+	if deposit.IsNegative() {
+		return apperror.NewValidationError("openingDeposit", "cannot be negative")
+	}
+	switch t.Product {
+	case ProductSavings:
+		short, err := deposit.LessThan(t.MinimumBalance)
+		if err != nil {
+			return err
+		}
+		if short {
+			return apperror.NewValidationError("openingDeposit", fmt.Sprintf("savings accounts open with at least %s", t.MinimumBalance))
+		}
+	case ProductFixedDeposit:
+		if !deposit.IsPositive() {
+			return apperror.NewValidationError("openingDeposit", "fixed deposits open with a positive amount")
+		}
+	}
+	return nil
+}
+
+// MaturesAt is when a fixed deposit's lock-in ends. Other products never
+// lock, so it is their opening time.
+func (a *Account) MaturesAt() time.Time {
+	return a.OpenedAt.AddDate(0, 0, a.Terms.LockInDays)
+}
+
+// checkCredit keeps customers from adding money to a fixed deposit once it
+// has been opened.
+func (a *Account) checkCredit(txnType TransactionType) error {
+	if a.Terms.Product != ProductFixedDeposit {
+		return nil
+	}
+	switch txnType {
+	case TxnDeposit, TxnInternalTransferIn, TxnExternalTransferIn:
+		return apperror.NewAccountError("credit", fmt.Sprintf("fixed deposit %d accepts no money after opening", a.AccountID))
+	}
+	return nil
+}
+
+// prematurePenalty is what withdrawing amount from a fixed deposit costs
+// before it matures.
+func (a *Account) prematurePenalty(amount money.Money, at time.Time) (money.Money, error) {
+	if a.Terms.Product != ProductFixedDeposit || !at.Before(a.MaturesAt()) {
+		return money.Money{}, nil
+	}
+	return amount.MulRat(a.Terms.PenaltyBPS, 10000, money.RoundHalfUp)
+}
@@ -0,0 +1,122 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testHolder = 7
+
+// openTestAccount opens an account under an ID nothing else has used, on
+// books with no limits so only the product's rules apply.
+func openTestAccount(t *testing.T, books *Books, product Product, deposit money.Money) *Account {
+	t.Helper()
+	id := 800000 + 100*int(runs.Add(1))
+	number, err := FormatNumber("SBIN", "000001", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc, err := NewAccount(books, id, number, testHolder, 1, 1, money.INR, DefaultTerms(product), deposit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acc
+}
+
+func inr(major int64) money.Money { return money.MustFromMajor(major, money.INR) }
+
+func noLimits() *Books {
+	return NewBooks(nil, func(*Account, time.Time) (Limits, error) { return Limits{}, nil })
+}
+
+func wantShort(t *testing.T, err error, what string) {
+	t.Helper()
+	var short *apperror.InsufficientFundsError
+	if !errors.As(err, &short) {
+		t.Errorf("%s: err = %v, want insufficient funds", what, err)
+	}
+}
+
+func TestSavingsKeepTheMinimumBalance(t *testing.T) {
+	id := 800000 + 100*int(runs.Add(1))
+	number, err := FormatNumber("SBIN", "000001", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewAccount(noLimits(), id, number, testHolder, 1, 1, money.INR, DefaultTerms(ProductSavings), money.New(49999, money.INR))
+	var invalid *apperror.ValidationError
+	if !errors.As(err, &invalid) || !strings.Contains(err.Error(), "openingDeposit") {
+		t.Errorf("opening below the minimum balance: err = %v, want the opening deposit refused", err)
+	}
+	openTestAccount(t, noLimits(), ProductSavings, inr(500))
+	acc := openTestAccount(t, noLimits(), ProductSavings, inr(1000))
+
+	wantShort(t, acc.WithdrawMoney(testHolder, money.New(50001, money.INR)), "withdrawal below the minimum")
+	if err := acc.WithdrawMoney(testHolder, inr(500)); err != nil {
+		t.Fatalf("withdrawal down to the minimum: %v", err)
+	}
+	if got := acc.GetBalance(); got != inr(500) {
+		t.Errorf("balance = %s, want %s", got, inr(500))
+	}
+}
+
+func TestCurrentAccountsDrawOnTheirOverdraft(t *testing.T) {
+	acc := openTestAccount(t, noLimits(), ProductCurrent, inr(0))
+
+	if err := acc.WithdrawMoney(testHolder, inr(10000)); err != nil {
+		t.Fatalf("withdrawal up to the overdraft limit: %v", err)
+	}
+	if got := acc.GetBalance(); got != inr(-10000) {
+		t.Errorf("balance = %s, want %s", got, inr(-10000))
+	}
+	wantShort(t, acc.WithdrawMoney(testHolder, money.New(1, money.INR)), "withdrawal past the overdraft limit")
+	if err := acc.DepositMoney(testHolder, inr(2500)); err != nil {
+		t.Fatal(err)
+	}
+	if got := acc.GetBalance(); got != inr(-7500) {
+		t.Errorf("balance = %s after repaying, want %s", got, inr(-7500))
+	}
+}
+
+func TestFixedDepositLockIn(t *testing.T) {
+	opened := time.Date(2025, time.March, 14, 10, 0, 0, 0, time.UTC)
+	now := opened
+	books := noLimits()
+	books.SetClock(func() time.Time { return now })
+	acc := openTestAccount(t, books, ProductFixedDeposit, inr(100000))
+
+	if want := opened.AddDate(0, 0, 365); !acc.MaturesAt().Equal(want) {
+		t.Errorf("matures at %s, want %s", acc.MaturesAt(), want)
+	}
+	if err := acc.DepositMoney(testHolder, inr(100)); err == nil {
+		t.Error("added money to a fixed deposit after opening")
+	}
+
+	// Before maturity every withdrawal pays 1% on top, rounded half up.
+	now = opened.AddDate(0, 6, 0)
+	if err := acc.WithdrawMoney(testHolder, money.New(1000050, money.INR)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := acc.GetBalance(), money.New(10000000-1000050-10001, money.INR); got != want {
+		t.Errorf("balance = %s after a premature withdrawal, want %s", got, want)
+	}
+	passbook := acc.GetPassbook()
+	if last := passbook[len(passbook)-1]; last.Type != TxnPenalty || last.Amount != money.New(10001, money.INR) {
+		t.Errorf("last entry = %s of %s, want a penalty of %s", last.Type, last.Amount, money.New(10001, money.INR))
+	}
+	// The penalty has to fit in the balance as well.
+	wantShort(t, acc.WithdrawMoney(testHolder, acc.GetBalance()), "withdrawing everything before maturity")
+
+	now = acc.MaturesAt()
+	before := acc.GetBalance()
+	if err := acc.WithdrawMoney(testHolder, inr(1000)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := acc.GetBalance(), money.New(before.Amount-100000, money.INR); got != want {
+		t.Errorf("balance = %s after a withdrawal at maturity, want %s", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Freeze is what an account is frozen against. Only transactions the
+// customer makes are refused; the bank still credits interest and charges
+// penalties.
+type Freeze string
+
+const (
+	FreezeNone    Freeze = ""
+	FreezeDebits  Freeze = "debits"
+	FreezeCredits Freeze = "credits"
+	FreezeFull    Freeze = "full"
+)
+
+func ParseFreeze(s string) (Freeze, error) {
+	switch f := Freeze(s); f {
+	case FreezeNone, FreezeDebits, FreezeCredits, FreezeFull:
+		return f, nil
+	}
+	return "", apperror.NewValidationError("freeze", fmt.Sprintf("unknown freeze %q", s))
+}
+
+func (f Freeze) blocks(txnType TransactionType) bool {
+	switch txnType {
+	case TxnDeposit, TxnInternalTransferIn, TxnExternalTransferIn:
+		return f == FreezeCredits || f == FreezeFull
+	case TxnWithdrawal, TxnInternalTransferOut, TxnExternalTransferOut, TxnClosure:
+		return f == FreezeDebits || f == FreezeFull
+	}
+	return false
+}
+
+func (f Freeze) against() string {
+	if f == FreezeFull {
+		return "all transactions"
+	}
+	return string(f)
+}
+
+// Lien earmarks Amount of the balance, for example under a court order, so
+// it cannot be withdrawn or transferred until the lien expires or is
+// released. A zero ExpiresAt holds until release.
+type Lien struct {
+	LienID     int
+	Amount     money.Money
+	Reason     string
+	PlacedBy   int
+	PlacedAt   time.Time
+	ExpiresAt  time.Time
+	ReleasedBy int
+	ReleasedAt time.Time
+}
+
+func (l Lien) ActiveAt(at time.Time) bool {
+	return l.ReleasedAt.IsZero() && (l.ExpiresAt.IsZero() || at.Before(l.ExpiresAt))
+}
+
+// SetFreeze freezes the account against freeze with reason, or unfreezes it
+// with FreezeNone.
+func (a *Account) SetFreeze(freeze Freeze, reason string) error {
+	if _, err := ParseFreeze(string(freeze)); err != nil {
+		return err
+	}
+	reason = strings.TrimSpace(reason)
+	if freeze != FreezeNone && reason == "" {
+		return apperror.NewValidationError("reason", "a freeze needs a reason")
+	}
+	if freeze == FreezeNone {
+		reason = ""
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Freeze = freeze
+	a.FreezeReason = reason
+	return nil
+}
+
+// PlaceLien adds lien to the account under the next lien ID. It may hold
+// more than the balance; the excess holds money credited later.
+func (a *Account) PlaceLien(lien Lien) (Lien, error) {
+	lien.Reason = strings.TrimSpace(lien.Reason)
+	if lien.Reason == "" {
+		return Lien{}, apperror.NewValidationError("reason", "a lien needs a reason")
+	}
+	if !lien.Amount.IsPositive() {
+		return Lien{}, apperror.NewValidationError("amount", "must be greater than 0")
+	}
+	if !lien.ExpiresAt.IsZero() && !lien.ExpiresAt.After(lien.PlacedAt) {
+		return Lien{}, apperror.NewValidationError("expiresAt", "must be in the future")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.Balance.Sub(lien.Amount); err != nil {
+		return Lien{}, err
+	}
+	lien.LienID = len(a.Liens) + 1
+	a.Liens = append(a.Liens, lien)
+	return lien, nil
+}
+
+func (a *Account) ReleaseLien(lienID, releasedBy int, at time.Time) (Lien, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if lienID < 1 || lienID > len(a.Liens) {
+		return Lien{}, apperror.NewNotFoundError("lien", lienID)
+	}
+	lien := &a.Liens[lienID-1]
+	if !lien.ActiveAt(at) {
+		return Lien{}, apperror.NewValidationError("lien", fmt.Sprintf("lien %d is no longer active", lienID))
+	}
+	lien.ReleasedBy = releasedBy
+	lien.ReleasedAt = at
+	return *lien, nil
+}
+
+func (a *Account) GetLiens() []Lien {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Lien(nil), a.Liens...)
+}
+
+// AvailableBalance is what the customer can draw: the balance less what
+// active liens hold, down to the product's floor, or nothing when liens
+// hold more than that. Withdrawal limits and a fixed deposit's premature
+// penalty are not taken off.
+func (a *Account) AvailableBalance() (money.Money, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	held, err := a.heldAt(a.books.currentTime())
+	if err != nil {
+		return money.Money{}, err
+	}
+	if held, err = held.Add(a.Terms.floor()); err != nil {
+		return money.Money{}, err
+	}
+	available, err := a.Balance.Sub(held)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if available.IsNegative() {
+		return money.Zero(a.Currency), nil
+	}
+	return available, nil
+}
+
+// heldAt is the total of the liens active at at. It expects a.mu to be
+// held.
+func (a *Account) heldAt(at time.Time) (money.Money, error) {
+	var held money.Money
+	for _, l := range a.Liens {
+		if !l.ActiveAt(at) {
+			continue
+		}
+		var err error
+		if held, err = held.Add(l.Amount); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return held, nil
+}
+
+// checkFreeze expects a.mu to be held.
+func (a *Account) checkFreeze(txnType TransactionType) error {
+	if a.Freeze.blocks(txnType) {
+		return apperror.NewFrozenError(a.AccountID, a.Freeze.against())
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFreezeRefusesOnlyWhatItIsAgainst(t *testing.T) {
+	tests := []struct {
+		freeze                Freeze
+		creditsOK, withdrawOK bool
+	}{
+		{FreezeNone, true, true},
+		{FreezeDebits, true, false},
+		{FreezeCredits, false, true},
+		{FreezeFull, false, false},
+	}
+	for _, tc := range tests {
+		acc := openTestAccount(t, noLimits(), ProductSavings, inr(10000))
+		if err := acc.SetFreeze(tc.freeze, "court order"); err != nil {
+			t.Fatal(err)
+		}
+		for _, op := range []struct {
+			name string
+			ok   bool
+			do   func() error
+		}{
+			{"deposit", tc.creditsOK, func() error { return acc.DepositMoney(testHolder, inr(100)) }},
+			{"withdrawal", tc.withdrawOK, func() error { return acc.WithdrawMoney(testHolder, inr(100)) }},
+		} {
+			err := op.do()
+			var frozen *apperror.FrozenError
+			switch {
+			case op.ok && err != nil:
+				t.Errorf("%q freeze: %s refused: %v", tc.freeze, op.name, err)
+			case !op.ok && !errors.As(err, &frozen):
+				t.Errorf("%q freeze: %s err = %v, want frozen", tc.freeze, op.name, err)
+			}
+		}
+	}
+
+	acc := openTestAccount(t, noLimits(), ProductSavings, inr(10000))
+	if err := acc.SetFreeze(FreezeDebits, " "); err == nil {
+		t.Error("froze an account without a reason")
+	}
+	if err := acc.SetFreeze(FreezeFull, "court order"); err != nil {
+		t.Fatal(err)
+	}
+	if err := acc.SetFreeze(FreezeNone, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := acc.WithdrawMoney(testHolder, inr(100)); err != nil {
+		t.Errorf("withdrawal after unfreezing: %v", err)
+	}
+}
+
+func TestLiensHoldTheAvailableBalance(t *testing.T) {
+	placed := time.Date(2025, time.March, 14, 10, 0, 0, 0, time.UTC)
+	now := placed
+	books := noLimits()
+	books.SetClock(func() time.Time { return now })
+	acc := openTestAccount(t, books, ProductSavings, inr(10000))
+
+	wantAvailable := func(want money.Money) {
+		t.Helper()
+		got, err := acc.AvailableBalance()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("available = %s, want %s", got, want)
+		}
+	}
+	if _, err := acc.PlaceLien(Lien{Amount: inr(1000), PlacedAt: placed}); err == nil {
+		t.Error("placed a lien without a reason")
+	}
+	if _, err := acc.PlaceLien(Lien{Amount: inr(1000), Reason: "loan", PlacedAt: placed, ExpiresAt: placed}); err == nil {
+		t.Error("placed a lien that has already expired")
+	}
+	court, err := acc.PlaceLien(Lien{Amount: inr(3000), Reason: "court order", PlacedAt: placed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loan, err := acc.PlaceLien(Lien{Amount: inr(1000), Reason: "loan", PlacedAt: placed, ExpiresAt: placed.AddDate(0, 1, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The liens sit above the minimum balance of ₹500, and what is
+	// available is exactly what can be withdrawn.
+	wantAvailable(inr(5500))
+	wantShort(t, acc.WithdrawMoney(testHolder, money.New(550001, money.INR)), "withdrawal into the liens")
+	if err := acc.WithdrawMoney(testHolder, inr(5500)); err != nil {
+		t.Fatalf("withdrawal down to the liens: %v", err)
+	}
+	wantAvailable(inr(0))
+
+	now = loan.ExpiresAt
+	wantAvailable(inr(1000))
+	if _, err := acc.ReleaseLien(loan.LienID, 1, now); err == nil {
+		t.Error("released a lien that had expired")
+	}
+	if _, err := acc.ReleaseLien(court.LienID, 1, now); err != nil {
+		t.Fatal(err)
+	}
+	wantAvailable(inr(4000))
+	if _, err := acc.ReleaseLien(court.LienID, 1, now); err == nil {
+		t.Error("released a lien twice")
+	}
+
+	// A lien may hold more than the balance, leaving nothing available.
+	if _, err := acc.PlaceLien(Lien{Amount: inr(6000), Reason: "court order", PlacedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	wantAvailable(inr(0))
+	wantShort(t, acc.WithdrawMoney(testHolder, money.New(1, money.INR)), "withdrawal under a lien larger than the balance")
+
+	// A current account can draw on its overdraft.
+	current := openTestAccount(t, books, ProductCurrent, inr(2000))
+	if _, err := current.PlaceLien(Lien{Amount: inr(500), Reason: "court order", PlacedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := current.AvailableBalance(); err != nil || got != inr(11500) {
+		t.Errorf("current account available = %s, %v; want %s", got, err, inr(11500))
+	}
+	wantShort(t, current.WithdrawMoney(testHolder, money.New(1150001, money.INR)), "withdrawal past the overdraft and lien")
+	if err := current.WithdrawMoney(testHolder, inr(11500)); err != nil {
+		t.Errorf("withdrawing all that is available: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"fmt"
+	"time"
+)
+
+// Snapshot is the persistable state of an account, without its passbook.
+type Snapshot struct {
+	AccountID int
+	Number    string
+	BankID    int
+	BranchID  int
+	OwnerID   int
+	Currency  string
+	Balance   money.Money
+	Terms     Terms
+	OpenedAt  time.Time
+	IsActive  bool
+
+	JointHolders    []int
+	Mode            OperatingMode
+	RequiredHolders int
+
+	AccruedInterest money.Money
+	LastAccrual     time.Time
+
+	Freeze       Freeze
+	FreezeReason string
+	Liens        []Lien
+
+	Nominees []Nominee
+}
+
+func (a *Account) Snapshot() Snapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.snapshot()
+}
+
+// snapshot expects a.mu to be held.
+func (a *Account) snapshot() Snapshot {
+	return Snapshot{
+		AccountID: a.AccountID,
+		Number:    a.Number,
+		BankID:    a.BankID,
+		BranchID:  a.BranchID,
+		OwnerID:   a.OwnerID,
+		Currency:  a.Currency,
+		Balance:   a.Balance,
+		Terms:     a.Terms,
+		OpenedAt:  a.OpenedAt,
+		IsActive:  a.IsActive,
+
+		JointHolders:    append([]int(nil), a.JointHolders...),
+		Mode:            a.Mode,
+		RequiredHolders: a.RequiredHolders,
+
+		AccruedInterest: a.AccruedInterest,
+		LastAccrual:     a.LastAccrual,
+
+		Freeze:       a.Freeze,
+		FreezeReason: a.FreezeReason,
+		Liens:        append([]Lien(nil), a.Liens...),
+
+		Nominees: append([]Nominee(nil), a.Nominees...),
+	}
+}
+
+// PassbookSince returns the passbook entries recorded after the first n.
+func (a *Account) PassbookSince(n int) []Transaction {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if n >= len(a.Passbook) {
+		return nil
+	}
+	entries := make([]Transaction, len(a.Passbook)-n)
+	copy(entries, a.Passbook[n:])
+	return entries
+}
+
+// Restore rebuilds an account kept in books from storage and registers it,
+// replacing any account already registered under the same ID.
+func Restore(books *Books, s Snapshot, passbook []Transaction) (*Account, error) {
+	if s.AccountID <= 0 {
+		return nil, apperror.NewValidationError("accountID", "must be greater than 0")
+	}
+	// Accounts stored before products existed behave as savings accounts
+	// without a minimum balance.
+	if s.Terms.Product == "" {
+		s.Terms = Terms{Product: ProductSavings}
+	}
+	// Accounts stored before currencies were kept are in their balance's
+	// currency, which was always rupees.
+	if s.Currency == "" {
+		s.Currency = s.Balance.Currency
+	}
+	if s.Currency == "" {
+		s.Currency = money.INR
+	}
+	if s.OpenedAt.IsZero() && len(passbook) > 0 {
+		s.OpenedAt = passbook[0].Timestamp
+	}
+	acc := &Account{
+		AccountID: s.AccountID,
+		Number:    s.Number,
+		BankID:    s.BankID,
+		BranchID:  s.BranchID,
+		OwnerID:   s.OwnerID,
+		Currency:  s.Currency,
+		Balance:   s.Balance,
+		Terms:     s.Terms,
+		OpenedAt:  s.OpenedAt,
+		IsActive:  s.IsActive,
+		Passbook:  append([]Transaction(nil), passbook...),
+
+		AccruedInterest: s.AccruedInterest,
+		LastAccrual:     s.LastAccrual,
+		Freeze:          s.Freeze,
+		FreezeReason:    s.FreezeReason,
+		Liens:           append([]Lien(nil), s.Liens...),
+		JointHolders:    append([]int(nil), s.JointHolders...),
+		Mode:            s.Mode,
+		RequiredHolders: s.RequiredHolders,
+		Nominees:        append([]Nominee(nil), s.Nominees...),
+		books:           books,
+	}
+	for _, txn := range passbook {
+		var seq int64
+		if _, err := fmt.Sscanf(txn.ReferenceID, "TXN%d", &seq); err == nil {
+			advanceReferenceCounter(seq)
+		}
+	}
+
+	accountsMu.Lock()
+	defer accountsMu.Unlock()
+	accounts[acc.AccountID] = acc
+	if acc.Number != "" {
+		accountsByNumber[acc.Number] = acc
+	}
+	return acc, nil
+}
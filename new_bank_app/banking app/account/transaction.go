@@ -0,0 +1,74 @@
+package account
+
+import (
+	"banking-app/fx"
+	"banking-app/money"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+type TransactionType string
+
+const (
+	TxnOpening             TransactionType = "OPENING"
+	TxnDeposit             TransactionType = "DEPOSIT"
+	TxnWithdrawal          TransactionType = "WITHDRAWAL"
+	TxnInternalTransferIn  TransactionType = "INTERNAL_TRANSFER_IN"
+	TxnInternalTransferOut TransactionType = "INTERNAL_TRANSFER_OUT"
+	TxnExternalTransferIn  TransactionType = "EXTERNAL_TRANSFER_IN"
+	TxnExternalTransferOut TransactionType = "EXTERNAL_TRANSFER_OUT"
+	TxnPenalty             TransactionType = "PENALTY"
+	TxnInterestAccrual     TransactionType = "INTEREST_ACCRUAL"
+	TxnInterestCredit      TransactionType = "INTEREST_CREDIT"
+	TxnAdjustment          TransactionType = "ADJUSTMENT"
+	TxnClosure             TransactionType = "CLOSURE"
+)
+
+type Transaction struct {
+	ReferenceID           string
+	Type                  TransactionType
+	Timestamp             time.Time
+	CounterpartyAccountID int
+	Amount                money.Money
+	Balance               money.Money
+	// FX is the quote a transfer between currencies was converted at, and
+	// CounterpartyAmount what the other account was debited or credited in
+	// its own currency. Both are unset for transfers in one currency.
+	FX                 *fx.Quote
+	CounterpartyAmount money.Money
+}
+
+var referenceCounter atomic.Int64
+
+func nextReferenceID() string {
+	return fmt.Sprintf("TXN%08d", referenceCounter.Add(1))
+}
+
+func advanceReferenceCounter(seq int64) {
+	for {
+		current := referenceCounter.Load()
+		if seq <= current || referenceCounter.CompareAndSwap(current, seq) {
+			return
+		}
+	}
+}
+
+func (a *Account) recordTransaction(referenceID string, txnType TransactionType, counterpartyID int, amount money.Money) {
+	a.Passbook = append(a.Passbook, Transaction{
+		ReferenceID:           referenceID,
+		Type:                  txnType,
+		Timestamp:             a.books.currentTime(),
+		CounterpartyAccountID: counterpartyID,
+		Amount:                amount,
+		Balance:               a.Balance,
+	})
+}
+
+func (a *Account) GetPassbook() []Transaction {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	passbook := make([]Transaction, len(a.Passbook))
+	copy(passbook, a.Passbook)
+	return passbook
+}
@@ -0,0 +1,147 @@
+package account
+
+import (
+	"banking-app/apperror"
+	"banking-app/fx"
+	"banking-app/journal"
+	"banking-app/money"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// The account registry outlives a test, so every run opens its accounts
+// under IDs nothing else has used.
+var runs atomic.Int64
+
+// TestParallelTransfersKeepTheMoneySupply is meant for go test -race: it
+// moves money at random between accounts at two banks from many goroutines
+// and checks that nothing is created or lost on the way, and that each
+// bank's books still balance on their own.
+func TestParallelTransfersKeepTheMoneySupply(t *testing.T) {
+	const (
+		owner     = 7
+		perBank   = 4
+		workers   = 8
+		transfers = 300
+	)
+	ledger := journal.New()
+	books := NewBooks(ledger, func(*Account, time.Time) (Limits, error) { return Limits{}, nil })
+
+	base := 900000 + 100*int(runs.Add(1))
+	var accs []*Account
+	supply := money.Zero(money.INR)
+	for i := range 2 * perBank {
+		bankID, code := 1, "SBIN"
+		if i >= perBank {
+			bankID, code = 2, "BARB"
+		}
+		id := base + i
+		deposit := money.MustFromMajor(10000, money.INR)
+		number, err := FormatNumber(code, "000001", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		acc, err := NewAccount(books, id, number, owner, bankID, 1, money.INR, DefaultTerms(ProductSavings), deposit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accs = append(accs, acc)
+		if supply, err = supply.Add(deposit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var moved atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range transfers {
+				from, to := accs[rand.IntN(len(accs))], accs[rand.IntN(len(accs))]
+				if from == to {
+					continue
+				}
+				amount := money.New(rand.Int64N(300000)+1, money.INR)
+				// The owner holds every account, so a transfer across banks
+				// can go either way.
+				var err error
+				if from.BankID == to.BankID || rand.IntN(2) == 0 {
+					err = TransferMoneyInternally(owner, from.AccountID, to.AccountID, amount, fx.Identity(money.INR))
+				} else {
+					err = from.TransferMoneyToExternal(to.AccountID, owner, owner, amount, fx.Identity(money.INR))
+				}
+				var short *apperror.InsufficientFundsError
+				switch {
+				case err == nil:
+					moved.Add(1)
+				case !errors.As(err, &short):
+					t.Errorf("transfer %d -> %d: %v", from.AccountID, to.AccountID, err)
+					return
+				}
+				// Readers run alongside the writers.
+				_ = to.GetBalance()
+				_ = from.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+	if moved.Load() == 0 {
+		t.Fatal("no transfer went through")
+	}
+
+	total := money.Zero(money.INR)
+	entries := 0
+	// Each bank's vault and clearing account, debits, net off against what
+	// it owes its own customers, credits.
+	net := make(map[int]money.Money)
+	for _, bankID := range []int{1, 2} {
+		var err error
+		if net[bankID], err = ledger.Balance(journal.Vault(bankID), money.INR).Add(ledger.Balance(journal.Clearing(bankID), money.INR)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, acc := range accs {
+		balance := acc.GetBalance()
+		passbook := acc.GetPassbook()
+		if last := passbook[len(passbook)-1].Balance; last != balance {
+			t.Errorf("account %d: passbook ends at %s, balance is %s", acc.AccountID, last, balance)
+		}
+		// The bank owes its customers their balances, so they are credits.
+		if got := ledger.Balance(journal.Customer(acc.AccountID), money.INR).Neg(); got != balance {
+			t.Errorf("account %d: journal owes %s, balance is %s", acc.AccountID, got, balance)
+		}
+		entries += len(passbook)
+		var err error
+		if total, err = total.Add(balance); err != nil {
+			t.Fatal(err)
+		}
+		if net[acc.BankID], err = net[acc.BankID].Sub(balance); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for bankID, n := range net {
+		if !n.IsZero() {
+			t.Errorf("bank %d: books are out by %s", bankID, n)
+		}
+	}
+	if total != supply {
+		t.Errorf("money supply = %s after transfers, want %s", total, supply)
+	}
+	if want := len(accs) + 2*int(moved.Load()); entries != want {
+		t.Errorf("passbooks hold %d entries, want %d for %d transfers", entries, want, moved.Load())
+	}
+	trial, err := ledger.TrialBalances()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tb := range trial {
+		if !tb.Balanced() {
+			t.Errorf("journal out of balance: %+v", tb)
+		}
+	}
+}
@@ -1,213 +1,659 @@
-package apperror
-
-import (
-	"fmt"
-	"net/http"
-)
-
-type BankError struct {
-	Err        error
-	StatusCode int
-	Message    string
-}
-
-func (e *BankError) Error() string {
-	if e.Err != nil {
-		return fmt.Sprintf("BankError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
-	}
-	return fmt.Sprintf("BankError (code: %d): %s", e.StatusCode, e.Message)
-}
-
-func (e *BankError) Unwrap() error {
-	return e.Err
-}
-
-func NewBankError(action, reason string, cause ...error) *BankError {
-	msg := fmt.Sprintf("bank error during %s", action)
-	var errCause error
-	if len(cause) > 0 {
-		errCause = cause[0]
-	}
-	return &BankError{
-		Err:        errCause,
-		StatusCode: http.StatusConflict,
-		Message:    fmt.Sprintf("%s: %s", msg, reason),
-	}
-}
-
-type CustomerError struct {
-	Err        error
-	StatusCode int
-	Message    string
-}
-
-func (e *CustomerError) Error() string {
-	if e.Err != nil {
-		return fmt.Sprintf("CustomerError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
-	}
-	return fmt.Sprintf("CustomerError (code: %d): %s", e.StatusCode, e.Message)
-}
-
-func (e *CustomerError) Unwrap() error {
-	return e.Err
-}
-
-func NewCustomerError(action, reason string, cause ...error) *CustomerError {
-	msg := fmt.Sprintf("customer error during %s", action)
-	var errCause error
-	if len(cause) > 0 {
-		errCause = cause[0]
-	}
-	return &CustomerError{
-		Err:        errCause,
-		StatusCode: http.StatusBadRequest,
-		Message:    fmt.Sprintf("%s: %s", msg, reason),
-	}
-}
-
-type AccountError struct {
-	Err        error
-	StatusCode int
-	Message    string
-}
-
-func (e *AccountError) Error() string {
-	if e.Err != nil {
-		return fmt.Sprintf("AccountError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
-	}
-	return fmt.Sprintf("AccountError (code: %d): %s", e.StatusCode, e.Message)
-}
-
-func (e *AccountError) Unwrap() error {
-	return e.Err
-}
-
-func NewAccountError(action, reason string, cause ...error) *AccountError {
-	msg := fmt.Sprintf("account error during %s", action)
-	var errCause error
-	if len(cause) > 0 {
-		errCause = cause[0]
-	}
-	return &AccountError{
-		Err:        errCause,
-		StatusCode: http.StatusUnprocessableEntity,
-		Message:    fmt.Sprintf("%s: %s", msg, reason),
-	}
-}
-
-type NotFoundError struct {
-	Err        error
-	StatusCode int
-	Message    string
-}
-
-func (e *NotFoundError) Error() string {
-	if e.Err != nil {
-		return fmt.Sprintf("NotFoundError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
-	}
-	return fmt.Sprintf("NotFoundError (code: %d): %s", e.StatusCode, e.Message)
-}
-
-func (e *NotFoundError) Unwrap() error {
-	return e.Err
-}
-
-func NewNotFoundError(resource string, id int, cause ...error) *NotFoundError {
-	var errCause error
-	if len(cause) > 0 {
-		errCause = cause[0]
-	}
-	return &NotFoundError{
-		Err:        errCause,
-		StatusCode: http.StatusNotFound,
-		Message:    fmt.Sprintf("%s with ID %d not found", resource, id),
-	}
-}
-
-type ValidationError struct {
-	Err        error
-	StatusCode int
-	Message    string
-}
-
-func (e *ValidationError) Error() string {
-	if e.Err != nil {
-		return fmt.Sprintf("ValidationError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
-	}
-	return fmt.Sprintf("ValidationError (code: %d): %s", e.StatusCode, e.Message)
-}
-
-func (e *ValidationError) Unwrap() error {
-	return e.Err
-}
-
-func NewValidationError(fieldName, message string, cause ...error) *ValidationError {
-	var errCause error
-	if len(cause) > 0 {
-		errCause = cause[0]
-	}
-	return &ValidationError{
-		Err:        errCause,
-		StatusCode: http.StatusBadRequest,
-		Message:    fmt.Sprintf("invalid %s: %s", fieldName, message),
-	}
-}
-
-type AuthError struct {
-	Err        error
-	StatusCode int
-	Message    string
-}
-
-func (e *AuthError) Error() string {
-	if e.Err != nil {
-		return fmt.Sprintf("AuthError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
-	}
-	return fmt.Sprintf("AuthError (code: %d): %s", e.StatusCode, e.Message)
-}
-
-func (e *AuthError) Unwrap() error {
-	return e.Err
-}
-
-func NewAuthError(action string, cause ...error) *AuthError {
-	var errCause error
-	if len(cause) > 0 {
-		errCause = cause[0]
-	}
-	return &AuthError{
-		Err:        errCause,
-		StatusCode: http.StatusUnauthorized,
-		Message:    fmt.Sprintf("unauthorized access while trying to %s", action),
-	}
-}
-
-type UserError struct {
-	Err        error
-	StatusCode int
-	Message    string
-}
-
-func (e *UserError) Error() string {
-	if e.Err != nil {
-		return fmt.Sprintf("UserError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
-	}
-	return fmt.Sprintf("UserError (code: %d): %s", e.StatusCode, e.Message)
-}
-
-func (e *UserError) Unwrap() error {
-	return e.Err
-}
-
-func NewUserError(action, reason string, cause ...error) *UserError {
-	msg := fmt.Sprintf("user error during %s", action)
-	var errCause error
-	if len(cause) > 0 {
-		errCause = cause[0]
-	}
-	return &UserError{
-		Err:        errCause,
-		StatusCode: http.StatusBadRequest,
-		Message:    fmt.Sprintf("%s: %s", msg, reason),
-	}
-}
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sentinel kinds for errors.Is. Each error type below that stands for one of
+// these kinds reports it, so callers can classify a failure without knowing
+// which concrete type carried it.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrInactive          = errors.New("inactive")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrLimitExceeded     = errors.New("limit exceeded")
+	ErrHeldForReview     = errors.New("held for review")
+	ErrPendingApproval   = errors.New("pending approval")
+	ErrBlocked           = errors.New("blocked")
+	ErrFrozen            = errors.New("frozen")
+)
+
+type BankError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *BankError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("BankError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("BankError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *BankError) Unwrap() error {
+	return e.Err
+}
+
+func NewBankError(action, reason string, cause ...error) *BankError {
+	msg := fmt.Sprintf("bank error during %s", action)
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &BankError{
+		Err:        errCause,
+		StatusCode: http.StatusConflict,
+		Message:    fmt.Sprintf("%s: %s", msg, reason),
+	}
+}
+
+type CustomerError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *CustomerError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("CustomerError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("CustomerError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *CustomerError) Unwrap() error {
+	return e.Err
+}
+
+func NewCustomerError(action, reason string, cause ...error) *CustomerError {
+	msg := fmt.Sprintf("customer error during %s", action)
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &CustomerError{
+		Err:        errCause,
+		StatusCode: http.StatusBadRequest,
+		Message:    fmt.Sprintf("%s: %s", msg, reason),
+	}
+}
+
+type AccountError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *AccountError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("AccountError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("AccountError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *AccountError) Unwrap() error {
+	return e.Err
+}
+
+func NewAccountError(action, reason string, cause ...error) *AccountError {
+	msg := fmt.Sprintf("account error during %s", action)
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &AccountError{
+		Err:        errCause,
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    fmt.Sprintf("%s: %s", msg, reason),
+	}
+}
+
+type NotFoundError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("NotFoundError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("NotFoundError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func NewNotFoundError(resource string, id int, cause ...error) *NotFoundError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &NotFoundError{
+		Err:        errCause,
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("%s with ID %d not found", resource, id),
+	}
+}
+
+// NewCodeNotFoundError is NewNotFoundError for things looked up by code
+// rather than ID.
+func NewCodeNotFoundError(resource, code string, cause ...error) *NotFoundError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &NotFoundError{
+		Err:        errCause,
+		StatusCode: http.StatusNotFound,
+		Message:    fmt.Sprintf("%s with code %s not found", resource, code),
+	}
+}
+
+type ValidationError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("ValidationError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("ValidationError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func NewValidationError(fieldName, message string, cause ...error) *ValidationError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &ValidationError{
+		Err:        errCause,
+		StatusCode: http.StatusBadRequest,
+		Message:    fmt.Sprintf("invalid %s: %s", fieldName, message),
+	}
+}
+
+type AuthError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *AuthError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("AuthError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("AuthError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+func (e *AuthError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+func NewAuthError(action string, cause ...error) *AuthError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &AuthError{
+		Err:        errCause,
+		StatusCode: http.StatusUnauthorized,
+		Message:    fmt.Sprintf("unauthorized access while trying to %s", action),
+	}
+}
+
+type ForbiddenError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *ForbiddenError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("ForbiddenError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("ForbiddenError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *ForbiddenError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ForbiddenError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+func NewForbiddenError(action string, cause ...error) *ForbiddenError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &ForbiddenError{
+		Err:        errCause,
+		StatusCode: http.StatusForbidden,
+		Message:    fmt.Sprintf("not permitted to %s", action),
+	}
+}
+
+type InactiveError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *InactiveError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("InactiveError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("InactiveError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *InactiveError) Unwrap() error {
+	return e.Err
+}
+
+func (e *InactiveError) Is(target error) bool {
+	return target == ErrInactive
+}
+
+func NewInactiveError(resource string, id int, cause ...error) *InactiveError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &InactiveError{
+		Err:        errCause,
+		StatusCode: http.StatusConflict,
+		Message:    fmt.Sprintf("%s with ID %d is inactive", resource, id),
+	}
+}
+
+// FrozenError refuses a transaction on an account frozen against it.
+type FrozenError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *FrozenError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("FrozenError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("FrozenError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *FrozenError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FrozenError) Is(target error) bool {
+	return target == ErrFrozen
+}
+
+// NewFrozenError takes what the account is frozen against, such as
+// "debits".
+func NewFrozenError(accountID int, against string, cause ...error) *FrozenError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &FrozenError{
+		Err:        errCause,
+		StatusCode: http.StatusConflict,
+		Message:    fmt.Sprintf("account with ID %d is frozen against %s", accountID, against),
+	}
+}
+
+type InsufficientFundsError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *InsufficientFundsError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("InsufficientFundsError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("InsufficientFundsError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *InsufficientFundsError) Unwrap() error {
+	return e.Err
+}
+
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+func NewInsufficientFundsError(accountID int, cause ...error) *InsufficientFundsError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &InsufficientFundsError{
+		Err:        errCause,
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    fmt.Sprintf("account with ID %d has insufficient funds", accountID),
+	}
+}
+
+// LimitExceededError names the limit a transaction would break and, for
+// limits that reset, when the account may try again.
+type LimitExceededError struct {
+	Err        error
+	StatusCode int
+	Message    string
+	Limit      string
+	ResetsAt   time.Time
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("LimitExceededError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("LimitExceededError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return e.Err
+}
+
+func (e *LimitExceededError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// NewLimitExceededError takes the limit as it should read in the message,
+// such as "daily withdrawal limit of INR 50000.00". A zero resetsAt means
+// the limit never resets.
+func NewLimitExceededError(accountID int, limit string, resetsAt time.Time, cause ...error) *LimitExceededError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	msg := fmt.Sprintf("account with ID %d would exceed its %s", accountID, limit)
+	if !resetsAt.IsZero() {
+		msg = fmt.Sprintf("%s; it resets at %s", msg, resetsAt.UTC().Format(time.RFC3339))
+	}
+	return &LimitExceededError{
+		Err:        errCause,
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    msg,
+		Limit:      limit,
+		ResetsAt:   resetsAt,
+	}
+}
+
+// HeldForReviewError reports that nothing went wrong yet: the request was
+// accepted but waits in the review queue under ReviewID.
+type HeldForReviewError struct {
+	Err        error
+	StatusCode int
+	Message    string
+	ReviewID   int
+}
+
+func (e *HeldForReviewError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("HeldForReviewError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("HeldForReviewError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *HeldForReviewError) Unwrap() error {
+	return e.Err
+}
+
+func (e *HeldForReviewError) Is(target error) bool {
+	return target == ErrHeldForReview
+}
+
+func NewHeldForReviewError(resource string, reviewID int, cause ...error) *HeldForReviewError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &HeldForReviewError{
+		Err:        errCause,
+		StatusCode: http.StatusAccepted,
+		Message:    fmt.Sprintf("%s is held for review as review %d", resource, reviewID),
+		ReviewID:   reviewID,
+	}
+}
+
+// PendingApprovalError reports that a debit from a joint account was
+// accepted but waits for the other holders to approve it as ApprovalID.
+type PendingApprovalError struct {
+	Err        error
+	StatusCode int
+	Message    string
+	ApprovalID int
+}
+
+func (e *PendingApprovalError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("PendingApprovalError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("PendingApprovalError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *PendingApprovalError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PendingApprovalError) Is(target error) bool {
+	return target == ErrPendingApproval
+}
+
+func NewPendingApprovalError(action string, approvalID, outstanding int, cause ...error) *PendingApprovalError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &PendingApprovalError{
+		Err:        errCause,
+		StatusCode: http.StatusAccepted,
+		Message:    fmt.Sprintf("%s waits for %d more holder(s) to approve it as approval %d", action, outstanding, approvalID),
+		ApprovalID: approvalID,
+	}
+}
+
+type BlockedError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *BlockedError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("BlockedError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("BlockedError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *BlockedError) Unwrap() error {
+	return e.Err
+}
+
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+func NewBlockedError(action, reason string, cause ...error) *BlockedError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &BlockedError{
+		Err:        errCause,
+		StatusCode: http.StatusForbidden,
+		Message:    fmt.Sprintf("%s was blocked: %s", action, reason),
+	}
+}
+
+type TooLargeError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *TooLargeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("TooLargeError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("TooLargeError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *TooLargeError) Unwrap() error {
+	return e.Err
+}
+
+func NewTooLargeError(what string, limit int64, cause ...error) *TooLargeError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &TooLargeError{
+		Err:        errCause,
+		StatusCode: http.StatusRequestEntityTooLarge,
+		Message:    fmt.Sprintf("%s must not be larger than %d bytes", what, limit),
+	}
+}
+
+// InternalError is a fault on the server's side, such as the system's
+// random source failing, that the caller can do nothing about.
+type InternalError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *InternalError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("InternalError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("InternalError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *InternalError) Unwrap() error {
+	return e.Err
+}
+
+func NewInternalError(action, reason string, cause ...error) *InternalError {
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &InternalError{
+		Err:        errCause,
+		StatusCode: http.StatusInternalServerError,
+		Message:    fmt.Sprintf("cannot %s: %s", action, reason),
+	}
+}
+
+type UserError struct {
+	Err        error
+	StatusCode int
+	Message    string
+}
+
+func (e *UserError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("UserError (code: %d): %s: %v", e.StatusCode, e.Message, e.Err)
+	}
+	return fmt.Sprintf("UserError (code: %d): %s", e.StatusCode, e.Message)
+}
+
+func (e *UserError) Unwrap() error {
+	return e.Err
+}
+
+func NewUserError(action, reason string, cause ...error) *UserError {
+	msg := fmt.Sprintf("user error during %s", action)
+	var errCause error
+	if len(cause) > 0 {
+		errCause = cause[0]
+	}
+	return &UserError{
+		Err:        errCause,
+		StatusCode: http.StatusBadRequest,
+		Message:    fmt.Sprintf("%s: %s", msg, reason),
+	}
+}
+
+// StatusCode returns the HTTP status carried by the first apperror type
+// found in err's chain, or 500 when err carries none.
+func StatusCode(err error) int {
+	var bankErr *BankError
+	var customerErr *CustomerError
+	var accountErr *AccountError
+	var notFoundErr *NotFoundError
+	var validationErr *ValidationError
+	var authErr *AuthError
+	var forbiddenErr *ForbiddenError
+	var inactiveErr *InactiveError
+	var frozenErr *FrozenError
+	var fundsErr *InsufficientFundsError
+	var limitErr *LimitExceededError
+	var heldErr *HeldForReviewError
+	var pendingErr *PendingApprovalError
+	var blockedErr *BlockedError
+	var tooLargeErr *TooLargeError
+	var userErr *UserError
+	var internalErr *InternalError
+	switch {
+	case errors.As(err, &notFoundErr):
+		return notFoundErr.StatusCode
+	case errors.As(err, &authErr):
+		return authErr.StatusCode
+	case errors.As(err, &forbiddenErr):
+		return forbiddenErr.StatusCode
+	case errors.As(err, &inactiveErr):
+		return inactiveErr.StatusCode
+	case errors.As(err, &frozenErr):
+		return frozenErr.StatusCode
+	case errors.As(err, &fundsErr):
+		return fundsErr.StatusCode
+	case errors.As(err, &limitErr):
+		return limitErr.StatusCode
+	case errors.As(err, &heldErr):
+		return heldErr.StatusCode
+	case errors.As(err, &pendingErr):
+		return pendingErr.StatusCode
+	case errors.As(err, &blockedErr):
+		return blockedErr.StatusCode
+	case errors.As(err, &tooLargeErr):
+		return tooLargeErr.StatusCode
+	case errors.As(err, &validationErr):
+		return validationErr.StatusCode
+	case errors.As(err, &accountErr):
+		return accountErr.StatusCode
+	case errors.As(err, &customerErr):
+		return customerErr.StatusCode
+	case errors.As(err, &userErr):
+		return userErr.StatusCode
+	case errors.As(err, &bankErr):
+		return bankErr.StatusCode
+	case errors.As(err, &internalErr):
+		return internalErr.StatusCode
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,225 @@
+package audit
+
+import (
+	"banking-app/apperror"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionBankCreated        Action = "bank.created"
+	ActionBankRenamed        Action = "bank.renamed"
+	ActionBankDeleted        Action = "bank.deleted"
+	ActionBranchOpened       Action = "bank.branch_opened"
+	ActionInterestRateSet    Action = "bank.interest_rate_set"
+	ActionLimitsSet          Action = "bank.limits_set"
+	ActionCustomerCreated    Action = "customer.created"
+	ActionCustomerUpdated    Action = "customer.updated"
+	ActionCustomerDeleted    Action = "customer.deleted"
+	ActionCustomerDeceased   Action = "customer.deceased"
+	ActionStaffCreated       Action = "staff.created"
+	ActionStaffRoleChanged   Action = "staff.role_changed"
+	ActionPasswordSet        Action = "credential.set"
+	ActionPasswordChanged    Action = "credential.changed"
+	ActionAccountOpened      Action = "account.opened"
+	ActionAccountClosed      Action = "account.closed"
+	ActionBalanceOverridden  Action = "account.balance_overridden"
+	ActionLimitIncreased     Action = "account.limit_increased"
+	ActionFreezeSet          Action = "account.freeze_set"
+	ActionLienPlaced         Action = "account.lien_placed"
+	ActionLienReleased       Action = "account.lien_released"
+	ActionHolderAdded        Action = "account.holder_added"
+	ActionOperatingModeSet   Action = "account.operating_mode_set"
+	ActionHolderRemoved      Action = "account.holder_removed"
+	ActionNomineesSet        Action = "account.nominees_set"
+	ActionDeposit            Action = "account.deposit"
+	ActionWithdrawal         Action = "account.withdrawal"
+	ActionExternalTransfer   Action = "transfer.external"
+	ActionInternalTransfer   Action = "transfer.internal"
+	ActionBeneficiaryAdded   Action = "beneficiary.added"
+	ActionBeneficiaryRenamed Action = "beneficiary.renamed"
+	ActionBeneficiaryDeleted Action = "beneficiary.deleted"
+	ActionStandingCreated    Action = "standing.created"
+	ActionStandingCancelled  Action = "standing.cancelled"
+	ActionStandingResumed    Action = "standing.resumed"
+	ActionStandingSuspended  Action = "standing.suspended"
+	ActionTransferHeld       Action = "transfer.held"
+	ActionTransferBlocked    Action = "transfer.blocked"
+	ActionTransferApproved   Action = "review.approved"
+	ActionTransferRejected   Action = "review.rejected"
+	ActionApprovalRequested  Action = "approval.requested"
+	ActionApprovalGranted    Action = "approval.granted"
+	ActionApprovalRejected   Action = "approval.rejected"
+	ActionEndOfDay           Action = "eod.closed"
+	ActionSettlement         Action = "ledger.settled"
+)
+
+// SystemActor is the ActorID of changes nobody logged in to make, such as
+// creating the first super-admin.
+const SystemActor = 0
+
+// Entry is one link of the hash chain. Hash covers every other field,
+// including PrevHash, so changing or removing any entry breaks every hash
+// after it.
+type Entry struct {
+	Sequence   int
+	Timestamp  time.Time
+	ActorID    int
+	Action     Action
+	Resource   string
+	ResourceID int
+	Before     map[string]string
+	After      map[string]string
+	PrevHash   string
+	Hash       string
+}
+
+func (e Entry) computeHash() string {
+	unsigned := e
+	unsigned.Hash = ""
+	// Maps marshal with sorted keys, so the encoding is canonical.
+	payload, _ := json.Marshal(unsigned)
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Head is the last entry appended to a log. It is kept apart from the
+// entries, so a log cut short by removing its last entries, which leaves a
+// chain that still verifies, no longer reaches its head.
+type Head struct {
+	Sequence int
+	Hash     string
+}
+
+// Filter selects entries in Query. Zero fields match everything.
+type Filter struct {
+	ActorID    int
+	Action     Action
+	Resource   string
+	ResourceID int
+	Since      time.Time
+	Until      time.Time
+}
+
+func (f Filter) matches(e Entry) bool {
+	switch {
+	case f.ActorID != 0 && e.ActorID != f.ActorID:
+		return false
+	case f.Action != "" && e.Action != f.Action:
+		return false
+	case f.Resource != "" && e.Resource != f.Resource:
+		return false
+	case f.ResourceID != 0 && e.ResourceID != f.ResourceID:
+		return false
+	case !f.Since.IsZero() && e.Timestamp.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Timestamp.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// Log is an append-only, hash-chained audit trail. Every entry is handed to
+// persist before it becomes visible, so the in-memory chain never runs ahead
+// of the stored one, and the new head to saveHead after it.
+type Log struct {
+	mu       sync.Mutex
+	entries  []Entry
+	persist  func(Entry) error
+	saveHead func(Head) error
+	now      func() time.Time
+}
+
+func NewLog(persist func(Entry) error, saveHead func(Head) error) *Log {
+	return &Log{persist: persist, saveHead: saveHead, now: time.Now}
+}
+
+func (l *Log) SetClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.now = now
+}
+
+// Restore loads previously persisted entries, refusing a chain that does
+// not verify or does not reach head. The chain may run past head, since
+// the head is saved after the entry it points to.
+func (l *Log) Restore(entries []Entry, head Head) error {
+	if err := verifyChain(entries); err != nil {
+		return err
+	}
+	if head.Sequence > len(entries) {
+		return apperror.NewBankError("audit verification", fmt.Sprintf("log ends at entry %d but its head is entry %d", len(entries), head.Sequence))
+	}
+	if head.Sequence > 0 && entries[head.Sequence-1].Hash != head.Hash {
+		return apperror.NewBankError("audit verification", fmt.Sprintf("entry %d is not the log's head", head.Sequence))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append([]Entry(nil), entries...)
+	return nil
+}
+
+func (l *Log) Append(actorID int, action Action, resource string, resourceID int, before, after map[string]string) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := Entry{
+		Sequence:   len(l.entries) + 1,
+		Timestamp:  l.now().UTC(),
+		ActorID:    actorID,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Before:     before,
+		After:      after,
+	}
+	if n := len(l.entries); n > 0 {
+		e.PrevHash = l.entries[n-1].Hash
+	}
+	e.Hash = e.computeHash()
+	if err := l.persist(e); err != nil {
+		return Entry{}, err
+	}
+	l.entries = append(l.entries, e)
+	// The entry is stored either way, and the next append moves the head
+	// past it.
+	return e, l.saveHead(Head{Sequence: e.Sequence, Hash: e.Hash})
+}
+
+func (l *Log) Query(f Filter) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var matched []Entry
+	for _, e := range l.entries {
+		if f.matches(e) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// Verify recomputes the whole chain and reports the first entry that was
+// altered, removed or reordered.
+func (l *Log) Verify() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return verifyChain(l.entries)
+}
+
+func verifyChain(entries []Entry) error {
+	prevHash := ""
+	for i, e := range entries {
+		if e.Sequence != i+1 || e.PrevHash != prevHash || e.computeHash() != e.Hash {
+			return apperror.NewBankError("audit verification", fmt.Sprintf("hash chain broken at entry %d", i+1))
+		}
+		prevHash = e.Hash
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestLog returns a log of four entries, a day apart, and what it
+// persisted.
+func newTestLog(t *testing.T) (*Log, *[]Entry, *Head) {
+	t.Helper()
+	var stored []Entry
+	var head Head
+	l := NewLog(func(e Entry) error {
+		stored = append(stored, e)
+		return nil
+	}, func(h Head) error {
+		head = h
+		return nil
+	})
+	at := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	l.SetClock(func() time.Time { return at })
+	for _, e := range []struct {
+		actor    int
+		action   Action
+		resource string
+		id       int
+	}{
+		{SystemActor, ActionStaffCreated, "customer", 1},
+		{1, ActionBankCreated, "bank", 10},
+		{1, ActionCustomerCreated, "customer", 20},
+		{20, ActionDeposit, "account", 30},
+	} {
+		if _, err := l.Append(e.actor, e.action, e.resource, e.id, nil, map[string]string{"id": "x"}); err != nil {
+			t.Fatal(err)
+		}
+		at = at.AddDate(0, 0, 1)
+	}
+	return l, &stored, &head
+}
+
+func TestAppendedChainVerifies(t *testing.T) {
+	l, stored, head := newTestLog(t)
+	if err := l.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*stored) != 4 || *head != (Head{Sequence: 4, Hash: (*stored)[3].Hash}) {
+		t.Fatalf("stored %d entries with head %+v, want 4 entries and the last as head", len(*stored), *head)
+	}
+	restored := NewLog(nil, nil)
+	if err := restored.Restore(*stored, *head); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.Query(Filter{}); len(got) != 4 {
+		t.Errorf("restored %d entries, want 4", len(got))
+	}
+}
+
+func TestBrokenChainsAreRefused(t *testing.T) {
+	_, stored, head := newTestLog(t)
+	entries := *stored
+	chain := func(modify func([]Entry) []Entry) []Entry {
+		return modify(append([]Entry(nil), entries...))
+	}
+
+	for name, broken := range map[string][]Entry{
+		"edited": chain(func(es []Entry) []Entry {
+			es[1].ActorID = 99
+			return es
+		}),
+		"edited and rehashed": chain(func(es []Entry) []Entry {
+			es[1].ActorID = 99
+			es[1].Hash = es[1].computeHash()
+			return es
+		}),
+		"reordered": chain(func(es []Entry) []Entry {
+			es[1], es[2] = es[2], es[1]
+			return es
+		}),
+		"removed": chain(func(es []Entry) []Entry {
+			return append(es[:1], es[2:]...)
+		}),
+	} {
+		if err := verifyChain(broken); err == nil {
+			t.Errorf("%s entry: chain verifies", name)
+		}
+		if err := NewLog(nil, nil).Restore(broken, *head); err == nil {
+			t.Errorf("%s entry: Restore accepted the chain", name)
+		}
+	}
+}
+
+func TestRestoreNeedsTheChainToReachItsHead(t *testing.T) {
+	_, stored, head := newTestLog(t)
+	entries := *stored
+
+	// Cutting entries off the end leaves a chain that verifies.
+	if err := verifyChain(entries[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewLog(nil, nil).Restore(entries[:2], *head); err == nil {
+		t.Error("Restore accepted a chain cut short of its head")
+	}
+	if err := NewLog(nil, nil).Restore(entries, Head{Sequence: 2, Hash: entries[1].Hash}); err != nil {
+		t.Errorf("chain running past a head saved before its last entry: %v", err)
+	}
+	if err := NewLog(nil, nil).Restore(entries, Head{Sequence: 2, Hash: entries[2].Hash}); err == nil {
+		t.Error("Restore accepted a head that is not in the chain")
+	}
+	if err := NewLog(nil, nil).Restore(entries, Head{}); err != nil {
+		t.Errorf("chain saved before heads were kept: %v", err)
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	l, _, _ := newTestLog(t)
+	start := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		f    Filter
+		want []int
+	}{
+		{"everything", Filter{}, []int{1, 2, 3, 4}},
+		{"actor", Filter{ActorID: 1}, []int{2, 3}},
+		{"action", Filter{Action: ActionDeposit}, []int{4}},
+		{"resource", Filter{Resource: "customer"}, []int{1, 3}},
+		{"resource ID", Filter{Resource: "customer", ResourceID: 20}, []int{3}},
+		{"since", Filter{Since: start.AddDate(0, 0, 2)}, []int{3, 4}},
+		{"until is exclusive", Filter{Until: start.AddDate(0, 0, 2)}, []int{1, 2}},
+		{"window", Filter{Since: start.AddDate(0, 0, 1), Until: start.AddDate(0, 0, 3)}, []int{2, 3}},
+		{"nothing", Filter{ActorID: 1, Action: ActionDeposit}, nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, e := range l.Query(tt.f) {
+			got = append(got, e.Sequence)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: entries %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: entries %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
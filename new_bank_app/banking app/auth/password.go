@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"banking-app/apperror"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	hashScheme       = "pbkdf2-sha256"
+	hashIterations   = 210000
+	saltLength       = 16
+	keyLength        = 32
+	minSecretLength  = 4
+	maxSecretLength  = 128
+	encodedHashParts = 4
+)
+
+// HashPassword derives a salted PBKDF2-SHA256 hash of a password or PIN.
+// The result is self-describing: scheme$iterations$salt$key.
+func HashPassword(secret string) (string, error) {
+	if err := validateSecret(secret); err != nil {
+		return "", err
+	}
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", apperror.NewInternalError("hash password", "cannot read random salt", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, secret, salt, hashIterations, keyLength)
+	if err != nil {
+		return "", apperror.NewInternalError("hash password", "key derivation failed", err)
+	}
+	return fmt.Sprintf("%s$%d$%s$%s", hashScheme, hashIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func VerifyPassword(encoded, secret string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != encodedHashParts || parts[0] != hashScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, secret, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+func validateSecret(secret string) error {
+	if len(secret) < minSecretLength {
+		return apperror.NewValidationError("password", fmt.Sprintf("must be at least %d characters", minSecretLength))
+	}
+	if len(secret) > maxSecretLength {
+		return apperror.NewValidationError("password", fmt.Sprintf("must be at most %d characters", maxSecretLength))
+	}
+	return nil
+}
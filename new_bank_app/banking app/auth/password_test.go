@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashAndVerifyPassword(t *testing.T) {
+	hash, err := HashPassword("Test@1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, hashScheme+"$") || strings.Contains(hash, "Test@1234") {
+		t.Errorf("hash %q is not a salted %s hash", hash, hashScheme)
+	}
+	if !VerifyPassword(hash, "Test@1234") {
+		t.Error("the right password does not verify")
+	}
+	if VerifyPassword(hash, "Test@1235") {
+		t.Error("a wrong password verifies")
+	}
+	again, err := HashPassword("Test@1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == hash {
+		t.Error("two hashes of one password are equal, so the salt is not random")
+	}
+}
+
+func TestVerifyPasswordRejectsMalformedHashes(t *testing.T) {
+	hash, err := HashPassword("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(hash, "$")
+	for name, encoded := range map[string]string{
+		"empty":          "",
+		"other scheme":   strings.Join(append([]string{"bcrypt"}, parts[1:]...), "$"),
+		"bad iterations": strings.Join([]string{parts[0], "0", parts[2], parts[3]}, "$"),
+		"bad salt":       strings.Join([]string{parts[0], parts[1], "!", parts[3]}, "$"),
+		"missing key":    strings.Join(parts[:3], "$"),
+	} {
+		if VerifyPassword(encoded, "1234") {
+			t.Errorf("%s hash %q verifies", name, encoded)
+		}
+	}
+}
+
+func TestHashPasswordValidatesLength(t *testing.T) {
+	for _, secret := range []string{"", "123", strings.Repeat("x", maxSecretLength+1)} {
+		if _, err := HashPassword(secret); err == nil {
+			t.Errorf("HashPassword accepted a secret of %d characters", len(secret))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"banking-app/apperror"
+	"fmt"
+)
+
+type Role string
+
+const (
+	RoleSuperAdmin   Role = "super-admin"
+	RoleBankOperator Role = "bank-operator"
+	RoleTeller       Role = "teller"
+	RoleAuditor      Role = "auditor"
+	RoleCustomer     Role = "customer"
+)
+
+type Permission string
+
+const (
+	PermManageBanks        Permission = "banks:manage"
+	PermManageStaff        Permission = "staff:manage"
+	PermOnboardCustomers   Permission = "customers:onboard"
+	PermDeleteCustomers    Permission = "customers:delete"
+	PermViewCustomers      Permission = "customers:view"
+	PermOpenAccounts       Permission = "accounts:open"
+	PermCloseAccounts      Permission = "accounts:close"
+	PermOverrideBalance    Permission = "accounts:override-balance"
+	PermViewLedger         Permission = "ledger:view"
+	PermViewAudit          Permission = "audit:view"
+	PermSetInterestRates   Permission = "banks:set-interest-rates"
+	PermRunEndOfDay        Permission = "eod:run"
+	PermSettle             Permission = "ledger:settle"
+	PermViewJournal        Permission = "journal:view"
+	PermManageLimits       Permission = "limits:manage"
+	PermReviewTransfers    Permission = "transfers:review"
+	PermRestrictAccounts   Permission = "accounts:restrict"
+	PermOperateOwnAccounts Permission = "own-accounts:operate"
+)
+
+var rolePermissions = map[Role]map[Permission]bool{
+	RoleSuperAdmin: {
+		PermManageBanks:      true,
+		PermManageStaff:      true,
+		PermOnboardCustomers: true,
+		PermDeleteCustomers:  true,
+		PermViewCustomers:    true,
+		PermOpenAccounts:     true,
+		PermCloseAccounts:    true,
+		PermOverrideBalance:  true,
+		PermViewLedger:       true,
+		PermViewAudit:        true,
+		PermSetInterestRates: true,
+		PermRunEndOfDay:      true,
+		PermSettle:           true,
+		PermViewJournal:      true,
+		PermManageLimits:     true,
+		PermReviewTransfers:  true,
+		PermRestrictAccounts: true,
+	},
+	RoleBankOperator: {
+		PermOnboardCustomers: true,
+		PermViewCustomers:    true,
+		PermOpenAccounts:     true,
+		PermCloseAccounts:    true,
+		PermOverrideBalance:  true,
+		PermViewLedger:       true,
+		PermSetInterestRates: true,
+		PermManageLimits:     true,
+		PermReviewTransfers:  true,
+		PermRestrictAccounts: true,
+	},
+	RoleTeller: {
+		PermOnboardCustomers: true,
+		PermViewCustomers:    true,
+		PermOpenAccounts:     true,
+	},
+	RoleAuditor: {
+		PermViewCustomers: true,
+		PermViewLedger:    true,
+		PermViewAudit:     true,
+		PermViewJournal:   true,
+	},
+	RoleCustomer: {
+		PermOperateOwnAccounts: true,
+	},
+}
+
+func ParseRole(s string) (Role, error) {
+	r := Role(s)
+	if _, ok := rolePermissions[r]; !ok {
+		return "", apperror.NewValidationError("role", fmt.Sprintf("unknown role %q", s))
+	}
+	return r, nil
+}
+
+func (r Role) Can(perm Permission) bool {
+	return rolePermissions[r][perm]
+}
+
+// IsBankScoped reports whether the role only applies to the banks it was
+// assigned, rather than to every bank.
+func (r Role) IsBankScoped() bool {
+	return r == RoleBankOperator || r == RoleTeller
+}
+
+func (r Role) IsStaff() bool {
+	return r != RoleCustomer
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"banking-app/apperror"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultSessionTTL = 30 * time.Minute
+
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Principal is an authenticated caller. It can only be built by verifying a
+// token, so holding one proves the caller logged in.
+type Principal struct {
+	customerID int
+	sessionID  string
+	expiresAt  time.Time
+}
+
+func (p *Principal) CustomerID() int {
+	return p.customerID
+}
+
+func (p *Principal) SessionID() string {
+	return p.sessionID
+}
+
+func (p *Principal) ExpiresAt() time.Time {
+	return p.expiresAt
+}
+
+type claims struct {
+	Subject   int    `json:"sub"`
+	SessionID string `json:"sid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TokenIssuer signs and verifies HS256 session tokens and remembers which
+// sessions have been revoked until they would have expired anyway. It also
+// remembers the sessions it issued to each customer, so that all of them can
+// be revoked at once.
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	now      func() time.Time
+	revoked  map[string]time.Time
+	sessions map[int]map[string]time.Time
+}
+
+func NewTokenIssuer(secret []byte, ttl time.Duration) (*TokenIssuer, error) {
+	if len(secret) < 32 {
+		return nil, apperror.NewValidationError("secret", "must be at least 32 bytes")
+	}
+	if ttl <= 0 {
+		return nil, apperror.NewValidationError("ttl", "must be positive")
+	}
+	return &TokenIssuer{
+		secret:   append([]byte(nil), secret...),
+		ttl:      ttl,
+		now:      time.Now,
+		revoked:  make(map[string]time.Time),
+		sessions: make(map[int]map[string]time.Time),
+	}, nil
+}
+
+// NewRandomTokenIssuer uses a fresh random secret, so its tokens do not
+// survive a restart.
+func NewRandomTokenIssuer(ttl time.Duration) (*TokenIssuer, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, apperror.NewInternalError("create token issuer", "cannot read random secret", err)
+	}
+	return NewTokenIssuer(secret, ttl)
+}
+
+func (t *TokenIssuer) SetClock(now func() time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.now = now
+}
+
+func (t *TokenIssuer) clock() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.now()
+}
+
+func (t *TokenIssuer) Issue(customerID int) (string, *Principal, error) {
+	sid := make([]byte, 16)
+	if _, err := rand.Read(sid); err != nil {
+		return "", nil, apperror.NewInternalError("issue token", "cannot read random session ID", err)
+	}
+	now := t.clock()
+	c := claims{
+		Subject:   customerID,
+		SessionID: hex.EncodeToString(sid),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(t.ttl).Unix(),
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", nil, apperror.NewInternalError("issue token", "cannot encode claims", err)
+	}
+	signingInput := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	token := signingInput + "." + t.sign(signingInput)
+	p := &Principal{customerID: c.Subject, sessionID: c.SessionID, expiresAt: time.Unix(c.ExpiresAt, 0)}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sessions := t.sessions[customerID]
+	if sessions == nil {
+		sessions = make(map[string]time.Time)
+		t.sessions[customerID] = sessions
+	}
+	pruneExpired(sessions, now)
+	sessions[p.sessionID] = p.expiresAt
+	return token, p, nil
+}
+
+func (t *TokenIssuer) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return nil, apperror.NewAuthError("use a malformed session token")
+	}
+	want := t.sign(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(want), []byte(parts[2])) {
+		return nil, apperror.NewAuthError("use a session token with a bad signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, apperror.NewAuthError("use a malformed session token", err)
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, apperror.NewAuthError("use a malformed session token", err)
+	}
+	p := &Principal{customerID: c.Subject, sessionID: c.SessionID, expiresAt: time.Unix(c.ExpiresAt, 0)}
+	if err := t.Check(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Check reports whether a principal's session is still usable.
+func (t *TokenIssuer) Check(p *Principal) error {
+	if p == nil {
+		return apperror.NewAuthError("act without logging in")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.now().Before(p.expiresAt) {
+		return apperror.NewAuthError("use an expired session")
+	}
+	if _, revoked := t.revoked[p.sessionID]; revoked {
+		return apperror.NewAuthError("use a session that was logged out")
+	}
+	return nil
+}
+
+func (t *TokenIssuer) Revoke(p *Principal) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pruneExpired(t.revoked, t.now())
+	t.revoked[p.sessionID] = p.expiresAt
+	delete(t.sessions[p.customerID], p.sessionID)
+}
+
+// RevokeCustomer revokes every session issued to customerID, for example
+// once their password has changed.
+func (t *TokenIssuer) RevokeCustomer(customerID int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pruneExpired(t.revoked, t.now())
+	for sid, expiry := range t.sessions[customerID] {
+		t.revoked[sid] = expiry
+	}
+	delete(t.sessions, customerID)
+}
+
+// pruneExpired drops the sessions that have expired by now. It expects t.mu
+// to be held.
+func pruneExpired(sessions map[string]time.Time, now time.Time) {
+	for sid, expiry := range sessions {
+		if !now.Before(expiry) {
+			delete(sessions, sid)
+		}
+	}
+}
+
+func (t *TokenIssuer) sign(signingInput string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"banking-app/apperror"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestIssuer(t *testing.T, now *time.Time) *TokenIssuer {
+	t.Helper()
+	issuer, err := NewTokenIssuer([]byte(strings.Repeat("k", 32)), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer.SetClock(func() time.Time { return *now })
+	return issuer
+}
+
+func wantAuthError(t *testing.T, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, apperror.ErrUnauthorized) {
+		t.Errorf("%s: err = %v, want an auth error", what, err)
+	}
+}
+
+func TestIssuedTokenVerifiesUntilItExpires(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	issuer := newTestIssuer(t, &now)
+	token, issued, err := issuer.Issue(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := issuer.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.CustomerID() != 42 || p.SessionID() != issued.SessionID() || !p.ExpiresAt().Equal(now.Add(time.Hour)) {
+		t.Errorf("verified %+v, want the issued %+v", p, issued)
+	}
+
+	now = now.Add(time.Hour - time.Second)
+	if _, err := issuer.Verify(token); err != nil {
+		t.Errorf("a second before expiry: %v", err)
+	}
+	now = now.Add(time.Second)
+	_, err = issuer.Verify(token)
+	wantAuthError(t, "at expiry", err)
+}
+
+func TestVerifyRejectsTamperedTokens(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	issuer := newTestIssuer(t, &now)
+	token, _, err := issuer.Issue(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	other, err := NewTokenIssuer([]byte(strings.Repeat("x", 32)), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, _, err := other.Issue(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherParts := strings.Split(forged, ".")
+
+	for name, tampered := range map[string]string{
+		"malformed":         "not-a-token",
+		"other payload":     parts[0] + "." + otherParts[1] + "." + parts[2],
+		"other signature":   parts[0] + "." + parts[1] + "." + otherParts[2],
+		"signed elsewhere":  forged,
+		"missing signature": parts[0] + "." + parts[1],
+	} {
+		_, err := issuer.Verify(tampered)
+		wantAuthError(t, name, err)
+	}
+}
+
+func TestRevokedSessionsNoLongerVerify(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	issuer := newTestIssuer(t, &now)
+	first, p1, err := issuer.Issue(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := issuer.Issue(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, _, err := issuer.Issue(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := issuer.Issue(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer.Revoke(p1)
+	_, err = issuer.Verify(first)
+	wantAuthError(t, "logged out session", err)
+	if _, err := issuer.Verify(second); err != nil {
+		t.Errorf("another session of the same customer: %v", err)
+	}
+
+	issuer.RevokeCustomer(42)
+	for _, token := range []string{second, third} {
+		_, err := issuer.Verify(token)
+		wantAuthError(t, "session of a revoked customer", err)
+	}
+	if _, err := issuer.Verify(other); err != nil {
+		t.Errorf("another customer's session: %v", err)
+	}
+	wantAuthError(t, "no principal", issuer.Check(nil))
+}
@@ -1,53 +1,148 @@
-package bank
-
-import (
-	"banking-app/apperror"
-	"strings"
-)
-
-type Bank struct {
-	BankID       int
-	Name         string
-	Abbreviation string
-	IsActive     bool
-}
-
-func NewBank(bankID int, name string) (*Bank, *apperror.ValidationError) {
-	name = strings.TrimSpace(name)
-	if bankID < 0 {
-		return nil, apperror.NewValidationError("bankID", "must be >= 0")
-	}
-	if name == "" {
-		return nil, apperror.NewValidationError("name", "fullname of bank cannot be empty")
-	}
-	if len(name) < 4 {
-		return nil, apperror.NewValidationError("name", "bank fullname cannot be less than 4 letters")
-	}
-	firstTwo := name[:2]
-	lastTwo := name[len(name)-2:]
-	abbreviation := strings.ToLower(firstTwo + lastTwo)
-
-	return &Bank{
-		BankID:       bankID,
-		Name:         name,
-		Abbreviation: abbreviation,
-		IsActive:     true,
-	}, nil
-}
-
-func (b *Bank) UpdateBankName(newName string) *apperror.ValidationError {
-	newName = strings.TrimSpace(newName)
-	if newName == "" {
-		return apperror.NewValidationError("name", "bank name cannot be empty")
-	}
-	if len(newName) < 4 {
-		return apperror.NewValidationError("name", "bank name must be at least 4 characters")
-	}
-
-	b.Name = newName
-	firstTwo := newName[:2]
-	lastTwo := newName[len(newName)-2:]
-	b.Abbreviation = strings.ToLower(firstTwo + lastTwo)
-
-	return nil
-}
+package bank
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"fmt"
+	"strings"
+)
+
+type Bank struct {
+	BankID int
+	Name   string
+	// Code is the four capital letters that open the routing codes of the
+	// bank's branches. It is unique among banks and survives renames.
+	Code     string
+	IsActive bool
+	// InterestRates is the annual rate in basis points the bank currently
+	// pays on each product. Products without one earn the rate in the terms
+	// their accounts were opened with.
+	InterestRates map[account.Product]int64
+	// ProductLimits overrides account.DefaultLimits for each product it
+	// names. Like InterestRates it is replaced, never written into.
+	ProductLimits map[account.Product]account.Limits
+	// Branches are in the order they opened, the head office first. Like
+	// InterestRates the slice is replaced, never written into.
+	Branches []Branch
+}
+
+// NewBank checks the code's format only; keeping codes unique is up to the
+// caller.
+func NewBank(bankID int, name, code string) (*Bank, error) {
+	name = strings.TrimSpace(name)
+	if bankID < 0 {
+		return nil, apperror.NewValidationError("bankID", "must be >= 0")
+	}
+	if name == "" {
+		return nil, apperror.NewValidationError("name", "fullname of bank cannot be empty")
+	}
+	if len(name) < 4 {
+		return nil, apperror.NewValidationError("name", "bank fullname cannot be less than 4 letters")
+	}
+	code, err := ParseCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bank{
+		BankID:   bankID,
+		Name:     name,
+		Code:     code,
+		IsActive: true,
+	}, nil
+}
+
+// ParseCode normalizes a bank code to capitals and checks it is four
+// letters.
+func ParseCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 4 || strings.IndexFunc(code, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+		return "", apperror.NewValidationError("code", fmt.Sprintf("%q is not four letters", code))
+	}
+	return code, nil
+}
+
+// SuggestCode proposes a code for a bank called name that taken reports as
+// free: the first two and last two letters of the name if it can, otherwise
+// the first two followed by the first free pair of letters. It returns ""
+// if all of those are taken.
+func SuggestCode(name string, taken func(code string) bool) string {
+	letters := strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return -1
+	}, strings.ToUpper(name))
+	letters += "XXXX"[:max(0, 4-len(letters))]
+	if code := letters[:2] + letters[len(letters)-2:]; !taken(code) {
+		return code
+	}
+	for a := 'A'; a <= 'Z'; a++ {
+		for b := 'A'; b <= 'Z'; b++ {
+			if code := letters[:2] + string(a) + string(b); !taken(code) {
+				return code
+			}
+		}
+	}
+	return ""
+}
+
+func (b *Bank) UpdateBankName(newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return apperror.NewValidationError("name", "bank name cannot be empty")
+	}
+	if len(newName) < 4 {
+		return apperror.NewValidationError("name", "bank name must be at least 4 characters")
+	}
+
+	b.Name = newName
+	return nil
+}
+
+// SetInterestRate replaces the map rather than writing into it, because
+// copies of the bank handed out earlier share the old one.
+func (b *Bank) SetInterestRate(product account.Product, rateBPS int64) error {
+	if _, err := account.ParseProduct(string(product)); err != nil {
+		return err
+	}
+	if product == account.ProductCurrent {
+		return apperror.NewValidationError("product", "current accounts do not earn interest")
+	}
+	if rateBPS < 0 || rateBPS > 10000 {
+		return apperror.NewValidationError("rateBPS", "must be between 0 and 10000 basis points")
+	}
+	rates := make(map[account.Product]int64, len(b.InterestRates)+1)
+	for p, r := range b.InterestRates {
+		rates[p] = r
+	}
+	rates[product] = rateBPS
+	b.InterestRates = rates
+	return nil
+}
+
+func (b *Bank) InterestRate(product account.Product) (int64, bool) {
+	rate, ok := b.InterestRates[product]
+	return rate, ok
+}
+
+func (b *Bank) SetLimits(product account.Product, limits account.Limits) error {
+	if _, err := account.ParseProduct(string(product)); err != nil {
+		return err
+	}
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+	all := make(map[account.Product]account.Limits, len(b.ProductLimits)+1)
+	for p, l := range b.ProductLimits {
+		all[p] = l
+	}
+	all[product] = limits
+	b.ProductLimits = all
+	return nil
+}
+
+func (b *Bank) Limits(product account.Product) (account.Limits, bool) {
+	limits, ok := b.ProductLimits[product]
+	return limits, ok
+}
@@ -0,0 +1,68 @@
+package bank
+
+import "testing"
+
+func TestParseCode(t *testing.T) {
+	tests := []struct {
+		code, want string
+		ok         bool
+	}{
+		{"SBIN", "SBIN", true},
+		{" sbin ", "SBIN", true},
+		{"SBI", "", false},
+		{"SBINX", "", false},
+		{"SB1N", "", false},
+		{"SB N", "", false},
+		{"ŞBIN", "", false},
+		{"", "", false},
+	}
+	for _, tc := range tests {
+		got, err := ParseCode(tc.code)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParseCode(%q) = %q, %v; want %q, ok %t", tc.code, got, err, tc.want, tc.ok)
+		}
+	}
+	if _, err := NewBank(1, "State Bank of India", "SB1N"); err == nil {
+		t.Error("NewBank accepted an invalid code")
+	}
+}
+
+func TestSuggestCodeAvoidsTakenCodes(t *testing.T) {
+	taken := map[string]bool{}
+	isTaken := func(code string) bool { return taken[code] }
+	for _, tc := range []struct{ name, want string }{
+		{"State Bank of India", "STIA"},
+		// The same first and last letters as the bank before it.
+		{"Stanley India", "STAA"},
+		{"Stanley Indústria", "STAB"},
+		{"Q", "QXXX"},
+	} {
+		got := SuggestCode(tc.name, isTaken)
+		if got != tc.want {
+			t.Errorf("SuggestCode(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+		taken[got] = true
+	}
+	if got := SuggestCode("Stanley India", func(string) bool { return true }); got != "" {
+		t.Errorf("SuggestCode with every code taken = %q, want none", got)
+	}
+}
+
+func TestRenameKeepsTheCode(t *testing.T) {
+	b, err := NewBank(1, "State Bank of India", "SBIN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.AddBranch(2, "Head Office", HeadOfficeCode); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UpdateBankName("Bank of Stanley"); err != nil {
+		t.Fatal(err)
+	}
+	if b.Code != "SBIN" {
+		t.Errorf("code = %q after a rename, want SBIN", b.Code)
+	}
+	if head, _ := b.HeadOffice(); head.RoutingCode != "SBIN0000001" {
+		t.Errorf("head office routing code = %q, want SBIN0000001", head.RoutingCode)
+	}
+}
@@ -0,0 +1,107 @@
+package bank
+
+import (
+	"banking-app/apperror"
+	"fmt"
+	"strings"
+)
+
+// HeadOfficeCode is the branch code of the branch every bank opens with.
+const HeadOfficeCode = "000001"
+
+// Branch is an office of a bank. Its RoutingCode follows the IFSC layout:
+// the bank's Code, a zero, then the branch's own six-character Code.
+type Branch struct {
+	BranchID    int
+	BankID      int
+	Name        string
+	Code        string
+	RoutingCode string
+	IsActive    bool
+}
+
+// ParseBranchCode normalizes a branch code to capitals and checks it is six
+// letters or digits.
+func ParseBranchCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 6 || strings.IndexFunc(code, func(r rune) bool { return !isAlphanumeric(r) }) >= 0 {
+		return "", apperror.NewValidationError("code", fmt.Sprintf("%q is not six letters or digits", code))
+	}
+	return code, nil
+}
+
+func RoutingCode(bankCode, branchCode string) string {
+	return bankCode + "0" + branchCode
+}
+
+// ParseRoutingCode splits a routing code such as SBIN0001234 into the bank
+// code and the branch code.
+func ParseRoutingCode(routingCode string) (bankCode, branchCode string, err error) {
+	routingCode = strings.ToUpper(strings.TrimSpace(routingCode))
+	invalid := apperror.NewValidationError("routingCode", fmt.Sprintf("%q is not a bank code, a zero and a branch code", routingCode))
+	if len(routingCode) != 11 || routingCode[4] != '0' {
+		return "", "", invalid
+	}
+	if bankCode, err = ParseCode(routingCode[:4]); err != nil {
+		return "", "", invalid
+	}
+	if branchCode, err = ParseBranchCode(routingCode[5:]); err != nil {
+		return "", "", invalid
+	}
+	return bankCode, branchCode, nil
+}
+
+// AddBranch opens a branch under a code no other branch of the bank uses.
+func (b *Bank) AddBranch(branchID int, name, code string) (Branch, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Branch{}, apperror.NewValidationError("name", "branch name cannot be empty")
+	}
+	code, err := ParseBranchCode(code)
+	if err != nil {
+		return Branch{}, err
+	}
+	if existing, ok := b.BranchByCode(code); ok {
+		return Branch{}, apperror.NewValidationError("code", fmt.Sprintf("%s is already branch %d", code, existing.BranchID))
+	}
+	branch := Branch{
+		BranchID:    branchID,
+		BankID:      b.BankID,
+		Name:        name,
+		Code:        code,
+		RoutingCode: RoutingCode(b.Code, code),
+		IsActive:    true,
+	}
+	b.Branches = append(append([]Branch(nil), b.Branches...), branch)
+	return branch, nil
+}
+
+func (b *Bank) Branch(branchID int) (Branch, bool) {
+	for _, branch := range b.Branches {
+		if branch.BranchID == branchID {
+			return branch, true
+		}
+	}
+	return Branch{}, false
+}
+
+func (b *Bank) BranchByCode(code string) (Branch, bool) {
+	for _, branch := range b.Branches {
+		if branch.Code == code {
+			return branch, true
+		}
+	}
+	return Branch{}, false
+}
+
+// HeadOffice is the bank's first branch.
+func (b *Bank) HeadOffice() (Branch, bool) {
+	if len(b.Branches) == 0 {
+		return Branch{}, false
+	}
+	return b.Branches[0], true
+}
+
+func isAlphanumeric(r rune) bool {
+	return (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
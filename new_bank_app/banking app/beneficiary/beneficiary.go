@@ -0,0 +1,79 @@
+package beneficiary
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxNicknameLength keeps nicknames short enough to list on a phone screen.
+const MaxNicknameLength = 50
+
+// Beneficiary is an account a customer has registered to send money to.
+// Deleting one keeps the record, so a beneficiary added again later starts
+// a fresh cooling-off period under a new ID.
+type Beneficiary struct {
+	BeneficiaryID int
+	CustomerID    int
+	Nickname      string
+	AccountID     int
+	AccountNumber string
+	BankID        int
+	// PayeeID is the customer who owned AccountID when it was added. It is
+	// kept for display; payments go to the account's owner at the time.
+	PayeeID   int
+	AddedAt   time.Time
+	DeletedAt time.Time
+}
+
+func (b Beneficiary) IsDeleted() bool {
+	return !b.DeletedAt.IsZero()
+}
+
+// Policy is the cooling-off applied to newly added beneficiaries: until
+// CoolingOff has passed, no more than CoolingOffLimit in total may be sent
+// to them.
+type Policy struct {
+	CoolingOff      time.Duration
+	CoolingOffLimit money.Money
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		CoolingOff:      24 * time.Hour,
+		CoolingOffLimit: money.MustFromMajor(5000, money.INR),
+	}
+}
+
+func (p Policy) Validate() error {
+	if p.CoolingOff < 0 {
+		return apperror.NewValidationError("coolingOff", "cannot be negative")
+	}
+	if p.CoolingOffLimit.IsNegative() {
+		return apperror.NewValidationError("coolingOffLimit", "cannot be negative")
+	}
+	return nil
+}
+
+// CoolsOffAt is when b may receive any amount.
+func (p Policy) CoolsOffAt(b Beneficiary) time.Time {
+	return b.AddedAt.Add(p.CoolingOff)
+}
+
+func (p Policy) CoolingOffAt(b Beneficiary, at time.Time) bool {
+	return at.Before(p.CoolsOffAt(b))
+}
+
+// NormalizeNickname trims nickname and checks it is usable.
+func NormalizeNickname(nickname string) (string, error) {
+	nickname = strings.TrimSpace(nickname)
+	if nickname == "" {
+		return "", apperror.NewValidationError("nickname", "cannot be empty")
+	}
+	if len([]rune(nickname)) > MaxNicknameLength {
+		return "", apperror.NewValidationError("nickname", fmt.Sprintf("must be at most %d characters", MaxNicknameLength))
+	}
+	return nickname, nil
+}
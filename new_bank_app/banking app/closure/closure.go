@@ -0,0 +1,84 @@
+package closure
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Method is how money leaving a closed account is paid out.
+type Method string
+
+const (
+	// MethodTransfer pays into another account, converting if it is in
+	// another currency.
+	MethodTransfer Method = "transfer"
+	// MethodCash pays out over the counter.
+	MethodCash Method = "cash"
+)
+
+func ParseMethod(s string) (Method, error) {
+	switch m := Method(s); m {
+	case MethodTransfer, MethodCash:
+		return m, nil
+	}
+	return "", apperror.NewValidationError("method", fmt.Sprintf("unknown payout method %q", s))
+}
+
+// Reason is why an account was closed.
+type Reason string
+
+const (
+	// ReasonRequest is a closure asked for by a holder or by the bank.
+	ReasonRequest Reason = "request"
+	// ReasonNomineeClaim is a closure that paid a deceased holder's
+	// nominees.
+	ReasonNomineeClaim Reason = "nominee-claim"
+)
+
+// Payout is one payment out of a closed account. ToAccountNumber names the
+// account a transfer was paid into, which Credited in its own currency.
+// Payouts under a nominee claim name the nominee they were paid to.
+type Payout struct {
+	Method          Method
+	ToAccountNumber string
+	ToAccountID     int
+	NomineeID       int
+	NomineeName     string
+	Amount          money.Money
+	Credited        money.Money
+}
+
+// Validate checks that p says where its money goes and only that.
+func (p Payout) Validate() error {
+	if _, err := ParseMethod(string(p.Method)); err != nil {
+		return err
+	}
+	number := strings.TrimSpace(p.ToAccountNumber)
+	if p.Method == MethodTransfer && number == "" {
+		return apperror.NewValidationError("toAccountNumber", "a transfer payout needs the account to pay into")
+	}
+	if p.Method == MethodCash && number != "" {
+		return apperror.NewValidationError("toAccountNumber", "a cash payout is not paid into an account")
+	}
+	return nil
+}
+
+// Closure is how a closed account was settled: the interest credited and
+// the charges taken on the way out, and where what was left went. Every
+// posting it made shares ReferenceID.
+type Closure struct {
+	ClosureID   int
+	AccountID   int
+	Reason      Reason
+	ReferenceID string
+	Interest    money.Money
+	Charges     money.Money
+	Payouts     []Payout
+	// DeceasedID is the holder whose nominees a claim paid.
+	DeceasedID int
+	ClosedBy   int
+	ClosedAt   time.Time
+}
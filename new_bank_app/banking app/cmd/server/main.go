@@ -0,0 +1,90 @@
+package main
+
+import (
+	"banking-app/auth"
+	"banking-app/beneficiary"
+	"banking-app/customer"
+	"banking-app/fx"
+	"banking-app/idempotency"
+	"banking-app/repository"
+	"banking-app/server"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	storePath := flag.String("store", "bank.jsonl", "path of the append-only store file")
+	adminFirst := flag.String("admin-first", "Pragnesh", "first name of the admin created on an empty store")
+	adminLast := flag.String("admin-last", "Sheth", "last name of the admin created on an empty store")
+	adminPassword := flag.String("admin-password", os.Getenv("BANK_ADMIN_PASSWORD"), "password of the admin created on an empty store")
+	sessionTTL := flag.Duration("session-ttl", auth.DefaultSessionTTL, "lifetime of login sessions")
+	eodInterval := flag.Duration("eod-interval", time.Hour, "how often to close business days that have ended")
+	idempotencyTTL := flag.Duration("idempotency-ttl", idempotency.DefaultTTL, "how long a completed request is remembered under its Idempotency-Key")
+	standingInterval := flag.Duration("standing-interval", time.Minute, "how often to run standing instructions that are due")
+	fxRates := flag.String("fx-rates", "", "path of a JSON file of exchange rates; without one only same-currency transfers work")
+	coolingOff := flag.Duration("beneficiary-cooling-off", beneficiary.DefaultPolicy().CoolingOff, "how long a new beneficiary may only receive the cooling-off limit")
+	flag.Parse()
+
+	store, err := repository.OpenFileStore(*storePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	manager, err := customer.NewCustomerManager(store, *adminFirst, *adminLast, *adminPassword)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := manager.SetIdempotencyTTL(*idempotencyTTL); err != nil {
+		log.Fatal(err)
+	}
+	policy := beneficiary.DefaultPolicy()
+	policy.CoolingOff = *coolingOff
+	if err := manager.SetBeneficiaryPolicy(policy); err != nil {
+		log.Fatal(err)
+	}
+
+	if *fxRates != "" {
+		rates, err := fx.LoadFile(*fxRates)
+		if err != nil {
+			log.Fatal(err)
+		}
+		manager.SetRateProvider(rates)
+	}
+
+	// A fixed secret keeps sessions valid across restarts; without one every
+	// restart signs out all customers.
+	if secret := os.Getenv("BANK_TOKEN_SECRET"); secret != "" {
+		tokens, err := auth.NewTokenIssuer([]byte(secret), *sessionTTL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		manager.SetTokenIssuer(tokens)
+	} else {
+		tokens, err := auth.NewRandomTokenIssuer(*sessionTTL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		manager.SetTokenIssuer(tokens)
+	}
+
+	scheduler := manager.StartEndOfDayScheduler(*eodInterval, func(err error) {
+		log.Printf("end of day: %v", err)
+	})
+	defer scheduler.Stop()
+	executor := manager.StartStandingInstructionScheduler(*standingInterval, func(err error) {
+		log.Printf("standing instructions: %v", err)
+	})
+	defer executor.Stop()
+
+	log.Printf("super-admin customer ID is %d", manager.SuperAdminID())
+	log.Printf("banking API listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, server.New(manager)); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,410 +1,968 @@
-package customer
-
-import (
-	"banking-app/account"
-	"banking-app/apperror"
-	"banking-app/bank"
-	"banking-app/ledger"
-	"fmt"
-)
-
-type Customer struct {
-	CustomerID int
-	FirstName  string
-	LastName   string
-	IsAdmin    bool
-	IsActive   bool
-	Accounts   map[int]*account.Account
-}
-
-type CustomerManager struct {
-	customers map[int]*Customer
-	banks     map[int]*bank.Bank
-	ledger    *ledger.Ledger
-	idCounter int
-	admin     *Customer
-}
-
-func handlePanic(context string) {
-	if r := recover(); r != nil {
-		fmt.Printf("[RECOVERED PANIC] %s: %v\n", context, r)
-	}
-}
-
-func NewCustomerManager(firstName, lastName string) *CustomerManager {
-	defer handlePanic("NewCustomerManager")
-
-	cm := &CustomerManager{
-		customers: make(map[int]*Customer),
-		banks:     make(map[int]*bank.Bank),
-		idCounter: 1000,
-	}
-
-	cm.ledger = ledger.NewLedger(func(bankID int) (float64, error) {
-		var total float64
-		for _, c := range cm.customers {
-			if !c.IsActive {
-				continue
-			}
-			for _, acc := range c.Accounts {
-				if acc.IsActive && cm.banks[acc.BankID] != nil && acc.BankID == bankID {
-					total += acc.Balance
-				}
-			}
-		}
-		return total, nil
-	})
-
-	admin := &Customer{
-		CustomerID: cm.generateCustomerID(),
-		FirstName:  TrimAndValidateName(firstName),
-		LastName:   TrimAndValidateName(lastName),
-		IsAdmin:    true,
-		IsActive:   true,
-		Accounts:   make(map[int]*account.Account),
-	}
-	cm.customers[admin.CustomerID] = admin
-	cm.admin = admin
-
-	return cm
-}
-
-func (cm *CustomerManager) GetLedger() *ledger.Ledger {
-	defer handlePanic("GetLedger")
-	return cm.ledger
-}
-
-func (cm *CustomerManager) generateCustomerID() int {
-	defer handlePanic("generateCustomerID")
-	cm.idCounter++
-	return cm.idCounter
-}
-
-func (cm *CustomerManager) isAuthorizedAdmin() bool {
-	defer handlePanic("isAuthorizedAdmin")
-	return cm.admin != nil && cm.admin.IsAdmin && cm.admin.IsActive
-}
-
-func (cm *CustomerManager) isAuthorizedCustomer(customerID int) bool {
-	defer handlePanic("isAuthorizedCustomer")
-	c := cm.customers[customerID]
-	return c != nil && c.IsActive && !c.IsAdmin
-}
-
-func (cm *CustomerManager) CreateNewBank(fullname string) (*bank.Bank, error) {
-	defer handlePanic("CreateNewBank")
-
-	if !cm.isAuthorizedAdmin() {
-		panic("admin authorization required")
-	}
-	id := cm.generateCustomerID()
-	b, err := bank.NewBank(id, fullname)
-	if err != nil {
-		return nil, err
-	}
-	cm.banks[id] = b
-	return b, nil
-}
-
-func (cm *CustomerManager) UpdateBankName(bankID int, newName string) error {
-	defer handlePanic("UpdateBankName")
-
-	if !cm.isAuthorizedAdmin() {
-		panic("admin authorization required")
-	}
-	b, ok := cm.banks[bankID]
-	if !ok {
-		panic(fmt.Sprintf("bank ID %d not found", bankID))
-	}
-	return b.UpdateBankName(newName)
-}
-
-func (cm *CustomerManager) GetBankById(id int) *bank.Bank {
-	defer handlePanic("GetBankById")
-	return cm.banks[id]
-}
-
-func (cm *CustomerManager) GetAllBanks() []bank.Bank {
-	defer handlePanic("GetAllBanks")
-	banks := make([]bank.Bank, 0, len(cm.banks))
-	for _, b := range cm.banks {
-		banks = append(banks, *b)
-	}
-	return banks
-}
-
-func (cm *CustomerManager) DeleteBank(bankID int) {
-	defer handlePanic("DeleteBank")
-
-	if !cm.isAuthorizedAdmin() {
-		panic("unauthorized: only admin can delete a bank")
-	}
-	delete(cm.banks, bankID)
-}
-
-func (cm *CustomerManager) CreateNewCustomer(firstName, lastName string) (*Customer, error) {
-	defer handlePanic("CreateNewCustomer")
-
-	if !cm.isAuthorizedAdmin() {
-		panic("admin authorization required")
-	}
-
-	customerID := cm.generateCustomerID()
-	c := &Customer{
-		CustomerID: customerID,
-		FirstName:  TrimAndValidateName(firstName),
-		LastName:   TrimAndValidateName(lastName),
-		IsAdmin:    false,
-		IsActive:   true,
-		Accounts:   make(map[int]*account.Account),
-	}
-
-	cm.customers[customerID] = c
-	return c, nil
-}
-
-func (cm *CustomerManager) CreateAccountForCustomer(customerID, bankID int) (*account.Account, error) {
-	defer handlePanic("CreateAccountForCustomer")
-
-	if !cm.isAuthorizedAdmin() {
-		panic("admin authorization required")
-	}
-
-	cust := cm.customers[customerID]
-	if cust == nil || !cust.IsActive {
-		panic(fmt.Sprintf("customer ID %d not found or inactive", customerID))
-	}
-
-	bank := cm.banks[bankID]
-	if bank == nil {
-		panic(fmt.Sprintf("bank ID %d not found", bankID))
-	}
-
-	accountID := cm.generateCustomerID()
-	acc, err := account.NewAccount(accountID, customerID, bank.BankID)
-	if err != nil {
-		panic(err)
-	}
-
-	cust.Accounts[acc.AccountID] = acc
-	return acc, nil
-}
-
-func (cm *CustomerManager) GetAllCustomers() []Customer {
-	defer handlePanic("GetAllCustomers")
-
-	customers := make([]Customer, 0, len(cm.customers))
-	for _, c := range cm.customers {
-		if c.IsActive {
-			customers = append(customers, *c)
-		}
-	}
-	return customers
-}
-
-func (cm *CustomerManager) GetCustomerById(customerID int) *Customer {
-	defer handlePanic("GetCustomerById")
-	c := cm.customers[customerID]
-	if c != nil && c.IsActive {
-		return c
-	}
-	return nil
-}
-
-func (cm *CustomerManager) DeleteCustomer(customerID int) {
-	defer handlePanic("DeleteCustomer")
-
-	if !cm.isAuthorizedAdmin() {
-		panic("unauthorized: only admin can delete a customer")
-	}
-	c := cm.customers[customerID]
-	if c != nil {
-		c.IsActive = false
-		for _, acc := range c.Accounts {
-			acc.IsActive = false
-		}
-	}
-}
-
-func (cm *CustomerManager) DeleteCustomerAccountById(customerID, accountID int) {
-	defer handlePanic("DeleteCustomerAccountById")
-
-	if !cm.isAuthorizedCustomer(customerID) {
-		panic("unauthorized: only active customers can delete their accounts")
-	}
-	c := cm.customers[customerID]
-	if acc, ok := c.Accounts[accountID]; ok {
-		acc.IsActive = false
-	}
-}
-
-func (cm *CustomerManager) DepositMoney(amount float64, accountID int) error {
-	defer handlePanic("DepositMoney")
-
-	acc, err := cm.GetAccountById(accountID)
-	if err != nil {
-		panic(err)
-	}
-	return acc.DepositMoney(acc.OwnerID, amount)
-}
-
-func (cm *CustomerManager) WithDrawMoney(amount float64, accountID int) error {
-	defer handlePanic("WithDrawMoney")
-
-	acc, err := cm.GetAccountById(accountID)
-	if err != nil {
-		panic(err)
-	}
-	return acc.WithdrawMoney(acc.OwnerID, amount)
-}
-
-func (cm *CustomerManager) WithDrawMoneyByAccount_Id(amount float64, accountID int) error {
-	defer handlePanic("WithDrawMoneyByAccount_Id")
-
-	acc, err := cm.GetAccountById(accountID)
-	if err != nil {
-		panic(err)
-	}
-	return acc.WithdrawMoney(acc.OwnerID, amount)
-}
-
-func (cm *CustomerManager) TransferMoney_To_External(amount float64, fromCustomerID, toCustomerID, fromAccountID, toAccountID int) error {
-	defer handlePanic("TransferMoney_To_External")
-
-	if !cm.isAuthorizedCustomer(fromCustomerID) || !cm.isAuthorizedCustomer(toCustomerID) {
-		panic("only active customers allowed")
-	}
-
-	fromAcc, err := account.GetAccountById(fromAccountID)
-	if err != nil {
-		panic(err)
-	}
-	toAcc, err := account.GetAccountById(toAccountID)
-	if err != nil {
-		panic(err)
-	}
-
-	if fromAcc.BankID != toAcc.BankID {
-		if err := cm.ledger.RecordTransfer(fromAcc.BankID, toAcc.BankID, amount); err != nil {
-			panic(err)
-		}
-	}
-
-	return fromAcc.TransferMoneyToExternal(toAccountID, fromCustomerID, toCustomerID, amount)
-}
-
-func (cm *CustomerManager) TransferMoneyInternally(fromAccountID, toAccountID int, amount float64) error {
-	defer handlePanic("TransferMoneyInternally")
-	return account.TransferMoneyInternally(fromAccountID, toAccountID, amount)
-}
-
-func (cm *CustomerManager) GetPassBook_ById(customerID, accountID, pageNo int) []interface{} {
-	defer handlePanic("GetPassBook_ById")
-	return nil
-}
-
-func (cm *CustomerManager) GetTotalBalanceBy_Customer_Id(customerID int) float64 {
-	defer handlePanic("GetTotalBalanceBy_Customer_Id")
-
-	c := cm.customers[customerID]
-	if c == nil || !c.IsActive {
-		return 0
-	}
-	total := 0.0
-	for _, acc := range c.Accounts {
-		if acc.IsActive {
-			total += acc.Balance
-		}
-	}
-	return total
-}
-
-func (cm *CustomerManager) GetTotalBalance() float64 {
-	defer handlePanic("GetTotalBalance")
-
-	total := 0.0
-	for _, c := range cm.customers {
-		if !c.IsActive {
-			continue
-		}
-		for _, acc := range c.Accounts {
-			if acc.IsActive {
-				total += acc.Balance
-			}
-		}
-	}
-	return total
-}
-
-func (cm *CustomerManager) GetAccount_BalanceBy_Id(accountID int) float64 {
-	defer handlePanic("GetAccount_BalanceBy_Id")
-
-	acc, err := cm.GetAccountById(accountID)
-	if err != nil {
-		return 0
-	}
-	return acc.Balance
-}
-
-func (cm *CustomerManager) GetAccountById(accountID int) (*account.Account, error) {
-	defer handlePanic("GetAccountById")
-
-	for _, c := range cm.customers {
-		if !c.IsActive {
-			continue
-		}
-		if acc, ok := c.Accounts[accountID]; ok && acc.IsActive {
-			return acc, nil
-		}
-	}
-	return nil, apperror.NewNotFoundError("account", accountID)
-}
-
-func (cm *CustomerManager) DeleteAccountById(accountID int) error {
-	defer handlePanic("DeleteAccountById")
-
-	for _, c := range cm.customers {
-		if acc, ok := c.Accounts[accountID]; ok {
-			acc.IsActive = false
-			return nil
-		}
-	}
-	return apperror.NewNotFoundError("account", accountID)
-}
-
-func (cm *CustomerManager) UpdateAccount(accountID int, newBalance float64) error {
-	defer handlePanic("UpdateAccount")
-
-	for _, c := range cm.customers {
-		if acc, ok := c.Accounts[accountID]; ok && acc.IsActive {
-			acc.Balance = newBalance
-			return nil
-		}
-	}
-	return apperror.NewNotFoundError("account", accountID)
-}
-
-func (cm *CustomerManager) UpdateCustomer(customerID int, firstName, lastName string) error {
-	defer handlePanic("UpdateCustomer")
-
-	c := cm.customers[customerID]
-	if c == nil || !c.IsActive {
-		panic(fmt.Sprintf("customer ID %d not found or inactive", customerID))
-	}
-	if firstName != "" {
-		c.FirstName = firstName
-	}
-	if lastName != "" {
-		c.LastName = lastName
-	}
-	return nil
-}
-
-func (cm *CustomerManager) UpdateCustomerNameById(customerID int, firstName, lastName string) error {
-	defer handlePanic("UpdateCustomerNameById")
-	return cm.UpdateCustomer(customerID, firstName, lastName)
-}
-
-func TrimAndValidateName(name string) string {
-	return name
-}
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/bank"
+	"banking-app/beneficiary"
+	"banking-app/closure"
+	"banking-app/eod"
+	"banking-app/fx"
+	"banking-app/helper"
+	"banking-app/idempotency"
+	"banking-app/joint"
+	"banking-app/journal"
+	"banking-app/ledger"
+	"banking-app/money"
+	"banking-app/repository"
+	"banking-app/risk"
+	"banking-app/standing"
+	"banking-app/unitofwork"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const PassbookPageSize = 10
+
+type Customer struct {
+	CustomerID int
+	FirstName  string
+	LastName   string
+	Role       auth.Role
+	BankIDs    []int
+	IsActive   bool
+	// DeceasedOn is the day the customer died, if the bank has been told.
+	DeceasedOn time.Time
+	Accounts   map[int]*account.Account
+}
+
+type CustomerManager struct {
+	mu        sync.RWMutex
+	customers map[int]*Customer
+	banks     map[int]*bank.Bank
+	ledger    *ledger.Ledger
+	idCounter int
+
+	store         repository.Store
+	persistMu     sync.Mutex
+	persistedTxns map[int]int
+
+	journal          *journal.Journal
+	persistedJournal int
+	// books are what this manager's accounts post to.
+	books *account.Books
+
+	idempotency *idempotency.Cache
+
+	// limitsMu guards copies of the banks' limits and the temporary
+	// increases, which the account package reads with accounts locked.
+	limitsMu       sync.RWMutex
+	bankLimits     map[int]map[account.Product]account.Limits
+	limitIncreases map[int]account.LimitIncrease
+
+	// fxMu guards the rate provider, which is read with accounts locked.
+	fxMu  sync.RWMutex
+	rates fx.Provider
+
+	risk          *risk.Engine
+	reviewMu      sync.Mutex
+	reviews       map[int]*risk.Review
+	reviewCounter int
+
+	// approvalMu is taken before a beneficiary's cooling-off lock, reviewMu
+	// and mu, since an approval moves money once its holders have agreed.
+	approvalMu      sync.Mutex
+	approvals       map[int]*joint.Approval
+	approvalCounter int
+
+	closures       map[int]*closure.Closure
+	closureCounter int
+
+	beneficiaries      map[int]*beneficiary.Beneficiary
+	beneficiaryCounter int
+	beneficiaryPolicy  beneficiary.Policy
+	// coolingOffMu guards coolingOff, the amounts reserved against each
+	// beneficiary's cooling-off limit by transfers still on their way.
+	coolingOffMu sync.Mutex
+	coolingOff   map[int]*coolingOffReservation
+
+	standingMu      sync.Mutex
+	instructions    map[int]*standing.Instruction
+	executions      map[int][]standing.Execution
+	standingCounter int
+
+	credentials map[int]string
+	tokens      *auth.TokenIssuer
+
+	audit *audit.Log
+
+	now        func() time.Time
+	eodMu      sync.Mutex
+	eodRuns    map[string]eod.Run
+	lastClosed time.Time
+
+	failureInjector unitofwork.FailureInjector
+}
+
+// NewCustomerManager restores any state already held in store. A super-admin
+// is created from firstName, lastName and adminPassword only when the store
+// has none yet. Sessions are signed with a random secret until SetTokenIssuer is
+// called.
+func NewCustomerManager(store repository.Store, firstName, lastName, adminPassword string) (*CustomerManager, error) {
+	tokens, err := auth.NewRandomTokenIssuer(auth.DefaultSessionTTL)
+	if err != nil {
+		return nil, err
+	}
+	// Until SetRateProvider is called no currency converts into another.
+	rates, err := fx.NewStatic(0)
+	if err != nil {
+		return nil, err
+	}
+	cm := &CustomerManager{
+		customers:     make(map[int]*Customer),
+		banks:         make(map[int]*bank.Bank),
+		idCounter:     1000,
+		store:         store,
+		persistedTxns: make(map[int]int),
+		credentials:   make(map[int]string),
+		tokens:        tokens,
+		audit:         audit.NewLog(store.AppendAudit, store.SaveAuditHead),
+		journal:       journal.New(),
+		idempotency:   idempotency.NewCache(idempotency.DefaultTTL, store.SaveIdempotencyKey),
+		now:           time.Now,
+		eodRuns:       make(map[string]eod.Run),
+
+		bankLimits:     make(map[int]map[account.Product]account.Limits),
+		limitIncreases: make(map[int]account.LimitIncrease),
+
+		rates: rates,
+
+		risk:    risk.NewEngine(risk.DefaultRules()...),
+		reviews: make(map[int]*risk.Review),
+
+		approvals: make(map[int]*joint.Approval),
+		closures:  make(map[int]*closure.Closure),
+
+		beneficiaries:     make(map[int]*beneficiary.Beneficiary),
+		beneficiaryPolicy: beneficiary.DefaultPolicy(),
+		coolingOff:        make(map[int]*coolingOffReservation),
+
+		instructions: make(map[int]*standing.Instruction),
+		executions:   make(map[int][]standing.Execution),
+	}
+
+	cm.ledger = ledger.NewLedger(func(bankID int, currency string) (money.Money, error) {
+		cm.mu.RLock()
+		defer cm.mu.RUnlock()
+
+		total := money.Zero(currency)
+		for _, c := range cm.customers {
+			if !c.IsActive {
+				continue
+			}
+			for _, acc := range c.Accounts {
+				if acc.BankID == bankID && acc.Currency == currency && cm.banks[acc.BankID] != nil && acc.IsOpen() {
+					var err error
+					if total, err = total.Add(acc.GetBalance()); err != nil {
+						return money.Money{}, err
+					}
+				}
+			}
+		}
+		return total, nil
+	})
+
+	cm.books = account.NewBooks(cm.journal, cm.resolveLimits)
+	if err := cm.restore(); err != nil {
+		return nil, err
+	}
+	if len(cm.superAdminIDs()) > 0 {
+		return cm, nil
+	}
+
+	admin := &Customer{
+		CustomerID: cm.generateCustomerID(),
+		FirstName:  TrimAndValidateName(firstName),
+		LastName:   TrimAndValidateName(lastName),
+		Role:       auth.RoleSuperAdmin,
+		IsActive:   true,
+		Accounts:   make(map[int]*account.Account),
+	}
+	if err := cm.saveCustomer(admin); err != nil {
+		return nil, err
+	}
+	if err := cm.storeCredential(admin.CustomerID, adminPassword); err != nil {
+		return nil, err
+	}
+	cm.customers[admin.CustomerID] = admin
+	if err := cm.recordAudit(nil, audit.ActionStaffCreated, "customer", admin.CustomerID, nil, admin.auditFields()); err != nil {
+		return nil, err
+	}
+
+	return cm, nil
+}
+
+func (cm *CustomerManager) GetLedger() *ledger.Ledger {
+	return cm.ledger
+}
+
+// GetDues lists the interbank dues. Bank-scoped viewers only see dues their
+// own banks owe or are owed.
+func (cm *CustomerManager) GetDues(p *auth.Principal) ([]ledger.Due, error) {
+	cm.mu.RLock()
+	viewer, err := cm.authorize(p, auth.PermViewLedger)
+	cm.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	dues := cm.ledger.Dues()
+	visible := dues[:0]
+	for _, d := range dues {
+		if viewer.inScope(d.FromBankID) || viewer.inScope(d.ToBankID) {
+			visible = append(visible, d)
+		}
+	}
+	return visible, nil
+}
+
+// GetBankPosition is the bank's position in one currency. It releases
+// cm.mu before asking the ledger, whose balance callback takes it again.
+func (cm *CustomerManager) GetBankPosition(p *auth.Principal, bankID int, currency string) (actual, receivable, owed money.Money, err error) {
+	cm.mu.RLock()
+	_, err = cm.authorize(p, auth.PermViewLedger, bankID)
+	if err == nil {
+		_, err = cm.lookupBank(bankID)
+	}
+	cm.mu.RUnlock()
+	if err == nil {
+		currency, err = money.ParseCurrency(currency)
+	}
+	if err != nil {
+		return money.Money{}, money.Money{}, money.Money{}, err
+	}
+	return cm.ledger.GetNetBankPosition(bankID, currency)
+}
+
+// generateCustomerID and the lookup helpers expect cm.mu to be held by the
+// caller.
+func (cm *CustomerManager) generateCustomerID() int {
+	cm.idCounter++
+	return cm.idCounter
+}
+
+func (cm *CustomerManager) lookupCustomer(customerID int) (*Customer, error) {
+	c := cm.customers[customerID]
+	if c == nil {
+		return nil, apperror.NewNotFoundError("customer", customerID)
+	}
+	if !c.IsActive {
+		return nil, apperror.NewInactiveError("customer", customerID)
+	}
+	return c, nil
+}
+
+func (cm *CustomerManager) lookupBank(bankID int) (*bank.Bank, error) {
+	b := cm.banks[bankID]
+	if b == nil {
+		return nil, apperror.NewNotFoundError("bank", bankID)
+	}
+	if !b.IsActive {
+		return nil, apperror.NewInactiveError("bank", bankID)
+	}
+	return b, nil
+}
+
+// CreateNewBank opens a bank with a head office branch. The code must not
+// belong to another bank; if it is empty one is made up from the name.
+func (cm *CustomerManager) CreateNewBank(p *auth.Principal, fullname, code string) (*bank.Bank, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.authorize(p, auth.PermManageBanks); err != nil {
+		return nil, err
+	}
+	if code == "" {
+		code = bank.SuggestCode(fullname, cm.bankCodeTaken)
+	} else if parsed, err := bank.ParseCode(code); err == nil && cm.bankCodeTaken(parsed) {
+		return nil, apperror.NewValidationError("code", fmt.Sprintf("%s already belongs to bank %d", parsed, cm.bankByCode(parsed).BankID))
+	}
+	id := cm.generateCustomerID()
+	b, err := bank.NewBank(id, fullname, code)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.AddBranch(cm.generateCustomerID(), "Head Office", bank.HeadOfficeCode); err != nil {
+		return nil, err
+	}
+	if err := cm.store.SaveBank(*b); err != nil {
+		return nil, err
+	}
+	cm.banks[id] = b
+	if err := cm.recordAudit(p, audit.ActionBankCreated, "bank", id, nil, bankFields(b)); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (cm *CustomerManager) UpdateBankName(p *auth.Principal, bankID int, newName string) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.authorize(p, auth.PermManageBanks, bankID); err != nil {
+		return err
+	}
+	b, err := cm.lookupBank(bankID)
+	if err != nil {
+		return err
+	}
+	before := bankFields(b)
+	if err := b.UpdateBankName(newName); err != nil {
+		return err
+	}
+	if err := cm.store.SaveBank(*b); err != nil {
+		return err
+	}
+	return cm.recordAudit(p, audit.ActionBankRenamed, "bank", bankID, before, bankFields(b))
+}
+
+func (cm *CustomerManager) GetBankById(id int) (*bank.Bank, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.lookupBank(id)
+}
+
+func (cm *CustomerManager) GetAllBanks() []bank.Bank {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	banks := make([]bank.Bank, 0, len(cm.banks))
+	for _, b := range cm.banks {
+		banks = append(banks, *b)
+	}
+	return banks
+}
+
+func (cm *CustomerManager) DeleteBank(p *auth.Principal, bankID int) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.authorize(p, auth.PermManageBanks, bankID); err != nil {
+		return err
+	}
+	b, err := cm.lookupBank(bankID)
+	if err != nil {
+		return err
+	}
+	if err := cm.store.DeleteBank(bankID); err != nil {
+		return err
+	}
+	delete(cm.banks, bankID)
+	return cm.recordAudit(p, audit.ActionBankDeleted, "bank", bankID, bankFields(b), nil)
+}
+
+func (cm *CustomerManager) CreateNewCustomer(p *auth.Principal, firstName, lastName string) (*Customer, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.authorize(p, auth.PermOnboardCustomers); err != nil {
+		return nil, err
+	}
+
+	customerID := cm.generateCustomerID()
+	c := &Customer{
+		CustomerID: customerID,
+		FirstName:  TrimAndValidateName(firstName),
+		LastName:   TrimAndValidateName(lastName),
+		Role:       auth.RoleCustomer,
+		IsActive:   true,
+		Accounts:   make(map[int]*account.Account),
+	}
+
+	if err := cm.saveCustomer(c); err != nil {
+		return nil, err
+	}
+	cm.customers[customerID] = c
+	if err := cm.recordAudit(p, audit.ActionCustomerCreated, "customer", customerID, nil, c.auditFields()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// CreateAccountForCustomer opens an account at one of the bank's branches,
+// or its head office if branchID is 0, in currency of the given product
+// under its default terms, at the bank's current interest rate for the
+// product if it has one, funded with openingDeposit. The amounts in the
+// terms are converted from BaseCurrency at today's mid-market rate and stay
+// fixed after that.
+func (cm *CustomerManager) CreateAccountForCustomer(p *auth.Principal, customerID, bankID, branchID int, currency string, product account.Product, openingDeposit money.Money) (*account.Account, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.authorize(p, auth.PermOpenAccounts, bankID); err != nil {
+		return nil, err
+	}
+
+	cust, err := cm.lookupCustomer(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if cust.Role.IsStaff() {
+		return nil, apperror.NewValidationError("customerID", fmt.Sprintf("%d is a staff member and cannot hold accounts", customerID))
+	}
+	bank, err := cm.lookupBank(bankID)
+	if err != nil {
+		return nil, err
+	}
+	branch, err := lookupBranch(bank, branchID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err = money.ParseCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	terms, err := account.DefaultTerms(product).Convert(cm.inCurrency(currency))
+	if err != nil {
+		return nil, err
+	}
+	if rate, ok := bank.InterestRate(product); ok {
+		terms.InterestRateBPS = rate
+	}
+	accountID := cm.generateCustomerID()
+	number, err := account.FormatNumber(bank.Code, branch.Code, accountID)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := account.NewAccount(cm.books, accountID, number, customerID, bank.BankID, branch.BranchID, currency, terms, openingDeposit)
+	if err != nil {
+		return nil, err
+	}
+
+	cust.Accounts[acc.AccountID] = acc
+	if err := cm.saveAccounts(acc); err != nil {
+		return nil, err
+	}
+	if err := cm.recordAudit(p, audit.ActionAccountOpened, "account", acc.AccountID, nil, accountFields(acc)); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// GetAllCustomers lists every active customer and staff member. Bank-scoped
+// viewers only see the accounts held at their own banks.
+func (cm *CustomerManager) GetAllCustomers(p *auth.Principal) ([]Customer, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	viewer, err := cm.authorize(p, auth.PermViewCustomers)
+	if err != nil {
+		return nil, err
+	}
+	customers := make([]Customer, 0, len(cm.customers))
+	for _, c := range cm.customers {
+		if c.IsActive {
+			customers = append(customers, c.viewFor(viewer))
+		}
+	}
+	return customers, nil
+}
+
+func (cm *CustomerManager) GetCustomerById(p *auth.Principal, customerID int) (*Customer, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	viewer, err := cm.authorizeCustomerView(p, customerID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := cm.lookupCustomer(customerID)
+	if err != nil {
+		return nil, err
+	}
+	view := c.viewFor(viewer)
+	return &view, nil
+}
+
+func (cm *CustomerManager) DeleteCustomer(p *auth.Principal, customerID int) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	c := cm.customers[customerID]
+	if _, err := cm.authorizeOver(p, c, auth.PermDeleteCustomers); err != nil {
+		return err
+	}
+	if _, err := cm.lookupCustomer(customerID); err != nil {
+		return err
+	}
+	if err := cm.keepSuperAdmin(c, ""); err != nil {
+		return err
+	}
+	for _, acc := range cm.heldAccounts(customerID) {
+		if acc.IsOpen() {
+			return apperror.NewCustomerError("delete", fmt.Sprintf("customer %d still holds open account %d; close it first", customerID, acc.AccountID))
+		}
+	}
+	before := c.auditFields()
+	c.IsActive = false
+	if err := cm.saveCustomer(c); err != nil {
+		return err
+	}
+	return cm.recordAudit(p, audit.ActionCustomerDeleted, "customer", customerID, before, c.auditFields())
+}
+
+// DeleteCustomerAccountById closes one of the caller's accounts through
+// CloseAccount, paying what is left out in cash.
+func (cm *CustomerManager) DeleteCustomerAccountById(p *auth.Principal, accountID int) error {
+	cm.mu.RLock()
+	c, err := cm.requireCustomer(p)
+	cm.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if acc, err := account.GetAccountById(accountID); err != nil || !acc.IsHolder(c.CustomerID) {
+		return apperror.NewNotFoundError("account", accountID)
+	}
+	_, err = cm.CloseAccount(p, accountID, closure.Payout{Method: closure.MethodCash})
+	return err
+}
+
+// DepositMoney and the other money-moving operations take an optional
+// idempotency key; a retry under the same key does not move the money again.
+func (cm *CustomerManager) DepositMoney(p *auth.Principal, amount money.Money, accountID int, idempotencyKey ...string) error {
+	return cm.idempotent(p, idempotencyKey, idempotency.Fingerprint("deposit", amount.String(), accountID), func() error {
+		return cm.depositMoney(p, amount, accountID)
+	})
+}
+
+func (cm *CustomerManager) depositMoney(p *auth.Principal, amount money.Money, accountID int) error {
+	if err := cm.authorizeCustomer(p); err != nil {
+		return err
+	}
+	acc, err := cm.openAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if err := acc.DepositMoney(p.CustomerID(), amount); err != nil {
+		return err
+	}
+	if err := cm.saveAccounts(acc); err != nil {
+		return afterMove(err)
+	}
+	return afterMove(cm.recordMovement(p, audit.ActionDeposit, acc, amount))
+}
+
+// WithDrawMoney takes money out of an account. From a joint account whose
+// operating mode needs several holders to agree, it only opens an approval
+// and reports it with an apperror.PendingApprovalError.
+func (cm *CustomerManager) WithDrawMoney(p *auth.Principal, amount money.Money, accountID int, idempotencyKey ...string) error {
+	return cm.idempotent(p, idempotencyKey, idempotency.Fingerprint("withdrawal", amount.String(), accountID), func() error {
+		return cm.withdrawMoney(p, amount, accountID, firstKey(idempotencyKey))
+	})
+}
+
+func (cm *CustomerManager) withdrawMoney(p *auth.Principal, amount money.Money, accountID int, idempotencyKey string) error {
+	if err := cm.authorizeCustomer(p); err != nil {
+		return err
+	}
+	acc, err := cm.openAccount(accountID)
+	if err != nil {
+		return err
+	}
+	request := joint.Approval{Operation: joint.OpWithdrawal, Amount: amount, IdempotencyKey: idempotencyKey}
+	if held, err := cm.holdForHolders(p, acc, request); held || err != nil {
+		return err
+	}
+	return cm.withdraw(p, p.CustomerID(), acc, amount)
+}
+
+// withdraw takes amount out of acc for one of its holders. p is the actor
+// audited.
+func (cm *CustomerManager) withdraw(p *auth.Principal, customerID int, acc *account.Account, amount money.Money) error {
+	if err := acc.WithdrawMoney(customerID, amount); err != nil {
+		return err
+	}
+	if err := cm.saveAccounts(acc); err != nil {
+		return afterMove(err)
+	}
+	return afterMove(cm.recordMovement(p, audit.ActionWithdrawal, acc, amount))
+}
+
+func (cm *CustomerManager) WithDrawMoneyByAccount_Id(p *auth.Principal, amount money.Money, accountID int, idempotencyKey ...string) error {
+	return cm.idempotent(p, idempotencyKey, idempotency.Fingerprint("withdrawal", amount.String(), accountID), func() error {
+		return cm.withdrawMoney(p, amount, accountID, firstKey(idempotencyKey))
+	})
+}
+
+// sendExternal puts a transfer by fromCustomerID past the risk engine before
+// anything moves. Transfers it holds go to the review queue and move only
+// once approved; those it blocks never move. p is the actor audited, nil
+// for the system acting on the customer's behalf.
+func (cm *CustomerManager) sendExternal(p *auth.Principal, fromCustomerID int, amount money.Money, toCustomerID, fromAccountID, toAccountID int, idempotencyKey string) error {
+	cm.mu.RLock()
+	target, err := cm.lookupCustomer(toCustomerID)
+	if err == nil && target.Role != auth.RoleCustomer {
+		err = apperror.NewNotFoundError("customer", toCustomerID)
+	}
+	cm.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	fromAcc, err := account.GetAccountById(fromAccountID)
+	if err != nil {
+		return err
+	}
+	toAcc, err := account.GetAccountById(toAccountID)
+	if err != nil {
+		return err
+	}
+	if !fromAcc.IsHolder(fromCustomerID) {
+		return apperror.NewAuthError("sender does not hold the source account")
+	}
+	if !amount.IsPositive() {
+		return apperror.NewValidationError("amount", "must be greater than 0")
+	}
+
+	if held, err := cm.screenTransfer(p, fromCustomerID, amount, fromAcc, toAcc, toCustomerID, idempotencyKey); held || err != nil {
+		return err
+	}
+	return cm.moveExternal(p, amount, fromAcc, toAcc, fromCustomerID, toCustomerID)
+}
+
+// moveExternal moves a transfer that has been screened, or approved in
+// review, with p as the actor. A transfer between currencies is converted
+// at the rate when it moves, and the banks owe each other the converted
+// amount.
+func (cm *CustomerManager) moveExternal(p *auth.Principal, amount money.Money, fromAcc, toAcc *account.Account, fromCustomerID, toCustomerID int) error {
+	quote, err := cm.quote(fromAcc.Currency, toAcc.Currency)
+	if err != nil {
+		return err
+	}
+	toAccountID := toAcc.AccountID
+	uow := cm.newUnitOfWork()
+	if err := fromAcc.StageTransferToExternal(uow, toAccountID, fromCustomerID, toCustomerID, amount, quote); err != nil {
+		return err
+	}
+	if fromAcc.BankID != toAcc.BankID {
+		credited, err := quote.Convert(amount)
+		if err != nil {
+			return err
+		}
+		if err := cm.ledger.StageTransfer(uow, fromAcc.BankID, toAcc.BankID, credited); err != nil {
+			return err
+		}
+	}
+	if err := uow.Commit(); err != nil {
+		return err
+	}
+	if err := cm.saveAccounts(fromAcc, toAcc); err != nil {
+		return afterMove(err)
+	}
+	if fromAcc.BankID != toAcc.BankID {
+		if err := cm.saveDues(); err != nil {
+			return afterMove(err)
+		}
+	}
+	return afterMove(cm.recordTransfer(p, audit.ActionExternalTransfer, fromAcc, toAcc, amount, quote))
+}
+
+// TransferMoneyInternally moves money to another account the caller holds,
+// given by its account number. Like a withdrawal, a transfer out of a joint
+// account may have to wait for the other holders' approval.
+func (cm *CustomerManager) TransferMoneyInternally(p *auth.Principal, fromAccountID int, toAccountNumber string, amount money.Money, idempotencyKey ...string) error {
+	toAcc, err := cm.accountByNumber(toAccountNumber)
+	if err != nil {
+		return err
+	}
+	return cm.idempotent(p, idempotencyKey, idempotency.Fingerprint("internal-transfer", amount.String(), fromAccountID, toAcc.Number), func() error {
+		return cm.transferInternally(p, fromAccountID, toAcc.AccountID, amount, firstKey(idempotencyKey))
+	})
+}
+
+func (cm *CustomerManager) transferInternally(p *auth.Principal, fromAccountID, toAccountID int, amount money.Money, idempotencyKey string) error {
+	if err := cm.authorizeCustomer(p); err != nil {
+		return err
+	}
+	fromAcc, err := account.GetAccountById(fromAccountID)
+	if err != nil {
+		return err
+	}
+	toAcc, err := account.GetAccountById(toAccountID)
+	if err != nil {
+		return err
+	}
+	if !toAcc.IsHolder(p.CustomerID()) {
+		return apperror.NewAuthError("caller does not hold the target account")
+	}
+	request := joint.Approval{Operation: joint.OpInternalTransfer, Amount: amount, ToAccountID: toAccountID, IdempotencyKey: idempotencyKey}
+	if held, err := cm.holdForHolders(p, fromAcc, request); held || err != nil {
+		return err
+	}
+	return cm.moveInternally(p, p.CustomerID(), fromAccountID, toAccountID, amount)
+}
+
+// moveInternally moves money between two accounts customerID holds,
+// converting it if they are in different currencies. Between accounts at
+// different banks, the banks owe each other the converted amount as they
+// would for an external transfer. p is the actor audited, nil for the
+// system acting on the customer's behalf.
+func (cm *CustomerManager) moveInternally(p *auth.Principal, customerID, fromAccountID, toAccountID int, amount money.Money) error {
+	fromAcc, err := account.GetAccountById(fromAccountID)
+	if err != nil {
+		return err
+	}
+	toAcc, err := account.GetAccountById(toAccountID)
+	if err != nil {
+		return err
+	}
+	quote, err := cm.quote(fromAcc.Currency, toAcc.Currency)
+	if err != nil {
+		return err
+	}
+	uow := cm.newUnitOfWork()
+	if err := account.StageTransferInternally(uow, customerID, fromAccountID, toAccountID, amount, quote); err != nil {
+		return err
+	}
+	if fromAcc.BankID != toAcc.BankID {
+		credited, err := quote.Convert(amount)
+		if err != nil {
+			return err
+		}
+		if err := cm.ledger.StageTransfer(uow, fromAcc.BankID, toAcc.BankID, credited); err != nil {
+			return err
+		}
+	}
+	if err := uow.Commit(); err != nil {
+		return err
+	}
+	if err := cm.saveAccounts(fromAcc, toAcc); err != nil {
+		return afterMove(err)
+	}
+	if fromAcc.BankID != toAcc.BankID {
+		if err := cm.saveDues(); err != nil {
+			return afterMove(err)
+		}
+	}
+	return afterMove(cm.recordTransfer(p, audit.ActionInternalTransfer, fromAcc, toAcc, amount, quote))
+}
+
+// SetFailureInjector makes every transfer's unit of work consult inject after
+// each prepared step. It exists so tests can force failures mid-transfer.
+func (cm *CustomerManager) SetFailureInjector(inject unitofwork.FailureInjector) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.failureInjector = inject
+}
+
+func (cm *CustomerManager) newUnitOfWork() *unitofwork.UnitOfWork {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return unitofwork.New().WithFailureInjector(cm.failureInjector)
+}
+
+func (cm *CustomerManager) GetPassBook_ById(p *auth.Principal, accountID, pageNo int) ([]account.Transaction, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	c, err := cm.requireCustomer(p)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := cm.findOpenAccount(accountID)
+	if err != nil || !acc.IsHolder(c.CustomerID) {
+		return nil, apperror.NewNotFoundError("account", accountID)
+	}
+	passbook := acc.GetPassbook()
+	start, end := helper.PaginationBounds(pageNo, PassbookPageSize, len(passbook))
+	return passbook[start:end], nil
+}
+
+// GetTotalBalanceBy_Customer_Id totals the customer's open accounts as
+// totalBalance does.
+func (cm *CustomerManager) GetTotalBalanceBy_Customer_Id(p *auth.Principal, customerID int) (money.Money, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	viewer, err := cm.authorizeCustomerView(p, customerID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	c, err := cm.lookupCustomer(customerID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	var balances []money.Money
+	for _, acc := range c.Accounts {
+		if acc.IsOpen() && viewer.inScope(acc.BankID) {
+			balances = append(balances, acc.GetBalance())
+		}
+	}
+	return cm.totalBalance(balances)
+}
+
+// GetTotalBalance totals every open account as totalBalance does.
+func (cm *CustomerManager) GetTotalBalance(p *auth.Principal) (money.Money, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	viewer, err := cm.authorize(p, auth.PermViewLedger)
+	if err != nil {
+		return money.Money{}, err
+	}
+	var balances []money.Money
+	for _, c := range cm.customers {
+		if !c.IsActive {
+			continue
+		}
+		for _, acc := range c.Accounts {
+			if acc.IsOpen() && viewer.inScope(acc.BankID) {
+				balances = append(balances, acc.GetBalance())
+			}
+		}
+	}
+	return cm.totalBalance(balances)
+}
+
+// GetAccount_BalanceBy_Id is the balance of an account p may view, as
+// ViewAccount decides.
+func (cm *CustomerManager) GetAccount_BalanceBy_Id(p *auth.Principal, accountID int) (money.Money, error) {
+	acc, err := cm.ViewAccount(p, accountID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return acc.GetBalance(), nil
+}
+
+// GetAccountByNumber finds an account p may view, as ViewAccount decides,
+// by its number.
+func (cm *CustomerManager) GetAccountByNumber(p *auth.Principal, number string) (*account.Account, error) {
+	acc, err := cm.accountByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return cm.ViewAccount(p, acc.AccountID)
+}
+
+// openAccount finds an open account without checking who is asking; the
+// caller has done that already.
+func (cm *CustomerManager) openAccount(accountID int) (*account.Account, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.findOpenAccount(accountID)
+}
+
+// accountByNumber checks the number's check digits before looking it up, so
+// a mistyped number is refused instead of landing on someone else's account.
+func (cm *CustomerManager) accountByNumber(number string) (*account.Account, error) {
+	number, err := account.ParseNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return account.GetAccountByNumber(number)
+}
+
+// ViewAccount returns an account to any of its holders or to staff allowed
+// to view customers at the account's bank.
+func (cm *CustomerManager) ViewAccount(p *auth.Principal, accountID int) (*account.Account, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	acc, err := cm.findOpenAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if self, err := cm.requireCustomer(p); err == nil && acc.IsHolder(self.CustomerID) {
+		return acc, nil
+	}
+	if _, err := cm.authorize(p, auth.PermViewCustomers, acc.BankID); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// findOpenAccount expects cm.mu to be held by the caller.
+func (cm *CustomerManager) findOpenAccount(accountID int) (*account.Account, error) {
+	for _, c := range cm.customers {
+		if acc, ok := c.Accounts[accountID]; ok {
+			if !c.IsActive || !acc.IsOpen() {
+				return nil, apperror.NewInactiveError("account", accountID)
+			}
+			return acc, nil
+		}
+	}
+	return nil, apperror.NewNotFoundError("account", accountID)
+}
+
+// DeleteAccountById closes an account on the bank's side, paying what is
+// left out in cash. Use CloseAccount to pay it into another account.
+func (cm *CustomerManager) DeleteAccountById(p *auth.Principal, accountID int) error {
+	cm.mu.RLock()
+	acc, err := cm.findOpenAccount(accountID)
+	if err == nil {
+		_, err = cm.authorize(p, auth.PermCloseAccounts, acc.BankID)
+	}
+	cm.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	_, err = cm.settleAccount(p, acc, closure.ReasonRequest, 0, []closure.Payout{{Method: closure.MethodCash}}, []int{100})
+	return err
+}
+
+func (cm *CustomerManager) UpdateAccount(p *auth.Principal, accountID int, newBalance money.Money) error {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	for _, c := range cm.customers {
+		if acc, ok := c.Accounts[accountID]; ok && acc.IsOpen() {
+			if _, err := cm.authorize(p, auth.PermOverrideBalance, acc.BankID); err != nil {
+				return err
+			}
+			before := accountFields(acc)
+			if err := acc.SetBalance(newBalance); err != nil {
+				return err
+			}
+			if err := cm.saveAccounts(acc); err != nil {
+				return err
+			}
+			return cm.recordAudit(p, audit.ActionBalanceOverridden, "account", accountID, before, accountFields(acc))
+		}
+	}
+	return apperror.NewNotFoundError("account", accountID)
+}
+
+func (cm *CustomerManager) UpdateCustomer(p *auth.Principal, customerID int, firstName, lastName string) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	c := cm.customers[customerID]
+	if _, err := cm.authorizeOver(p, c, auth.PermOnboardCustomers); err != nil {
+		return err
+	}
+	if _, err := cm.lookupCustomer(customerID); err != nil {
+		return err
+	}
+	before := c.auditFields()
+	if firstName != "" {
+		c.FirstName = firstName
+	}
+	if lastName != "" {
+		c.LastName = lastName
+	}
+	if err := cm.saveCustomer(c); err != nil {
+		return err
+	}
+	return cm.recordAudit(p, audit.ActionCustomerUpdated, "customer", customerID, before, c.auditFields())
+}
+
+func (cm *CustomerManager) UpdateCustomerNameById(p *auth.Principal, customerID int, firstName, lastName string) error {
+	return cm.UpdateCustomer(p, customerID, firstName, lastName)
+}
+
+func TrimAndValidateName(name string) string {
+	return name
+}
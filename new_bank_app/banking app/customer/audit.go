@@ -0,0 +1,102 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/bank"
+	"banking-app/fx"
+	"banking-app/money"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// recordAudit appends an entry on behalf of p, or of the system when p is
+// nil.
+func (cm *CustomerManager) recordAudit(p *auth.Principal, action audit.Action, resource string, resourceID int, before, after map[string]string) error {
+	actorID := audit.SystemActor
+	if p != nil {
+		actorID = p.CustomerID()
+	}
+	_, err := cm.audit.Append(actorID, action, resource, resourceID, before, after)
+	return err
+}
+
+// recordMovement logs money leaving or entering acc.
+func (cm *CustomerManager) recordMovement(p *auth.Principal, action audit.Action, acc *account.Account, amount money.Money) error {
+	return cm.recordAudit(p, action, "account", acc.AccountID, nil, movementFields(acc, amount))
+}
+
+// recordTransfer logs amount leaving acc for to, converted at quote.
+func (cm *CustomerManager) recordTransfer(p *auth.Principal, action audit.Action, acc, to *account.Account, amount money.Money, quote fx.Quote) error {
+	after := movementFields(acc, amount)
+	after["to_account_id"] = strconv.Itoa(to.AccountID)
+	if !quote.IsIdentity() {
+		after["fx_rate"] = quote.Rate.String()
+		after["fx_spread_bps"] = strconv.FormatInt(quote.SpreadBPS, 10)
+	}
+	return cm.recordAudit(p, action, "account", acc.AccountID, nil, after)
+}
+
+func movementFields(acc *account.Account, amount money.Money) map[string]string {
+	return map[string]string{
+		"amount":  amount.String(),
+		"balance": acc.GetBalance().String(),
+	}
+}
+
+func (cm *CustomerManager) QueryAuditLog(p *auth.Principal, filter audit.Filter) ([]audit.Entry, error) {
+	cm.mu.RLock()
+	_, err := cm.authorize(p, auth.PermViewAudit)
+	cm.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	return cm.audit.Query(filter), nil
+}
+
+func (cm *CustomerManager) VerifyAuditLog(p *auth.Principal) error {
+	cm.mu.RLock()
+	_, err := cm.authorize(p, auth.PermViewAudit)
+	cm.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return cm.audit.Verify()
+}
+
+func bankFields(b *bank.Bank) map[string]string {
+	return map[string]string{
+		"name":      b.Name,
+		"code":      b.Code,
+		"is_active": strconv.FormatBool(b.IsActive),
+	}
+}
+
+func (c *Customer) auditFields() map[string]string {
+	fields := map[string]string{
+		"first_name": c.FirstName,
+		"last_name":  c.LastName,
+		"role":       string(c.Role),
+		"bank_ids":   fmt.Sprint(c.BankIDs),
+		"is_active":  strconv.FormatBool(c.IsActive),
+	}
+	if !c.DeceasedOn.IsZero() {
+		fields["deceased_on"] = c.DeceasedOn.Format(time.DateOnly)
+	}
+	return fields
+}
+
+func accountFields(acc *account.Account) map[string]string {
+	s := acc.Snapshot()
+	return map[string]string{
+		"number":    s.Number,
+		"owner_id":  strconv.Itoa(s.OwnerID),
+		"bank_id":   strconv.Itoa(s.BankID),
+		"branch_id": strconv.Itoa(s.BranchID),
+		"product":   string(s.Terms.Product),
+		"balance":   s.Balance.String(),
+		"is_active": strconv.FormatBool(s.IsActive),
+	}
+}
@@ -0,0 +1,366 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/beneficiary"
+	"banking-app/idempotency"
+	"banking-app/joint"
+	"banking-app/money"
+	"banking-app/risk"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SetBeneficiaryPolicy sets the cooling-off applied to beneficiaries,
+// including those already added.
+func (cm *CustomerManager) SetBeneficiaryPolicy(policy beneficiary.Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.beneficiaryPolicy = policy
+	return nil
+}
+
+func (cm *CustomerManager) BeneficiaryPolicy() beneficiary.Policy {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.beneficiaryPolicy
+}
+
+// AddBeneficiary registers another customer's account, given by its
+// account number, as somewhere the caller can send money. Only a small
+// amount may be sent to it until the cooling-off period has passed.
+func (cm *CustomerManager) AddBeneficiary(p *auth.Principal, accountNumber string, nickname string) (beneficiary.Beneficiary, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.requireCustomer(p); err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	nickname, err := beneficiary.NormalizeNickname(nickname)
+	if err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	acc, err := cm.accountByNumber(accountNumber)
+	if err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	accountID := acc.AccountID
+	payee := cm.customers[acc.OwnerID]
+	if !acc.IsOpen() || payee == nil || !payee.IsActive || payee.Role != auth.RoleCustomer {
+		return beneficiary.Beneficiary{}, apperror.NewCodeNotFoundError("account", acc.Number)
+	}
+	if acc.IsHolder(p.CustomerID()) {
+		return beneficiary.Beneficiary{}, apperror.NewValidationError("accountID", "own accounts are paid with internal transfers")
+	}
+	if err := cm.checkBeneficiaryUnique(p.CustomerID(), 0, accountID, nickname); err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+
+	b := &beneficiary.Beneficiary{
+		BeneficiaryID: cm.beneficiaryCounter + 1,
+		CustomerID:    p.CustomerID(),
+		Nickname:      nickname,
+		AccountID:     accountID,
+		AccountNumber: acc.Number,
+		BankID:        acc.BankID,
+		PayeeID:       acc.OwnerID,
+		AddedAt:       cm.now().UTC(),
+	}
+	if err := cm.store.SaveBeneficiary(*b); err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	cm.beneficiaryCounter = b.BeneficiaryID
+	cm.beneficiaries[b.BeneficiaryID] = b
+	if err := cm.recordAudit(p, audit.ActionBeneficiaryAdded, "beneficiary", b.BeneficiaryID, nil, beneficiaryFields(b)); err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	return *b, nil
+}
+
+// Beneficiaries lists the caller's beneficiaries, oldest first.
+func (cm *CustomerManager) Beneficiaries(p *auth.Principal) ([]beneficiary.Beneficiary, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if _, err := cm.requireCustomer(p); err != nil {
+		return nil, err
+	}
+	var list []beneficiary.Beneficiary
+	for _, b := range cm.beneficiaries {
+		if b.CustomerID == p.CustomerID() && !b.IsDeleted() {
+			list = append(list, *b)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].BeneficiaryID < list[j].BeneficiaryID })
+	return list, nil
+}
+
+func (cm *CustomerManager) Beneficiary(p *auth.Principal, beneficiaryID int) (beneficiary.Beneficiary, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	b, err := cm.ownBeneficiary(p, beneficiaryID)
+	if err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	return *b, nil
+}
+
+// RenameBeneficiary changes a beneficiary's nickname. The cooling-off
+// period carries on from when it was added.
+func (cm *CustomerManager) RenameBeneficiary(p *auth.Principal, beneficiaryID int, nickname string) (beneficiary.Beneficiary, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	b, err := cm.ownBeneficiary(p, beneficiaryID)
+	if err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	nickname, err = beneficiary.NormalizeNickname(nickname)
+	if err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	if err := cm.checkBeneficiaryUnique(b.CustomerID, b.BeneficiaryID, 0, nickname); err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	before := beneficiaryFields(b)
+	renamed := *b
+	renamed.Nickname = nickname
+	if err := cm.store.SaveBeneficiary(renamed); err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	*b = renamed
+	if err := cm.recordAudit(p, audit.ActionBeneficiaryRenamed, "beneficiary", b.BeneficiaryID, before, beneficiaryFields(b)); err != nil {
+		return beneficiary.Beneficiary{}, err
+	}
+	return *b, nil
+}
+
+func (cm *CustomerManager) DeleteBeneficiary(p *auth.Principal, beneficiaryID int) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	b, err := cm.ownBeneficiary(p, beneficiaryID)
+	if err != nil {
+		return err
+	}
+	deleted := *b
+	deleted.DeletedAt = cm.now().UTC()
+	if err := cm.store.SaveBeneficiary(deleted); err != nil {
+		return err
+	}
+	before := beneficiaryFields(b)
+	*b = deleted
+	return cm.recordAudit(p, audit.ActionBeneficiaryDeleted, "beneficiary", b.BeneficiaryID, before, nil)
+}
+
+// TransferToBeneficiary sends amount from one of the caller's accounts to
+// one of their beneficiaries.
+func (cm *CustomerManager) TransferToBeneficiary(p *auth.Principal, fromAccountID, beneficiaryID int, amount money.Money, idempotencyKey ...string) error {
+	return cm.idempotent(p, idempotencyKey, idempotency.Fingerprint("beneficiary-transfer", amount.String(), fromAccountID, beneficiaryID), func() error {
+		return cm.transferToBeneficiary(p, fromAccountID, beneficiaryID, amount, firstKey(idempotencyKey))
+	})
+}
+
+func (cm *CustomerManager) transferToBeneficiary(p *auth.Principal, fromAccountID, beneficiaryID int, amount money.Money, idempotencyKey string) error {
+	cm.mu.RLock()
+	_, err := cm.requireCustomer(p)
+	if err == nil {
+		_, err = cm.beneficiaryOf(p.CustomerID(), beneficiaryID)
+	}
+	cm.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	fromAcc, err := account.GetAccountById(fromAccountID)
+	if err != nil {
+		return err
+	}
+	request := joint.Approval{Operation: joint.OpBeneficiaryTransfer, Amount: amount, BeneficiaryID: beneficiaryID, IdempotencyKey: idempotencyKey}
+	if held, err := cm.holdForHolders(p, fromAcc, request); held || err != nil {
+		return err
+	}
+	return cm.payBeneficiary(p, p.CustomerID(), fromAccountID, beneficiaryID, amount, idempotencyKey)
+}
+
+// payBeneficiary sends money from customerID to one of their beneficiaries.
+// p is the actor audited, nil for the system acting on the customer's
+// behalf.
+func (cm *CustomerManager) payBeneficiary(p *auth.Principal, customerID, fromAccountID, beneficiaryID int, amount money.Money, idempotencyKey string) error {
+	cm.mu.RLock()
+	b, err := cm.beneficiaryOf(customerID, beneficiaryID)
+	var target beneficiary.Beneficiary
+	if err == nil {
+		target = *b
+	}
+	policy := cm.beneficiaryPolicy
+	now := cm.now().UTC()
+	cm.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if policy.CoolingOffAt(target, now) {
+		release, err := cm.reserveCoolingOff(policy, target, fromAccountID, amount)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	// The account may have passed to another holder since it was added, so
+	// it is paid to whoever owns it now.
+	toAcc, err := account.GetAccountById(target.AccountID)
+	if err != nil {
+		return err
+	}
+	return cm.sendExternal(p, customerID, amount, toAcc.Snapshot().OwnerID, fromAccountID, target.AccountID, idempotencyKey)
+}
+
+// coolingOffReservation is what transfers to one beneficiary that are still
+// on their way have reserved against its cooling-off limit. Its lock
+// serialises the transfers to that beneficiary only while they check the
+// limit and reserve their amount.
+type coolingOffReservation struct {
+	mu       sync.Mutex
+	reserved money.Money
+}
+
+// reserveCoolingOff refuses amount if it would take what the customer has
+// sent to b since adding it, has waiting in review or has on its way, past
+// the limit. Otherwise it reserves amount until the transfer has moved, so
+// concurrent transfers cannot each fit under the limit and together exceed
+// it; the caller calls release once it has. Amounts in other currencies
+// count at the mid-market rate.
+func (cm *CustomerManager) reserveCoolingOff(policy beneficiary.Policy, b beneficiary.Beneficiary, fromAccountID int, amount money.Money) (release func(), err error) {
+	cm.coolingOffMu.Lock()
+	r := cm.coolingOff[b.BeneficiaryID]
+	if r == nil {
+		r = &coolingOffReservation{}
+		cm.coolingOff[b.BeneficiaryID] = r
+	}
+	cm.coolingOffMu.Unlock()
+
+	currency := policy.CoolingOffLimit.Currency
+	if amount, err = cm.atMid(amount, currency); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sent, err := cm.sentToBeneficiary(b, currency)
+	if err != nil {
+		return nil, err
+	}
+	total, err := sent.Add(r.reserved)
+	if err == nil {
+		total, err = total.Add(amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+	over, err := policy.CoolingOffLimit.LessThan(total)
+	if err != nil {
+		return nil, err
+	}
+	if over {
+		limit := fmt.Sprintf("cooling-off limit of %s for new beneficiary %q", policy.CoolingOffLimit, b.Nickname)
+		return nil, apperror.NewLimitExceededError(fromAccountID, limit, policy.CoolsOffAt(b))
+	}
+	r.reserved, _ = r.reserved.Add(amount)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.reserved, _ = r.reserved.Sub(amount)
+	}, nil
+}
+
+func (cm *CustomerManager) sentToBeneficiary(b beneficiary.Beneficiary, currency string) (money.Money, error) {
+	sent := money.Zero(currency)
+	add := func(m money.Money) (err error) {
+		if m, err = cm.atMid(m, currency); err != nil {
+			return err
+		}
+		sent, err = sent.Add(m)
+		return err
+	}
+
+	cm.mu.RLock()
+	accounts := cm.heldAccounts(b.CustomerID)
+	cm.mu.RUnlock()
+	for _, acc := range accounts {
+		for _, txn := range acc.GetPassbook() {
+			if txn.Type != account.TxnExternalTransferOut || txn.CounterpartyAccountID != b.AccountID || txn.Timestamp.Before(b.AddedAt) {
+				continue
+			}
+			if err := add(txn.Amount); err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+
+	cm.reviewMu.Lock()
+	defer cm.reviewMu.Unlock()
+	for _, r := range cm.reviews {
+		if r.Status != risk.ReviewPending || r.CustomerID != b.CustomerID || r.ToAccountID != b.AccountID || r.HeldAt.Before(b.AddedAt) {
+			continue
+		}
+		if err := add(r.Amount); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return sent, nil
+}
+
+// ownBeneficiary finds one of the caller's beneficiaries. Those of other
+// customers are reported as not found. It expects cm.mu to be held.
+func (cm *CustomerManager) ownBeneficiary(p *auth.Principal, beneficiaryID int) (*beneficiary.Beneficiary, error) {
+	if _, err := cm.requireCustomer(p); err != nil {
+		return nil, err
+	}
+	return cm.beneficiaryOf(p.CustomerID(), beneficiaryID)
+}
+
+// beneficiaryOf expects cm.mu to be held.
+func (cm *CustomerManager) beneficiaryOf(customerID, beneficiaryID int) (*beneficiary.Beneficiary, error) {
+	b := cm.beneficiaries[beneficiaryID]
+	if b == nil || b.IsDeleted() || b.CustomerID != customerID {
+		return nil, apperror.NewNotFoundError("beneficiary", beneficiaryID)
+	}
+	return b, nil
+}
+
+// checkBeneficiaryUnique refuses a second beneficiary for the same account
+// or under the same nickname. exceptID skips the beneficiary being renamed,
+// and a zero accountID skips the account check. It expects cm.mu to be held.
+func (cm *CustomerManager) checkBeneficiaryUnique(customerID, exceptID, accountID int, nickname string) error {
+	for _, b := range cm.beneficiaries {
+		if b.CustomerID != customerID || b.BeneficiaryID == exceptID || b.IsDeleted() {
+			continue
+		}
+		if accountID != 0 && b.AccountID == accountID {
+			return apperror.NewValidationError("accountID", fmt.Sprintf("account %d is already beneficiary %d", accountID, b.BeneficiaryID))
+		}
+		if strings.EqualFold(b.Nickname, nickname) {
+			return apperror.NewValidationError("nickname", fmt.Sprintf("%q is already in use", nickname))
+		}
+	}
+	return nil
+}
+
+func beneficiaryFields(b *beneficiary.Beneficiary) map[string]string {
+	return map[string]string{
+		"nickname":       b.Nickname,
+		"account_id":     strconv.Itoa(b.AccountID),
+		"account_number": b.AccountNumber,
+		"bank_id":        strconv.Itoa(b.BankID),
+		"payee_id":       strconv.Itoa(b.PayeeID),
+	}
+}
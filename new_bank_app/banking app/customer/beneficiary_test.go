@@ -0,0 +1,50 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/risk"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrentTransfersStayWithinTheCoolingOffLimit(t *testing.T) {
+	cm, admin := newTestManager(t)
+	cm.SetRiskEngine(risk.NewEngine())
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	shruti, _ := newTestCustomer(t, cm, admin, "Shruti")
+	from := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 100000)
+	to := newTestAccount(t, cm, admin, shruti, sbi, account.ProductSavings, 1000)
+	payee, err := cm.AddBeneficiary(p, to.Number, "Shruti")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The default policy lets a new beneficiary receive INR 5000.00 until it
+	// cools off, so five of these fit and the rest are refused.
+	var paid, refused atomic.Int64
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var exceeded *apperror.LimitExceededError
+			switch err := cm.TransferToBeneficiary(p, from.AccountID, payee.BeneficiaryID, rupees(1000)); {
+			case err == nil:
+				paid.Add(1)
+			case errors.As(err, &exceeded):
+				refused.Add(1)
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if paid.Load() != 5 || refused.Load() != 3 {
+		t.Errorf("%d transfers paid and %d refused, want 5 and 3", paid.Load(), refused.Load())
+	}
+	wantBalance(t, to, rupees(6000))
+}
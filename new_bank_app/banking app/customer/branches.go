@@ -0,0 +1,111 @@
+package customer
+
+import (
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/bank"
+	"strconv"
+)
+
+// GetBankByCode finds a bank by its code, in any case.
+func (cm *CustomerManager) GetBankByCode(code string) (*bank.Bank, error) {
+	code, err := bank.ParseCode(code)
+	if err != nil {
+		return nil, err
+	}
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	b := cm.bankByCode(code)
+	if b == nil {
+		return nil, apperror.NewCodeNotFoundError("bank", code)
+	}
+	return cm.lookupBank(b.BankID)
+}
+
+// OpenBranch opens a branch of the bank under a code none of its other
+// branches use.
+func (cm *CustomerManager) OpenBranch(p *auth.Principal, bankID int, name, code string) (bank.Branch, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.authorize(p, auth.PermManageBanks, bankID); err != nil {
+		return bank.Branch{}, err
+	}
+	b, err := cm.lookupBank(bankID)
+	if err != nil {
+		return bank.Branch{}, err
+	}
+	branch, err := b.AddBranch(cm.generateCustomerID(), name, code)
+	if err != nil {
+		return bank.Branch{}, err
+	}
+	if err := cm.store.SaveBank(*b); err != nil {
+		return bank.Branch{}, err
+	}
+	return branch, cm.recordAudit(p, audit.ActionBranchOpened, "branch", branch.BranchID, nil, branchFields(branch))
+}
+
+// GetBranchByRoutingCode finds a branch, and the bank it belongs to, by its
+// routing code.
+func (cm *CustomerManager) GetBranchByRoutingCode(routingCode string) (*bank.Bank, bank.Branch, error) {
+	bankCode, branchCode, err := bank.ParseRoutingCode(routingCode)
+	if err != nil {
+		return nil, bank.Branch{}, err
+	}
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	b := cm.bankByCode(bankCode)
+	if b == nil || !b.IsActive {
+		return nil, bank.Branch{}, apperror.NewCodeNotFoundError("branch", bank.RoutingCode(bankCode, branchCode))
+	}
+	branch, ok := b.BranchByCode(branchCode)
+	if !ok || !branch.IsActive {
+		return nil, bank.Branch{}, apperror.NewCodeNotFoundError("branch", bank.RoutingCode(bankCode, branchCode))
+	}
+	return b, branch, nil
+}
+
+// bankByCode expects cm.mu to be held.
+func (cm *CustomerManager) bankByCode(code string) *bank.Bank {
+	for _, b := range cm.banks {
+		if b.Code == code {
+			return b
+		}
+	}
+	return nil
+}
+
+// bankCodeTaken expects cm.mu to be held.
+func (cm *CustomerManager) bankCodeTaken(code string) bool {
+	return cm.bankByCode(code) != nil
+}
+
+// lookupBranch finds an open branch of b, its head office if branchID is 0.
+func lookupBranch(b *bank.Bank, branchID int) (bank.Branch, error) {
+	var branch bank.Branch
+	var ok bool
+	if branchID == 0 {
+		branch, ok = b.HeadOffice()
+	} else {
+		branch, ok = b.Branch(branchID)
+	}
+	if !ok {
+		return bank.Branch{}, apperror.NewNotFoundError("branch", branchID)
+	}
+	if !branch.IsActive {
+		return bank.Branch{}, apperror.NewInactiveError("branch", branchID)
+	}
+	return branch, nil
+}
+
+func branchFields(b bank.Branch) map[string]string {
+	return map[string]string{
+		"bank_id":      strconv.Itoa(b.BankID),
+		"name":         b.Name,
+		"code":         b.Code,
+		"routing_code": b.RoutingCode,
+	}
+}
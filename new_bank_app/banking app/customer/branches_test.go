@@ -0,0 +1,47 @@
+package customer
+
+import (
+	"banking-app/apperror"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBankCodesAreUnique(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+
+	for _, code := range []string{"SBIN", " sbin "} {
+		_, err := cm.CreateNewBank(admin, "Southern Bank of India", code)
+		var invalid *apperror.ValidationError
+		if !errors.As(err, &invalid) || !strings.Contains(err.Error(), "already belongs") {
+			t.Errorf("code %q: err = %v, want the code refused as taken", code, err)
+		}
+	}
+	for _, code := range []string{"SBI", "SB1N", "SBINX"} {
+		if _, err := cm.CreateNewBank(admin, "Southern Bank of India", code); err == nil {
+			t.Errorf("created a bank under the invalid code %q", code)
+		}
+	}
+
+	// Without a code, each bank is given one no other bank has.
+	stanley := newTestBank(t, cm, admin, "Stanley India", "")
+	india := newTestBank(t, cm, admin, "Stanley Bank of India", "")
+	if stanley.Code != "STIA" || india.Code == stanley.Code || india.Code == sbi.Code {
+		t.Errorf("suggested codes %q and %q beside %q", stanley.Code, india.Code, sbi.Code)
+	}
+
+	if err := cm.UpdateBankName(admin, sbi.BankID, "Bank of Stanley"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cm.GetBankByCode("sbin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.BankID != sbi.BankID || got.Code != "SBIN" {
+		t.Errorf("SBIN after a rename is bank %d with code %q, want bank %d", got.BankID, got.Code, sbi.BankID)
+	}
+	if _, err := cm.GetBankByCode("HDFC"); err == nil {
+		t.Error("found a bank under a code no bank has")
+	}
+}
@@ -0,0 +1,483 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/closure"
+	"banking-app/eod"
+	"banking-app/joint"
+	"banking-app/money"
+	"banking-app/risk"
+	"banking-app/standing"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// CloseAccount settles and closes an account. Interest accrued up to
+// yesterday is credited, a fixed deposit that has not matured is charged its
+// premature-withdrawal penalty, and what is left is paid out as payout says.
+// A holder may close an account they can debit alone; staff need
+// PermCloseAccounts at its bank. An account cannot be closed while it is
+// frozen, holds money under liens, has standing instructions of its own or
+// has debits waiting for its holders or for review.
+func (cm *CustomerManager) CloseAccount(p *auth.Principal, accountID int, payout closure.Payout) (closure.Closure, error) {
+	if err := payout.Validate(); err != nil {
+		return closure.Closure{}, err
+	}
+	cm.mu.RLock()
+	acc, err := cm.findOpenAccount(accountID)
+	if err == nil {
+		err = cm.authorizeClosure(p, acc)
+	}
+	cm.mu.RUnlock()
+	if err != nil {
+		return closure.Closure{}, err
+	}
+	return cm.settleAccount(p, acc, closure.ReasonRequest, 0, []closure.Payout{payout}, []int{100})
+}
+
+// authorizeClosure expects cm.mu to be held.
+func (cm *CustomerManager) authorizeClosure(p *auth.Principal, acc *account.Account) error {
+	if self, err := cm.requireCustomer(p); err == nil && acc.IsHolder(self.CustomerID) {
+		return requireSoleMandate(acc)
+	}
+	_, err := cm.authorize(p, auth.PermCloseAccounts, acc.BankID)
+	return err
+}
+
+// AccountClosure is how a closed account was settled. Its holders may see
+// it, as may staff who view customers at its bank.
+func (cm *CustomerManager) AccountClosure(p *auth.Principal, accountID int) (closure.Closure, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	acc, err := account.GetAccountById(accountID)
+	if err != nil {
+		return closure.Closure{}, err
+	}
+	if self, err := cm.requireCustomer(p); err != nil || !acc.IsHolder(self.CustomerID) {
+		if _, err := cm.authorize(p, auth.PermViewCustomers, acc.BankID); err != nil {
+			return closure.Closure{}, err
+		}
+	}
+	for _, c := range cm.closures {
+		if c.AccountID == accountID {
+			return *c, nil
+		}
+	}
+	return closure.Closure{}, apperror.NewNotFoundError("closure of account", accountID)
+}
+
+// SetNominees replaces the nominees of an account, who are paid its balance
+// when its last holder dies. Any holder may set them, as may staff who open
+// accounts at its bank.
+func (cm *CustomerManager) SetNominees(p *auth.Principal, accountID int, nominees []account.Nominee) ([]account.Nominee, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	acc, err := cm.findOpenAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if self, err := cm.requireCustomer(p); err != nil || !acc.IsHolder(self.CustomerID) {
+		if _, err := cm.authorize(p, auth.PermOpenAccounts, acc.BankID); err != nil {
+			return nil, err
+		}
+	}
+	before := nomineeFields(acc.GetNominees())
+	set, err := acc.SetNominees(nominees)
+	if err != nil {
+		return nil, err
+	}
+	if err := cm.saveAccounts(acc); err != nil {
+		return nil, err
+	}
+	return set, cm.recordAudit(p, audit.ActionNomineesSet, "account", accountID, before, nomineeFields(set))
+}
+
+// RecordDeath records that a customer died on diedOn. They can no longer
+// sign in and their standing instructions are cancelled. Each joint account
+// they held passes to the holders who survive them; an account they held
+// alone stays as it is until its nominees claim it with SettleNomineeClaim.
+// It needs PermCloseAccounts at every bank the customer holds accounts at,
+// or at every bank if they hold none.
+//
+// Each change is saved before it is made, and the customer is marked as
+// deceased only once their accounts and instructions have been dealt with,
+// so a call that fails part of the way through can simply be made again.
+func (cm *CustomerManager) RecordDeath(p *auth.Principal, customerID int, diedOn time.Time) error {
+	cm.standingMu.Lock()
+	defer cm.standingMu.Unlock()
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	c, err := cm.lookupCustomer(customerID)
+	if err != nil {
+		return err
+	}
+	if c.Role != auth.RoleCustomer {
+		return apperror.NewValidationError("customerID", fmt.Sprintf("%d is a staff member, not a customer", customerID))
+	}
+	if _, err := cm.authorizeOver(p, c, auth.PermCloseAccounts); err != nil {
+		return err
+	}
+	diedOn = eod.BusinessDate(diedOn)
+	if diedOn.IsZero() || diedOn.After(cm.now()) {
+		return apperror.NewValidationError("diedOn", "must be a date that has passed")
+	}
+
+	for _, acc := range cm.heldAccounts(customerID) {
+		if len(acc.Holders()) == 1 {
+			continue
+		}
+		holdersBefore := holderFields(acc)
+		after, err := acc.WithoutHolder(customerID)
+		if err != nil {
+			return err
+		}
+		if err := cm.saveAccountAs(acc, after); err != nil {
+			return err
+		}
+		if err := acc.RemoveHolder(customerID); err != nil {
+			return err
+		}
+		if owner := cm.customers[after.OwnerID]; owner != nil && owner != c {
+			delete(c.Accounts, acc.AccountID)
+			owner.Accounts[acc.AccountID] = acc
+		}
+		if err := cm.recordAudit(p, audit.ActionHolderRemoved, "account", acc.AccountID, holdersBefore, holderFields(acc)); err != nil {
+			return err
+		}
+	}
+	for _, in := range cm.instructions {
+		if in.CustomerID != customerID || (in.Status != standing.StatusActive && in.Status != standing.StatusSuspended) {
+			continue
+		}
+		cancelled := *in
+		cancelled.Status = standing.StatusCancelled
+		if err := cm.store.SaveInstruction(cancelled); err != nil {
+			return err
+		}
+		instructionBefore := instructionFields(in)
+		*in = cancelled
+		if err := cm.recordAudit(p, audit.ActionStandingCancelled, "standing_instruction", in.InstructionID, instructionBefore, instructionFields(in)); err != nil {
+			return err
+		}
+	}
+
+	before := c.auditFields()
+	deceased := *c
+	deceased.IsActive = false
+	deceased.DeceasedOn = diedOn
+	if err := cm.saveCustomer(&deceased); err != nil {
+		return err
+	}
+	c.IsActive, c.DeceasedOn = false, diedOn
+	return cm.recordAudit(p, audit.ActionCustomerDeceased, "customer", customerID, before, c.auditFields())
+}
+
+// SettleNomineeClaim closes an account whose only holder has died and pays
+// each of its nominees their share. payouts say how each nominee, named by
+// NomineeID, is paid; every nominee is paid exactly once. Rounding leaves
+// any odd minor unit with the first nominee. It needs PermCloseAccounts at
+// the account's bank.
+func (cm *CustomerManager) SettleNomineeClaim(p *auth.Principal, accountID int, payouts []closure.Payout) (closure.Closure, error) {
+	for _, po := range payouts {
+		if err := po.Validate(); err != nil {
+			return closure.Closure{}, err
+		}
+	}
+	cm.mu.RLock()
+	acc, deceasedID, err := cm.claimableAccount(p, accountID)
+	cm.mu.RUnlock()
+	if err != nil {
+		return closure.Closure{}, err
+	}
+
+	nominees := acc.GetNominees()
+	if len(nominees) == 0 {
+		return closure.Closure{}, apperror.NewAccountError("claim", fmt.Sprintf("account %d has no nominees; its balance passes to the holder's estate", accountID))
+	}
+	if len(payouts) != len(nominees) {
+		return closure.Closure{}, apperror.NewValidationError("payouts", fmt.Sprintf("account %d has %d nominees, each to be paid once", accountID, len(nominees)))
+	}
+	ordered := make([]closure.Payout, len(nominees))
+	shares := make([]int, len(nominees))
+	for _, po := range payouts {
+		i := po.NomineeID - 1
+		if i < 0 || i >= len(nominees) {
+			return closure.Closure{}, apperror.NewNotFoundError("nominee", po.NomineeID)
+		}
+		if shares[i] != 0 {
+			return closure.Closure{}, apperror.NewValidationError("payouts", fmt.Sprintf("nominee %d is paid twice", po.NomineeID))
+		}
+		po.NomineeName = nominees[i].Name
+		ordered[i] = po
+		shares[i] = nominees[i].SharePercent
+	}
+	return cm.settleAccount(p, acc, closure.ReasonNomineeClaim, deceasedID, ordered, shares)
+}
+
+// claimableAccount finds an open account held by a customer recorded as
+// deceased. It expects cm.mu to be held.
+func (cm *CustomerManager) claimableAccount(p *auth.Principal, accountID int) (*account.Account, int, error) {
+	acc, err := account.GetAccountById(accountID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := cm.authorize(p, auth.PermCloseAccounts, acc.BankID); err != nil {
+		return nil, 0, err
+	}
+	if !acc.IsOpen() {
+		return nil, 0, apperror.NewInactiveError("account", accountID)
+	}
+	holder := cm.customers[acc.Snapshot().OwnerID]
+	if holder == nil || holder.DeceasedOn.IsZero() {
+		return nil, 0, apperror.NewAccountError("claim", fmt.Sprintf("the holder of account %d is not recorded as deceased", accountID))
+	}
+	return acc, holder.CustomerID, nil
+}
+
+// settleAccount closes acc, paying what is payable out through payouts in
+// proportion to shares, which add up to 100. Standing instructions and
+// approvals are locked out until it is closed.
+func (cm *CustomerManager) settleAccount(p *auth.Principal, acc *account.Account, reason closure.Reason, deceasedID int, payouts []closure.Payout, shares []int) (closure.Closure, error) {
+	cm.standingMu.Lock()
+	defer cm.standingMu.Unlock()
+	cm.approvalMu.Lock()
+	defer cm.approvalMu.Unlock()
+
+	if err := cm.checkClosable(acc); err != nil {
+		return closure.Closure{}, err
+	}
+	targets, err := cm.payoutTargets(acc, payouts)
+	if err != nil {
+		return closure.Closure{}, err
+	}
+	if err := cm.accrueToDate(acc); err != nil {
+		return closure.Closure{}, err
+	}
+	statement, err := acc.ClosingStatement(cm.clockNow())
+	if err != nil {
+		return closure.Closure{}, err
+	}
+	amounts, err := splitShares(statement.Payable, shares)
+	if err != nil {
+		return closure.Closure{}, err
+	}
+
+	staged := make([]account.Payout, len(payouts))
+	for i := range payouts {
+		payouts[i].Amount = amounts[i]
+		staged[i] = account.Payout{To: targets[i], Amount: amounts[i]}
+		if targets[i] == nil {
+			continue
+		}
+		if staged[i].Quote, err = cm.quote(acc.Currency, targets[i].Currency); err != nil {
+			return closure.Closure{}, err
+		}
+		if payouts[i].Credited, err = staged[i].Quote.Convert(amounts[i]); err != nil {
+			return closure.Closure{}, err
+		}
+	}
+	uow := cm.newUnitOfWork()
+	referenceID, err := acc.StageClosure(uow, statement, staged)
+	if err != nil {
+		return closure.Closure{}, err
+	}
+	interbank := false
+	for i, to := range targets {
+		if to == nil || to.BankID == acc.BankID || !payouts[i].Credited.IsPositive() {
+			continue
+		}
+		if err := cm.ledger.StageTransfer(uow, acc.BankID, to.BankID, payouts[i].Credited); err != nil {
+			return closure.Closure{}, err
+		}
+		interbank = true
+	}
+	if err := uow.Commit(); err != nil {
+		return closure.Closure{}, err
+	}
+	saved := []*account.Account{acc}
+	for _, to := range targets {
+		if to != nil {
+			saved = append(saved, to)
+		}
+	}
+	// The money has moved and the account is closed, so the closure is
+	// recorded even if saving the accounts or dues fails.
+	saveErr := cm.saveAccounts(saved...)
+	if saveErr == nil && interbank {
+		saveErr = cm.saveDues()
+	}
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	c := closure.Closure{
+		ClosureID:   cm.closureCounter + 1,
+		AccountID:   acc.AccountID,
+		Reason:      reason,
+		ReferenceID: referenceID,
+		Interest:    statement.Interest,
+		Charges:     statement.Charges,
+		Payouts:     payouts,
+		DeceasedID:  deceasedID,
+		ClosedBy:    p.CustomerID(),
+		ClosedAt:    cm.now().UTC(),
+	}
+	cm.closureCounter = c.ClosureID
+	cm.closures[c.ClosureID] = &c
+	if err := cm.store.SaveClosure(c); err != nil {
+		return closure.Closure{}, afterMove(err)
+	}
+	if saveErr != nil {
+		return closure.Closure{}, afterMove(saveErr)
+	}
+	if err := cm.recordAudit(p, audit.ActionAccountClosed, "account", acc.AccountID, closureBefore(statement), closureFields(&c)); err != nil {
+		return closure.Closure{}, afterMove(err)
+	}
+	return c, nil
+}
+
+// checkClosable refuses to close an account that something is still
+// waiting to move money out of or into. It expects cm.standingMu and
+// cm.approvalMu to be held.
+func (cm *CustomerManager) checkClosable(acc *account.Account) error {
+	id := acc.AccountID
+	for _, in := range cm.instructions {
+		if in.Status != standing.StatusActive && in.Status != standing.StatusSuspended {
+			continue
+		}
+		if in.FromAccountID == id || (in.BeneficiaryID == 0 && in.ToAccountID == id) {
+			return apperror.NewAccountError("close", fmt.Sprintf("account %d has %s standing instruction %d; cancel it first", id, in.Status, in.InstructionID))
+		}
+	}
+	for _, a := range cm.approvals {
+		if a.Status == joint.StatusPending && (a.AccountID == id || a.ToAccountID == id) {
+			return apperror.NewAccountError("close", fmt.Sprintf("account %d has a debit waiting for its holders in approval %d", id, a.ApprovalID))
+		}
+	}
+	cm.reviewMu.Lock()
+	defer cm.reviewMu.Unlock()
+	for _, r := range cm.reviews {
+		if r.Status == risk.ReviewPending && (r.FromAccountID == id || r.ToAccountID == id) {
+			return apperror.NewAccountError("close", fmt.Sprintf("account %d has a transfer waiting in review %d", id, r.ReviewID))
+		}
+	}
+	return nil
+}
+
+// payoutTargets finds the open accounts transfer payouts are paid into and
+// fills in their IDs and numbers. Cash payouts have no target.
+func (cm *CustomerManager) payoutTargets(acc *account.Account, payouts []closure.Payout) ([]*account.Account, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	targets := make([]*account.Account, len(payouts))
+	for i, po := range payouts {
+		if po.Method != closure.MethodTransfer {
+			continue
+		}
+		to, err := cm.accountByNumber(po.ToAccountNumber)
+		if err != nil {
+			return nil, err
+		}
+		if to, err = cm.findOpenAccount(to.AccountID); err != nil {
+			return nil, err
+		}
+		if to == acc {
+			return nil, apperror.NewValidationError("toAccountNumber", "a closing account cannot be paid out into itself")
+		}
+		payouts[i].ToAccountNumber, payouts[i].ToAccountID = to.Number, to.AccountID
+		targets[i] = to
+	}
+	return targets, nil
+}
+
+// accrueToDate accrues a closing account's interest for the business days
+// up to yesterday that end of day has not reached yet.
+func (cm *CustomerManager) accrueToDate(acc *account.Account) error {
+	cm.mu.RLock()
+	rate := cm.interestRate(acc)
+	yesterday := eod.BusinessDate(cm.now()).AddDate(0, 0, -1)
+	cm.mu.RUnlock()
+
+	s := acc.Snapshot()
+	day := s.LastAccrual.AddDate(0, 0, 1)
+	if s.LastAccrual.IsZero() {
+		day = eod.BusinessDate(s.OpenedAt)
+	}
+	accrued := false
+	for ; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+		daily, err := acc.AccrueInterest(day, rate)
+		if err != nil {
+			return err
+		}
+		accrued = accrued || daily.IsPositive()
+	}
+	if !accrued {
+		return nil
+	}
+	return cm.saveAccounts(acc)
+}
+
+// splitShares divides total by percentages, rounding each share down and
+// leaving what rounding leaves over with the first.
+func splitShares(total money.Money, shares []int) ([]money.Money, error) {
+	amounts := make([]money.Money, len(shares))
+	first := total
+	for i := 1; i < len(shares); i++ {
+		share, err := total.MulRat(int64(shares[i]), 100, money.RoundDown)
+		if err != nil {
+			return nil, err
+		}
+		if first, err = first.Sub(share); err != nil {
+			return nil, err
+		}
+		amounts[i] = share
+	}
+	amounts[0] = first
+	return amounts, nil
+}
+
+func closureBefore(s account.Statement) map[string]string {
+	return map[string]string{
+		"balance":   s.Balance.String(),
+		"is_active": "true",
+	}
+}
+
+func closureFields(c *closure.Closure) map[string]string {
+	fields := map[string]string{
+		"reason":       string(c.Reason),
+		"reference_id": c.ReferenceID,
+		"interest":     c.Interest.String(),
+		"charges":      c.Charges.String(),
+		"is_active":    "false",
+	}
+	for i, po := range c.Payouts {
+		key := "payout_" + strconv.Itoa(i+1)
+		fields[key] = string(po.Method) + " " + po.Amount.String()
+		if po.ToAccountNumber != "" {
+			fields[key] += " to " + po.ToAccountNumber
+		}
+		if po.NomineeID != 0 {
+			fields[key] += " for nominee " + strconv.Itoa(po.NomineeID)
+		}
+	}
+	if c.DeceasedID != 0 {
+		fields["deceased_id"] = strconv.Itoa(c.DeceasedID)
+	}
+	return fields
+}
+
+func nomineeFields(nominees []account.Nominee) map[string]string {
+	fields := map[string]string{"nominees": strconv.Itoa(len(nominees))}
+	for _, n := range nominees {
+		fields["nominee_"+strconv.Itoa(n.NomineeID)] = fmt.Sprintf("%s (%s) %d%%", n.Name, n.Relationship, n.SharePercent)
+	}
+	return fields
+}
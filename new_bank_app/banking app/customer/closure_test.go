@@ -0,0 +1,219 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/closure"
+	"banking-app/money"
+	"banking-app/repository"
+	"banking-app/standing"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecordDeathCanBeMadeAgainAfterAFailedSave(t *testing.T) {
+	store := &flakyStore{MemoryStore: repository.NewMemoryStore()}
+	cm, admin := newTestManagerOn(t, store)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, _ := newTestCustomer(t, cm, admin, "Riya")
+	shruti, p := newTestCustomer(t, cm, admin, "Shruti")
+	shared := newTestAccount(t, cm, admin, shruti, sbi, account.ProductSavings, 10000)
+	current := newTestAccount(t, cm, admin, shruti, sbi, account.ProductCurrent, 0)
+	if err := cm.AddAccountHolder(admin, shared.AccountID, riya.CustomerID); err != nil {
+		t.Fatal(err)
+	}
+	in, err := cm.CreateStandingInstruction(p, standing.Spec{
+		FromAccountID: shared.AccountID,
+		ToAccountID:   current.AccountID,
+		Amount:        rupees(1000),
+		Schedule:      standing.Schedule{Frequency: standing.Weekly, StartDate: time.Now().AddDate(0, 0, 7)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diedOn := time.Now().AddDate(0, 0, -1)
+	for _, tc := range []struct {
+		name string
+		fail *atomic.Bool
+	}{
+		{"accounts down", &store.failAccounts},
+		{"instructions down", &store.failStanding},
+		{"customers down", &store.failCustomers},
+	} {
+		tc.fail.Store(true)
+		err := cm.RecordDeath(admin, shruti.CustomerID, diedOn)
+		tc.fail.Store(false)
+		if err == nil {
+			t.Fatalf("%s: recorded a death the store could not save", tc.name)
+		}
+		if !shruti.IsActive {
+			t.Fatalf("%s: customer marked deceased before their accounts and instructions were dealt with", tc.name)
+		}
+	}
+	if err := cm.RecordDeath(admin, shruti.CustomerID, diedOn); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := shared.Holders(), []int{riya.CustomerID}; !slices.Equal(got, want) {
+		t.Errorf("holders = %v, want %v", got, want)
+	}
+	saved, err := store.LoadAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range saved {
+		if s.AccountID == shared.AccountID && (s.OwnerID != riya.CustomerID || len(s.JointHolders) != 0) {
+			t.Errorf("saved holders = %d and %v, want %d alone", s.OwnerID, s.JointHolders, riya.CustomerID)
+		}
+	}
+	instructions, err := store.LoadInstructions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range instructions {
+		if s.InstructionID == in.InstructionID && s.Status != standing.StatusCancelled {
+			t.Errorf("saved instruction is %s, want cancelled", s.Status)
+		}
+	}
+	customers, err := store.LoadCustomers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range customers {
+		if c.CustomerID == shruti.CustomerID && (c.IsActive || c.DeceasedOn.IsZero()) {
+			t.Errorf("saved customer is active = %t, deceased on %s", c.IsActive, c.DeceasedOn)
+		}
+	}
+}
+
+func TestLiensAndStandingInstructionsBlockClosure(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	other := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 1000)
+	cash := closure.Payout{Method: closure.MethodCash}
+
+	lien, err := cm.PlaceLien(admin, acc.AccountID, rupees(500), "court order", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.CloseAccount(p, acc.AccountID, cash); err == nil {
+		t.Fatal("closed an account holding money under a lien")
+	}
+	if _, err := cm.ReleaseLien(admin, acc.AccountID, lien.LienID); err != nil {
+		t.Fatal(err)
+	}
+
+	in, err := cm.CreateStandingInstruction(p, standing.Spec{
+		FromAccountID: acc.AccountID,
+		ToAccountID:   other.AccountID,
+		Amount:        rupees(1000),
+		Schedule:      standing.Schedule{Frequency: standing.Weekly, StartDate: time.Now().AddDate(0, 0, 7)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.CloseAccount(p, other.AccountID, cash); err == nil {
+		t.Fatal("closed an account a standing instruction pays into")
+	}
+	if _, err := cm.CloseAccount(p, acc.AccountID, cash); err == nil {
+		t.Fatal("closed an account with a standing instruction of its own")
+	}
+	if !acc.IsOpen() {
+		t.Fatal("account closed by a refused closure")
+	}
+	wantBalance(t, acc, rupees(10000))
+
+	if _, err := cm.CancelStandingInstruction(p, in.InstructionID); err != nil {
+		t.Fatal(err)
+	}
+	c, err := cm.CloseAccount(p, acc.AccountID, cash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Payouts[0].Amount; got != rupees(10000) {
+		t.Errorf("paid out %s, want %s", got, rupees(10000))
+	}
+}
+
+func TestNomineeSharesLeaveTheOddPaisaWithTheFirst(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 1000)
+	if err := cm.DepositMoney(p, money.New(1, money.INR), acc.AccountID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SetNominees(p, acc.AccountID, []account.Nominee{
+		{Name: "Shruti", Relationship: "sister", SharePercent: 34},
+		{Name: "Meera", Relationship: "mother", SharePercent: 33},
+		{Name: "Arjun", Relationship: "brother", SharePercent: 33},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.RecordDeath(admin, riya.CustomerID, time.Now().AddDate(0, 0, -1)); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := cm.SettleNomineeClaim(admin, acc.AccountID, []closure.Payout{
+		{Method: closure.MethodCash, NomineeID: 3},
+		{Method: closure.MethodCash, NomineeID: 1},
+		{Method: closure.MethodCash, NomineeID: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []money.Money{money.New(34001, money.INR), money.New(33000, money.INR), money.New(33000, money.INR)}
+	if len(c.Payouts) != len(want) {
+		t.Fatalf("%d payouts, want %d", len(c.Payouts), len(want))
+	}
+	for i, po := range c.Payouts {
+		if po.NomineeID != i+1 || po.Amount != want[i] {
+			t.Errorf("payout %d = %s to nominee %d, want %s to nominee %d", i, po.Amount, po.NomineeID, want[i], i+1)
+		}
+	}
+	if acc.IsOpen() {
+		t.Error("claimed account is still open")
+	}
+}
+
+func TestClosureIsRecordedWhenSavingAfterTheMoveFails(t *testing.T) {
+	store := &flakyStore{MemoryStore: repository.NewMemoryStore()}
+	cm, admin := newTestManagerOn(t, store)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	theirs := newTestAccount(t, cm, admin, riya, bob, account.ProductSavings, 1000)
+
+	store.failAccounts.Store(true)
+	_, err := cm.CloseAccount(p, acc.AccountID, closure.Payout{Method: closure.MethodTransfer, ToAccountNumber: theirs.Number})
+	store.failAccounts.Store(false)
+	var moved movedError
+	if !errors.As(err, &moved) {
+		t.Fatalf("err = %v, want it marked as coming after the move", err)
+	}
+	if acc.IsOpen() {
+		t.Fatal("account still open after its money moved")
+	}
+	wantBalance(t, theirs, rupees(11000))
+
+	c, err := cm.AccountClosure(p, acc.AccountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Payouts) != 1 || c.Payouts[0].Amount != rupees(10000) {
+		t.Errorf("closure pays out %+v, want INR 10000.00", c.Payouts)
+	}
+	saved, err := store.LoadClosures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].AccountID != acc.AccountID {
+		t.Errorf("stored closures = %+v, want the closure of account %d", saved, acc.AccountID)
+	}
+}
@@ -0,0 +1,82 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/auth"
+	"banking-app/bank"
+	"banking-app/money"
+	"banking-app/repository"
+	"sync/atomic"
+	"testing"
+)
+
+const testPassword = "Test@1234"
+
+// Accounts share one registry across managers, so each test manager numbers
+// its customers, banks and accounts from a block of IDs of its own.
+var idBlocks atomic.Int64
+
+func newTestManager(t *testing.T) (*CustomerManager, *auth.Principal) {
+	t.Helper()
+	return newTestManagerOn(t, repository.NewMemoryStore())
+}
+
+func newTestManagerOn(t *testing.T, store repository.Store) (*CustomerManager, *auth.Principal) {
+	t.Helper()
+	cm, err := NewCustomerManager(store, "Test", "Admin", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm.idCounter = int(idBlocks.Add(1)) * 100000
+	_, admin, err := cm.Login(cm.SuperAdminID(), testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cm, admin
+}
+
+func newTestBank(t *testing.T, cm *CustomerManager, admin *auth.Principal, name, code string) *bank.Bank {
+	t.Helper()
+	b, err := cm.CreateNewBank(admin, name, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// newTestCustomer onboards a customer with a password and signs them in.
+func newTestCustomer(t *testing.T, cm *CustomerManager, admin *auth.Principal, firstName string) (*Customer, *auth.Principal) {
+	t.Helper()
+	c, err := cm.CreateNewCustomer(admin, firstName, "Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.SetPassword(admin, c.CustomerID, testPassword); err != nil {
+		t.Fatal(err)
+	}
+	_, p, err := cm.Login(c.CustomerID, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, p
+}
+
+func newTestAccount(t *testing.T, cm *CustomerManager, admin *auth.Principal, c *Customer, b *bank.Bank, product account.Product, rupees int64) *account.Account {
+	t.Helper()
+	acc, err := cm.CreateAccountForCustomer(admin, c.CustomerID, b.BankID, 0, money.INR, product, money.MustFromMajor(rupees, money.INR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acc
+}
+
+func rupees(major int64) money.Money {
+	return money.MustFromMajor(major, money.INR)
+}
+
+func wantBalance(t *testing.T, acc *account.Account, want money.Money) {
+	t.Helper()
+	if got := acc.GetBalance(); got != want {
+		t.Errorf("account %d balance = %s, want %s", acc.AccountID, got, want)
+	}
+}
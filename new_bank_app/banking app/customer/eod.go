@@ -0,0 +1,266 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/eod"
+	"banking-app/money"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SetClock makes the manager, its audit log, its idempotency keys and every
+// account tell time by now, for example a clock.Simulated.
+func (cm *CustomerManager) SetClock(now func() time.Time) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.now = now
+	cm.audit.SetClock(now)
+	cm.idempotency.SetClock(now)
+	cm.books.SetClock(now)
+}
+
+func (cm *CustomerManager) clockNow() time.Time {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.now()
+}
+
+func (cm *CustomerManager) SetInterestRate(p *auth.Principal, bankID int, product account.Product, rateBPS int64) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.authorize(p, auth.PermSetInterestRates, bankID); err != nil {
+		return err
+	}
+	b, err := cm.lookupBank(bankID)
+	if err != nil {
+		return err
+	}
+	before, _ := b.InterestRate(product)
+	if err := b.SetInterestRate(product, rateBPS); err != nil {
+		return err
+	}
+	if err := cm.store.SaveBank(*b); err != nil {
+		return err
+	}
+	return cm.recordAudit(p, audit.ActionInterestRateSet, "bank", bankID,
+		map[string]string{"product": string(product), "rate_bps": strconv.FormatInt(before, 10)},
+		map[string]string{"product": string(product), "rate_bps": strconv.FormatInt(rateBPS, 10)})
+}
+
+// RunEndOfDay closes businessDate, after any earlier days still open. Every
+// open savings account accrues a day of interest at its bank's current
+// rate, and on the last day of a month the interest accrued so far is
+// credited to the balance. Running a date that is already closed returns its
+// original run and posts nothing.
+func (cm *CustomerManager) RunEndOfDay(p *auth.Principal, businessDate time.Time) (eod.Run, error) {
+	if err := cm.authorizeEndOfDay(p); err != nil {
+		return eod.Run{}, err
+	}
+	date := eod.BusinessDate(businessDate)
+	if date.After(eod.BusinessDate(cm.clockNow())) {
+		return eod.Run{}, apperror.NewValidationError("businessDate", fmt.Sprintf("%s has not started yet", date.Format(time.DateOnly)))
+	}
+
+	cm.eodMu.Lock()
+	defer cm.eodMu.Unlock()
+	if run, ok := cm.eodRuns[date.Format(time.DateOnly)]; ok {
+		return run, nil
+	}
+	runs, err := cm.closeThrough(p, date)
+	if err != nil {
+		return eod.Run{}, err
+	}
+	if len(runs) == 0 {
+		return eod.Run{}, apperror.NewValidationError("businessDate", fmt.Sprintf("%s is before the first business day", date.Format(time.DateOnly)))
+	}
+	return runs[len(runs)-1], nil
+}
+
+// CloseDueDays closes, in order, every business day that has ended by the
+// manager's clock and is not closed yet.
+func (cm *CustomerManager) CloseDueDays(p *auth.Principal) ([]eod.Run, error) {
+	if err := cm.authorizeEndOfDay(p); err != nil {
+		return nil, err
+	}
+	return cm.closeDueDays(p)
+}
+
+// StartEndOfDayScheduler closes due days as the system every interval until
+// the returned scheduler is stopped. Failures go to onError and are retried
+// on the next tick.
+func (cm *CustomerManager) StartEndOfDayScheduler(interval time.Duration, onError func(error)) *eod.Scheduler {
+	return eod.Start(interval, func() {
+		if _, err := cm.closeDueDays(nil); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
+
+// EndOfDayRuns lists the closed business days, oldest first.
+func (cm *CustomerManager) EndOfDayRuns(p *auth.Principal) ([]eod.Run, error) {
+	cm.mu.RLock()
+	_, err := cm.authorize(p, auth.PermViewLedger)
+	cm.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	cm.eodMu.Lock()
+	defer cm.eodMu.Unlock()
+	runs := make([]eod.Run, 0, len(cm.eodRuns))
+	for _, run := range cm.eodRuns {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].BusinessDate.Before(runs[j].BusinessDate) })
+	return runs, nil
+}
+
+func (cm *CustomerManager) authorizeEndOfDay(p *auth.Principal) error {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	_, err := cm.authorize(p, auth.PermRunEndOfDay)
+	return err
+}
+
+func (cm *CustomerManager) closeDueDays(p *auth.Principal) ([]eod.Run, error) {
+	yesterday := eod.BusinessDate(cm.clockNow()).AddDate(0, 0, -1)
+
+	cm.eodMu.Lock()
+	defer cm.eodMu.Unlock()
+	return cm.closeThrough(p, yesterday)
+}
+
+// closeThrough closes every open day up to and including last, starting the
+// day after the last closed one or, before the first close, on the day the
+// earliest account was opened. It expects cm.eodMu to be held.
+func (cm *CustomerManager) closeThrough(p *auth.Principal, last time.Time) ([]eod.Run, error) {
+	next := cm.lastClosed.AddDate(0, 0, 1)
+	if cm.lastClosed.IsZero() {
+		var ok bool
+		if next, ok = cm.firstBusinessDay(); !ok {
+			return nil, nil
+		}
+	}
+	var runs []eod.Run
+	for day := next; !day.After(last); day = day.AddDate(0, 0, 1) {
+		run, err := cm.closeDay(p, day)
+		if err != nil {
+			return runs, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func (cm *CustomerManager) firstBusinessDay() (time.Time, bool) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	var first time.Time
+	for _, c := range cm.customers {
+		for _, acc := range c.Accounts {
+			if first.IsZero() || acc.OpenedAt.Before(first) {
+				first = acc.OpenedAt
+			}
+		}
+	}
+	return eod.BusinessDate(first), !first.IsZero()
+}
+
+// closeDay expects cm.eodMu to be held. Accounts that already accrued for
+// date are skipped, so a day that failed half way can simply be run again.
+func (cm *CustomerManager) closeDay(p *auth.Principal, date time.Time) (eod.Run, error) {
+	key := date.Format(time.DateOnly)
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	run := eod.Run{
+		BusinessDate:     date,
+		InterestAccrued:  money.Zero(BaseCurrency),
+		InterestCredited: money.Zero(BaseCurrency),
+	}
+	for _, acc := range cm.eodAccounts() {
+		accrued, err := acc.AccrueInterest(date, cm.interestRate(acc))
+		if err != nil {
+			return eod.Run{}, err
+		}
+		var credited money.Money
+		if eod.IsMonthEnd(date) {
+			if credited, err = acc.CreditAccruedInterest(); err != nil {
+				return eod.Run{}, err
+			}
+		}
+		if accrued.IsPositive() {
+			run.AccountsAccrued++
+			if run.InterestAccrued, err = cm.addAtMid(run.InterestAccrued, accrued); err != nil {
+				return eod.Run{}, err
+			}
+		}
+		if credited.IsPositive() {
+			run.AccountsCredited++
+			if run.InterestCredited, err = cm.addAtMid(run.InterestCredited, credited); err != nil {
+				return eod.Run{}, err
+			}
+		}
+		if accrued.IsPositive() || credited.IsPositive() {
+			if err := cm.saveAccounts(acc); err != nil {
+				return eod.Run{}, err
+			}
+		}
+	}
+
+	run.CompletedAt = cm.now()
+	if err := cm.store.SaveEODRun(run); err != nil {
+		return eod.Run{}, err
+	}
+	cm.eodRuns[key] = run
+	cm.lastClosed = date
+	return run, cm.recordAudit(p, audit.ActionEndOfDay, "eod", dateID(date), nil, map[string]string{
+		"business_date":     key,
+		"accounts_accrued":  strconv.Itoa(run.AccountsAccrued),
+		"interest_accrued":  run.InterestAccrued.String(),
+		"accounts_credited": strconv.Itoa(run.AccountsCredited),
+		"interest_credited": run.InterestCredited.String(),
+	})
+}
+
+// eodAccounts lists the open accounts of active customers at existing
+// banks in AccountID order. It expects cm.mu to be held.
+func (cm *CustomerManager) eodAccounts() []*account.Account {
+	var accs []*account.Account
+	for _, c := range cm.customers {
+		if !c.IsActive {
+			continue
+		}
+		for _, acc := range c.Accounts {
+			if cm.banks[acc.BankID] != nil && acc.IsOpen() {
+				accs = append(accs, acc)
+			}
+		}
+	}
+	sort.Slice(accs, func(i, j int) bool { return accs[i].AccountID < accs[j].AccountID })
+	return accs
+}
+
+// interestRate is the bank's current rate for the account's product, or
+// the rate in its terms if the bank sets none. It expects cm.mu to be held.
+func (cm *CustomerManager) interestRate(acc *account.Account) int64 {
+	if b := cm.banks[acc.BankID]; b != nil {
+		if rate, ok := b.InterestRate(acc.Terms.Product); ok {
+			return rate
+		}
+	}
+	return acc.Terms.InterestRateBPS
+}
+
+// dateID turns a business date into the audit resource ID 20261016.
+func dateID(date time.Time) int {
+	y, m, d := date.Date()
+	return y*10000 + int(m)*100 + d
+}
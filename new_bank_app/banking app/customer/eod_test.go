@@ -0,0 +1,120 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/clock"
+	"banking-app/money"
+	"testing"
+	"time"
+)
+
+// halfEven divides n by d, rounding halves to the even neighbour.
+func halfEven(n, d int64) int64 {
+	q, r := n/d, n%d
+	if 2*r > d || (2*r == d && q%2 == 1) {
+		q++
+	}
+	return q
+}
+
+func TestYearOfDailyAccrualsCreditsMonthlyInterest(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sim := clock.NewSimulated(time.Date(2025, time.January, 1, 9, 0, 0, 0, time.UTC))
+	cm.SetClock(sim.Now)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, _ := newTestCustomer(t, cm, admin, "Riya")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 100000)
+	rateBPS := acc.Terms.InterestRateBPS
+
+	// The same year worked out by hand in paise: a day's interest on the
+	// balance, added to the balance on each month's last day.
+	balance, accrued := int64(10000000), int64(0)
+	var credited money.Money
+	for day := 0; day < 365; day++ {
+		date := sim.Now()
+		sim.AdvanceDays(1)
+		run, err := cm.RunEndOfDay(admin, date)
+		if err != nil {
+			t.Fatalf("%s: %v", date.Format(time.DateOnly), err)
+		}
+		if credited, err = credited.Add(run.InterestCredited); err != nil {
+			t.Fatal(err)
+		}
+
+		accrued += halfEven(balance*rateBPS, 10000*account.DaysPerYear)
+		if date.AddDate(0, 0, 1).Day() == 1 {
+			balance, accrued = balance+accrued, 0
+		}
+	}
+
+	want := money.New(balance, money.INR)
+	wantBalance(t, acc, want)
+	if interest, _ := want.Sub(rupees(100000)); credited != interest {
+		t.Errorf("interest credited over the year = %s, want %s", credited, interest)
+	}
+	counts := make(map[account.TransactionType]int)
+	for _, txn := range acc.GetPassbook() {
+		counts[txn.Type]++
+	}
+	if counts[account.TxnInterestAccrual] != 365 || counts[account.TxnInterestCredit] != 12 {
+		t.Errorf("passbook holds %d accruals and %d credits, want 365 and 12", counts[account.TxnInterestAccrual], counts[account.TxnInterestCredit])
+	}
+}
+
+func TestRunningADayAgainAccruesOnce(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sim := clock.NewSimulated(time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC))
+	cm.SetClock(sim.Now)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, _ := newTestCustomer(t, cm, admin, "Riya")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 100000)
+	date := sim.Now()
+	sim.AdvanceDays(1)
+
+	first, err := cm.RunEndOfDay(admin, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.AccountsAccrued != 1 || !first.InterestAccrued.IsPositive() {
+		t.Fatalf("first run = %+v, want one account accrued", first)
+	}
+	entries := len(acc.GetPassbook())
+	accrued := acc.Snapshot().AccruedInterest
+
+	again, err := cm.RunEndOfDay(admin, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != first {
+		t.Errorf("second run = %+v, want the first run %+v", again, first)
+	}
+	// Even asked directly, the account refuses a date it already accrued.
+	if daily, err := acc.AccrueInterest(date, acc.Terms.InterestRateBPS); err != nil || daily.IsPositive() {
+		t.Errorf("accruing %s again = %s, %v, want nothing", date.Format(time.DateOnly), daily, err)
+	}
+	if got := acc.Snapshot().AccruedInterest; got != accrued {
+		t.Errorf("accrued interest = %s after rerunning the day, want %s", got, accrued)
+	}
+	if got := len(acc.GetPassbook()); got != entries {
+		t.Errorf("passbook grew from %d to %d entries on the rerun", entries, got)
+	}
+	wantBalance(t, acc, rupees(100000))
+}
+
+func TestManagersKeepTheirOwnClocks(t *testing.T) {
+	simulated, simAdmin := newTestManager(t)
+	start := time.Date(2025, time.January, 1, 9, 0, 0, 0, time.UTC)
+	simulated.SetClock(clock.NewSimulated(start).Now)
+	live, liveAdmin := newTestManager(t)
+
+	riya, _ := newTestCustomer(t, simulated, simAdmin, "Riya")
+	past := newTestAccount(t, simulated, simAdmin, riya, newTestBank(t, simulated, simAdmin, "State Bank of India", "SBIN"), account.ProductSavings, 1000)
+	shruti, _ := newTestCustomer(t, live, liveAdmin, "Shruti")
+	present := newTestAccount(t, live, liveAdmin, shruti, newTestBank(t, live, liveAdmin, "State Bank of India", "SBIN"), account.ProductSavings, 1000)
+	if !past.OpenedAt.Equal(start) {
+		t.Errorf("account opened under the simulated clock at %s, want %s", past.OpenedAt, start)
+	}
+	if present.OpenedAt.Year() == start.Year() {
+		t.Errorf("account opened under the system clock at %s, the simulated clock's time", present.OpenedAt)
+	}
+}
@@ -0,0 +1,184 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/beneficiary"
+	"banking-app/closure"
+	"banking-app/money"
+	"banking-app/repository"
+	"banking-app/unitofwork"
+	"errors"
+	"slices"
+	"testing"
+)
+
+var errInjected = errors.New("injected failure")
+
+// books is everything a transfer that fails part way must leave as it was.
+type books struct {
+	balances  []money.Money
+	passbooks []int
+	owed      []money.Money
+	open      []bool
+}
+
+func readBooks(cm *CustomerManager, accs ...*account.Account) books {
+	var b books
+	for _, acc := range accs {
+		b.balances = append(b.balances, acc.GetBalance())
+		b.passbooks = append(b.passbooks, len(acc.GetPassbook()))
+		b.open = append(b.open, acc.IsOpen())
+		for _, other := range accs {
+			if other.BankID != acc.BankID {
+				b.owed = append(b.owed, cm.GetLedger().OwedAmount(acc.BankID, other.BankID, money.INR))
+			}
+		}
+	}
+	return b
+}
+
+func (b books) equal(other books) bool {
+	return slices.Equal(b.balances, other.balances) && slices.Equal(b.passbooks, other.passbooks) &&
+		slices.Equal(b.owed, other.owed) && slices.Equal(b.open, other.open)
+}
+
+func TestFailureAtAnyStepLeavesTheBooksUnchanged(t *testing.T) {
+	cm, admin := newTestManager(t)
+	cm.SetRiskEngine(nil)
+	if err := cm.SetBeneficiaryPolicy(beneficiary.Policy{}); err != nil {
+		t.Fatal(err)
+	}
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	shruti, _ := newTestCustomer(t, cm, admin, "Shruti")
+	savings := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	current := newTestAccount(t, cm, admin, riya, sbi, account.ProductCurrent, 1000)
+	closing := newTestAccount(t, cm, admin, riya, sbi, account.ProductCurrent, 3000)
+	theirs := newTestAccount(t, cm, admin, shruti, bob, account.ProductSavings, 1000)
+	payee, err := cm.AddBeneficiary(p, theirs.Number, "Shruti")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		steps int
+		accs  []*account.Account
+		run   func() error
+	}{
+		{
+			name:  "internal transfer",
+			steps: 1,
+			accs:  []*account.Account{savings, current},
+			run: func() error {
+				return cm.TransferMoneyInternally(p, savings.AccountID, current.Number, rupees(500))
+			},
+		},
+		{
+			name:  "transfer to another bank",
+			steps: 2,
+			accs:  []*account.Account{savings, theirs},
+			run: func() error {
+				return cm.TransferToBeneficiary(p, savings.AccountID, payee.BeneficiaryID, rupees(700))
+			},
+		},
+		{
+			name:  "closure paid out to another bank",
+			steps: 2,
+			accs:  []*account.Account{closing, theirs},
+			run: func() error {
+				_, err := cm.CloseAccount(p, closing.AccountID, closure.Payout{Method: closure.MethodTransfer, ToAccountNumber: theirs.Number})
+				return err
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for step := 0; step < tc.steps; step++ {
+				before := readBooks(cm, tc.accs...)
+				cm.SetFailureInjector(func(phase unitofwork.Phase, i int) error {
+					if phase == unitofwork.PhasePrepare && i == step {
+						return errInjected
+					}
+					return nil
+				})
+				if err := tc.run(); !errors.Is(err, errInjected) {
+					t.Fatalf("failure after step %d: err = %v, want the injected failure", step, err)
+				}
+				if after := readBooks(cm, tc.accs...); !after.equal(before) {
+					t.Errorf("failure after step %d changed the books: %+v, want %+v", step, after, before)
+				}
+			}
+
+			// Every step aborted cleanly, so nothing is left locked and the
+			// same work goes through once the failures stop.
+			steps := 0
+			cm.SetFailureInjector(func(unitofwork.Phase, int) error {
+				steps++
+				return nil
+			})
+			before := readBooks(cm, tc.accs...)
+			if err := tc.run(); err != nil {
+				t.Fatal(err)
+			}
+			if steps != tc.steps {
+				t.Errorf("unit of work prepared %d steps, want %d", steps, tc.steps)
+			}
+			if readBooks(cm, tc.accs...).equal(before) {
+				t.Error("books unchanged after the work went through")
+			}
+		})
+	}
+}
+
+func TestFailedSettlementSaveLeavesTheWindowOpen(t *testing.T) {
+	store := &flakyStore{MemoryStore: repository.NewMemoryStore()}
+	cm, admin := newTestManagerOn(t, store)
+	cm.SetRiskEngine(nil)
+	if err := cm.SetBeneficiaryPolicy(beneficiary.Policy{}); err != nil {
+		t.Fatal(err)
+	}
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	shruti, _ := newTestCustomer(t, cm, admin, "Shruti")
+	from := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	to := newTestAccount(t, cm, admin, shruti, bob, account.ProductSavings, 1000)
+	payee, err := cm.AddBeneficiary(p, to.Number, "Shruti")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.TransferToBeneficiary(p, from.AccountID, payee.BeneficiaryID, rupees(700)); err != nil {
+		t.Fatal(err)
+	}
+
+	store.failSettlements.Store(true)
+	if _, err := cm.SettleInterbank(admin); err == nil {
+		t.Fatal("settlement reported success with the store down")
+	}
+	store.failSettlements.Store(false)
+	if owed := cm.GetLedger().OwedAmount(sbi.BankID, bob.BankID, money.INR); owed != rupees(700) {
+		t.Errorf("owed after the failed settlement = %s, want INR 700.00", owed)
+	}
+	if batches := cm.GetLedger().Batches(); len(batches) != 0 {
+		t.Errorf("failed settlement archived %d batches", len(batches))
+	}
+
+	batch, err := cm.SettleInterbank(admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.BatchID != 1 || len(batch.Payments) != 1 || batch.Payments[0].Amount != rupees(700) {
+		t.Errorf("batch = %+v, want batch 1 paying INR 700.00", batch)
+	}
+	if owed := cm.GetLedger().OwedAmount(sbi.BankID, bob.BankID, money.INR); !owed.IsZero() {
+		t.Errorf("owed after settling = %s, want nothing", owed)
+	}
+	saved, err := store.LoadSettlements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].BatchID != 1 {
+		t.Errorf("stored batches = %+v, want batch 1 alone", saved)
+	}
+}
@@ -0,0 +1,103 @@
+package customer
+
+import (
+	"banking-app/fx"
+	"banking-app/money"
+	"sort"
+)
+
+// BaseCurrency is the currency the bank's default terms, limits and
+// cooling-off policy are set in, and the one balances in several currencies
+// are totalled in.
+// Accounts in other currencies get them converted at the mid-market rate.
+const BaseCurrency = money.INR
+
+// SetRateProvider sets where exchange rates for transfers between
+// currencies, and for converting the bank's defaults, come from.
+func (cm *CustomerManager) SetRateProvider(rates fx.Provider) {
+	cm.fxMu.Lock()
+	defer cm.fxMu.Unlock()
+	cm.rates = rates
+}
+
+// Quote is what converting from into to would cost now.
+func (cm *CustomerManager) Quote(from, to string) (fx.Quote, error) {
+	from, err := money.ParseCurrency(from)
+	if err != nil {
+		return fx.Quote{}, err
+	}
+	if to, err = money.ParseCurrency(to); err != nil {
+		return fx.Quote{}, err
+	}
+	return cm.quote(from, to)
+}
+
+func (cm *CustomerManager) quote(from, to string) (fx.Quote, error) {
+	if from == to {
+		return fx.Identity(from), nil
+	}
+	cm.fxMu.RLock()
+	rates := cm.rates
+	cm.fxMu.RUnlock()
+	return rates.Quote(from, to)
+}
+
+// atMid converts m into currency at the mid-market rate, without the
+// spread a customer's conversion pays. It is for comparing amounts with
+// thresholds and for reporting.
+func (cm *CustomerManager) atMid(m money.Money, currency string) (money.Money, error) {
+	if m.Currency == currency || m.Currency == "" {
+		return money.Money{Amount: m.Amount, Currency: currency}, nil
+	}
+	q, err := cm.quote(m.Currency, currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return m.Convert(currency, int64(q.Mid), fx.RateScale, money.RoundHalfUp)
+}
+
+// addAtMid adds m to total in total's currency.
+func (cm *CustomerManager) addAtMid(total, m money.Money) (money.Money, error) {
+	m, err := cm.atMid(m, total.Currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return total.Add(m)
+}
+
+// totalBalance adds balances up exactly, currency by currency. Balances all
+// in one currency total in it without needing a rate; a mix is converted to
+// BaseCurrency at the mid-market rate one currency total at a time. No
+// balances total zero in BaseCurrency.
+func (cm *CustomerManager) totalBalance(balances []money.Money) (money.Money, error) {
+	byCurrency := make(map[string]money.Money)
+	var currencies []string
+	for _, m := range balances {
+		sum, ok := byCurrency[m.Currency]
+		if !ok {
+			currencies = append(currencies, m.Currency)
+		}
+		var err error
+		if byCurrency[m.Currency], err = sum.Add(m); err != nil {
+			return money.Money{}, err
+		}
+	}
+	if len(currencies) == 1 {
+		return byCurrency[currencies[0]], nil
+	}
+	sort.Strings(currencies)
+	total := money.Zero(BaseCurrency)
+	for _, currency := range currencies {
+		var err error
+		if total, err = cm.addAtMid(total, byCurrency[currency]); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// inCurrency returns a converter into currency for account.Terms.Convert
+// and account.Limits.Convert.
+func (cm *CustomerManager) inCurrency(currency string) func(money.Money) (money.Money, error) {
+	return func(m money.Money) (money.Money, error) { return cm.atMid(m, currency) }
+}
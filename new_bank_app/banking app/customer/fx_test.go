@@ -0,0 +1,58 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/fx"
+	"banking-app/money"
+	"testing"
+)
+
+func TestTotalBalanceNeedsNoRateForOneCurrency(t *testing.T) {
+	cm, admin := newTestManager(t)
+	rates, err := fx.NewStatic(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rates.Set(money.USD, money.INR, 83*fx.RateScale); err != nil {
+		t.Fatal(err)
+	}
+	cm.SetRateProvider(rates)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, _ := newTestCustomer(t, cm, admin, "Riya")
+	shruti, _ := newTestCustomer(t, cm, admin, "Shruti")
+	for _, cents := range []int64{1001, 1002} {
+		if _, err := cm.CreateAccountForCustomer(admin, shruti.CustomerID, sbi.BankID, 0, money.USD, account.ProductSavings, money.New(cents, money.USD)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 1000)
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductCurrent, 0)
+	if err := acc.SetBalance(money.New(33, money.INR)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a rate to convert at, single-currency totals still add up.
+	none, err := fx.NewStatic(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm.SetRateProvider(none)
+	for _, tc := range []struct {
+		c    *Customer
+		want money.Money
+	}{
+		{riya, money.New(100033, money.INR)},
+		{shruti, money.New(2003, money.USD)},
+	} {
+		got, err := cm.GetTotalBalanceBy_Customer_Id(admin, tc.c.CustomerID)
+		if err != nil {
+			t.Fatalf("total for %s: %v", tc.c.FirstName, err)
+		}
+		if got != tc.want {
+			t.Errorf("total for %s = %s, want %s", tc.c.FirstName, got, tc.want)
+		}
+	}
+	if _, err := cm.GetTotalBalance(admin); err == nil {
+		t.Error("total across INR and USD worked without a rate")
+	}
+}
@@ -0,0 +1,77 @@
+package customer
+
+import (
+	"banking-app/apperror"
+	"banking-app/auth"
+	"errors"
+	"time"
+)
+
+// maxIdempotencyKeyLength bounds what a client can make the store remember.
+const maxIdempotencyKeyLength = 255
+
+// SetIdempotencyTTL sets how long a completed request is remembered under
+// its idempotency key. Retries after that run again.
+func (cm *CustomerManager) SetIdempotencyTTL(ttl time.Duration) error {
+	return cm.idempotency.SetTTL(ttl)
+}
+
+// idempotent runs op at most once per idempotency key. Once the first
+// request moved its money, a retry with the same key and fingerprint returns
+// what the first one did without running op, even if what followed the move
+// failed; a key reused for another request is refused. Without a key op
+// simply runs.
+func (cm *CustomerManager) idempotent(p *auth.Principal, idempotencyKey []string, fingerprint string, op func() error) error {
+	if len(idempotencyKey) == 0 || idempotencyKey[0] == "" {
+		return op()
+	}
+	key := idempotencyKey[0]
+	if len(idempotencyKey) > 1 {
+		return apperror.NewValidationError("idempotencyKey", "a request takes at most one key")
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return apperror.NewValidationError("idempotencyKey", "must be at most 255 characters")
+	}
+	if err := cm.authorizeCustomer(p); err != nil {
+		return err
+	}
+
+	replay, err := cm.idempotency.Begin(p.CustomerID(), key, fingerprint)
+	if err != nil || replay {
+		return err
+	}
+	err = op()
+	var moved movedError
+	remember := err == nil || errors.As(err, &moved)
+	if finishErr := cm.idempotency.Finish(p.CustomerID(), key, fingerprint, remember, err); err == nil {
+		err = finishErr
+	}
+	return err
+}
+
+// movedError is a failure in saving or auditing a movement of money that
+// has already happened. The request behind it must not run again.
+type movedError struct {
+	error
+}
+
+func (e movedError) Unwrap() error {
+	return e.error
+}
+
+// afterMove marks err, from the work that follows moving money, as a
+// movedError.
+func afterMove(err error) error {
+	if err == nil {
+		return nil
+	}
+	return movedError{err}
+}
+
+// firstKey is the idempotency key of a request that takes an optional one.
+func firstKey(idempotencyKey []string) string {
+	if len(idempotencyKey) == 0 {
+		return ""
+	}
+	return idempotencyKey[0]
+}
@@ -0,0 +1,141 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/idempotency"
+	"banking-app/joint"
+	"banking-app/ledger"
+	"banking-app/repository"
+	"banking-app/standing"
+	"sync/atomic"
+	"testing"
+)
+
+// flakyStore fails the writes that are switched on.
+type flakyStore struct {
+	*repository.MemoryStore
+	failAccounts    atomic.Bool
+	failAudit       atomic.Bool
+	failKeys        atomic.Bool
+	failApprovals   atomic.Bool
+	failExecutions  atomic.Bool
+	failSettlements atomic.Bool
+	failCustomers   atomic.Bool
+	failStanding    atomic.Bool
+}
+
+var errFlaky = apperror.NewBankError("persist", "store unavailable")
+
+func (s *flakyStore) SaveAccount(a account.Snapshot) error {
+	if s.failAccounts.Load() {
+		return errFlaky
+	}
+	return s.MemoryStore.SaveAccount(a)
+}
+
+func (s *flakyStore) SaveCustomer(c repository.CustomerRecord) error {
+	if s.failCustomers.Load() {
+		return errFlaky
+	}
+	return s.MemoryStore.SaveCustomer(c)
+}
+
+func (s *flakyStore) SaveInstruction(in standing.Instruction) error {
+	if s.failStanding.Load() {
+		return errFlaky
+	}
+	return s.MemoryStore.SaveInstruction(in)
+}
+
+func (s *flakyStore) AppendAudit(e audit.Entry) error {
+	if s.failAudit.Load() {
+		return errFlaky
+	}
+	return s.MemoryStore.AppendAudit(e)
+}
+
+func (s *flakyStore) SaveIdempotencyKey(r idempotency.Record) error {
+	if s.failKeys.Load() {
+		return errFlaky
+	}
+	return s.MemoryStore.SaveIdempotencyKey(r)
+}
+
+func (s *flakyStore) SaveApproval(a joint.Approval) error {
+	if s.failApprovals.Load() {
+		return errFlaky
+	}
+	return s.MemoryStore.SaveApproval(a)
+}
+
+func (s *flakyStore) AppendExecution(e standing.Execution) error {
+	if s.failExecutions.Load() {
+		return errFlaky
+	}
+	return s.MemoryStore.AppendExecution(e)
+}
+
+func (s *flakyStore) SaveSettlement(b ledger.SettlementBatch) error {
+	if s.failSettlements.Load() {
+		return errFlaky
+	}
+	return s.MemoryStore.SaveSettlement(b)
+}
+
+func TestRetryAfterFailureFollowingTheMoveDoesNotMoveAgain(t *testing.T) {
+	store := &flakyStore{MemoryStore: repository.NewMemoryStore()}
+	cm, admin := newTestManagerOn(t, store)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 1000)
+
+	for _, tc := range []struct {
+		name string
+		fail *atomic.Bool
+		move func(key string) error
+		want int64
+		// replayed is whether a retry reports the first request's failure,
+		// rather than the request having gone through.
+		replayed bool
+	}{
+		{"deposit with audit down", &store.failAudit, func(key string) error { return cm.DepositMoney(p, rupees(500), acc.AccountID, key) }, 1500, true},
+		{"withdrawal with accounts down", &store.failAccounts, func(key string) error { return cm.WithDrawMoney(p, rupees(200), acc.AccountID, key) }, 1300, true},
+		{"deposit with keys down", &store.failKeys, func(key string) error { return cm.DepositMoney(p, rupees(100), acc.AccountID, key) }, 1400, false},
+	} {
+		tc.fail.Store(true)
+		first := tc.move(tc.name)
+		if first == nil {
+			t.Errorf("%s: no error reported", tc.name)
+		}
+		tc.fail.Store(false)
+		wantBalance(t, acc, rupees(tc.want))
+		err := tc.move(tc.name)
+		if tc.replayed && err != first {
+			t.Errorf("%s: retry: err = %v, want the first request's %v", tc.name, err, first)
+		}
+		if !tc.replayed && err != nil {
+			t.Errorf("%s: retry: %v", tc.name, err)
+		}
+		wantBalance(t, acc, rupees(tc.want))
+	}
+}
+
+func TestRetryAfterFailureBeforeTheMoveRunsAgain(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 1000)
+
+	if err := cm.WithDrawMoney(p, rupees(900), acc.AccountID, "w1"); err == nil {
+		t.Fatal("withdrawal below the minimum balance went through")
+	}
+	if err := cm.DepositMoney(p, rupees(500), acc.AccountID); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.WithDrawMoney(p, rupees(900), acc.AccountID, "w1"); err != nil {
+		t.Fatalf("retry once funded: %v", err)
+	}
+	wantBalance(t, acc, rupees(600))
+}
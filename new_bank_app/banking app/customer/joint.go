@@ -0,0 +1,338 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/joint"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// AddAccountHolder makes another customer a joint holder of an account. An
+// account with a single holder becomes either-or-survivor until its
+// operating mode is changed.
+func (cm *CustomerManager) AddAccountHolder(p *auth.Principal, accountID, customerID int) error {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	acc, err := cm.findOpenAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if _, err := cm.authorize(p, auth.PermOpenAccounts, acc.BankID); err != nil {
+		return err
+	}
+	holder, err := cm.lookupCustomer(customerID)
+	if err != nil {
+		return err
+	}
+	if holder.Role.IsStaff() {
+		return apperror.NewValidationError("customerID", fmt.Sprintf("%d is a staff member and cannot hold accounts", customerID))
+	}
+	before := holderFields(acc)
+	if err := acc.AddHolder(customerID); err != nil {
+		return err
+	}
+	if err := cm.saveAccounts(acc); err != nil {
+		return err
+	}
+	return cm.recordAudit(p, audit.ActionHolderAdded, "account", accountID, before, holderFields(acc))
+}
+
+// SetOperatingMode changes how many holders of a joint account must agree
+// to a debit. requiredHolders only applies to account.ModeAnyNOfM. Debits
+// already waiting for approval keep the number they started with.
+func (cm *CustomerManager) SetOperatingMode(p *auth.Principal, accountID int, mode account.OperatingMode, requiredHolders int) error {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	acc, err := cm.findOpenAccount(accountID)
+	if err != nil {
+		return err
+	}
+	if _, err := cm.authorize(p, auth.PermOpenAccounts, acc.BankID); err != nil {
+		return err
+	}
+	before := holderFields(acc)
+	if err := acc.SetOperatingMode(mode, requiredHolders); err != nil {
+		return err
+	}
+	if err := cm.saveAccounts(acc); err != nil {
+		return err
+	}
+	return cm.recordAudit(p, audit.ActionOperatingModeSet, "account", accountID, before, holderFields(acc))
+}
+
+// holdForHolders opens an approval for a debit by the caller from acc when
+// its operating mode needs more than one holder to agree. held reports that
+// the debit must not move now: it waits for the other holders, or it was
+// already decided under the same idempotency key, which expires with the
+// idempotency cache. Debits from accounts the caller does not hold are left
+// for the usual checks to refuse.
+func (cm *CustomerManager) holdForHolders(p *auth.Principal, acc *account.Account, request joint.Approval) (held bool, err error) {
+	required := acc.ApprovalsRequired()
+	if required <= 1 || !acc.IsHolder(p.CustomerID()) {
+		return false, nil
+	}
+	if !request.Amount.IsPositive() {
+		return true, apperror.NewValidationError("amount", "must be greater than 0")
+	}
+	if request.Amount.Currency != acc.Currency {
+		return true, apperror.NewValidationError("currency", fmt.Sprintf("account %d is in %s, not %s", acc.AccountID, acc.Currency, request.Amount.Currency))
+	}
+	request.AccountID = acc.AccountID
+
+	cm.approvalMu.Lock()
+	defer cm.approvalMu.Unlock()
+
+	if request.IdempotencyKey != "" {
+		for _, a := range cm.approvals {
+			if a.RequestedBy != p.CustomerID() || a.IdempotencyKey != request.IdempotencyKey || !cm.idempotency.Live(a.RequestedAt) {
+				continue
+			}
+			if !a.SameRequest(request) {
+				return true, apperror.NewValidationError("idempotencyKey", fmt.Sprintf("%q was already used for a different request", request.IdempotencyKey))
+			}
+			return true, approvalOutcome(a)
+		}
+	}
+
+	a := request
+	a.ApprovalID = cm.approvalCounter + 1
+	a.RequestedBy = p.CustomerID()
+	a.Required = required
+	a.ApprovedBy = []int{p.CustomerID()}
+	a.Status = joint.StatusPending
+	a.RequestedAt = cm.clockNow().UTC()
+	if err := cm.store.SaveApproval(a); err != nil {
+		return true, err
+	}
+	cm.approvalCounter = a.ApprovalID
+	cm.approvals[a.ApprovalID] = &a
+	if err := cm.recordAudit(p, audit.ActionApprovalRequested, "approval", a.ApprovalID, nil, approvalFields(&a)); err != nil {
+		return true, err
+	}
+	return true, approvalOutcome(&a)
+}
+
+// approvalOutcome is what the debit behind a returns to whoever asked for
+// it.
+func approvalOutcome(a *joint.Approval) error {
+	switch a.Status {
+	case joint.StatusApproved:
+		return nil
+	case joint.StatusRejected:
+		return apperror.NewBlockedError(string(a.Operation), fmt.Sprintf("declined by holder %d in approval %d", a.DecidedBy, a.ApprovalID))
+	}
+	return apperror.NewPendingApprovalError(string(a.Operation), a.ApprovalID, a.Outstanding())
+}
+
+// Approvals lists the approvals in status, or all of them when status is
+// empty, on accounts the caller holds, oldest first.
+func (cm *CustomerManager) Approvals(p *auth.Principal, status joint.Status) ([]joint.Approval, error) {
+	if err := cm.authorizeCustomer(p); err != nil {
+		return nil, err
+	}
+	cm.approvalMu.Lock()
+	defer cm.approvalMu.Unlock()
+
+	var list []joint.Approval
+	for _, a := range cm.approvals {
+		if status != "" && a.Status != status {
+			continue
+		}
+		if acc, err := account.GetAccountById(a.AccountID); err == nil && acc.IsHolder(p.CustomerID()) {
+			list = append(list, *a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ApprovalID < list[j].ApprovalID })
+	return list, nil
+}
+
+func (cm *CustomerManager) Approval(p *auth.Principal, approvalID int) (joint.Approval, error) {
+	cm.approvalMu.Lock()
+	defer cm.approvalMu.Unlock()
+	a, err := cm.holderApproval(p, approvalID)
+	if err != nil {
+		return joint.Approval{}, err
+	}
+	return *a, nil
+}
+
+// ConfirmApproval adds the caller's agreement to a pending debit. The one
+// that completes the required number moves the money, as the debit stood
+// when it was asked for, on behalf of the holder who asked. If it cannot
+// move now, for example because the balance has since fallen, the
+// confirmation is not recorded and the approval stays pending. A transfer
+// the risk engine holds still counts as approved by the holders, as does a
+// debit that moved but whose saving or auditing failed.
+func (cm *CustomerManager) ConfirmApproval(p *auth.Principal, approvalID int) (joint.Approval, error) {
+	cm.approvalMu.Lock()
+	defer cm.approvalMu.Unlock()
+
+	a, err := cm.pendingApproval(p, approvalID)
+	if err != nil {
+		return joint.Approval{}, err
+	}
+	if a.HasApproved(p.CustomerID()) {
+		return joint.Approval{}, apperror.NewValidationError("approval", fmt.Sprintf("customer %d already approved approval %d", p.CustomerID(), approvalID))
+	}
+	before := approvalFields(a)
+	updated := *a
+	updated.ApprovedBy = append(append([]int(nil), a.ApprovedBy...), p.CustomerID())
+	var moveErr error
+	if updated.Outstanding() == 0 {
+		moveErr = cm.executeApproval(p, &updated)
+		var moved movedError
+		if moveErr != nil && !errors.As(moveErr, &moved) && !errors.Is(moveErr, apperror.ErrHeldForReview) {
+			return joint.Approval{}, moveErr
+		}
+		updated.Status = joint.StatusApproved
+		updated.DecidedBy = p.CustomerID()
+		updated.DecidedAt = cm.clockNow().UTC()
+	}
+	if err := cm.store.SaveApproval(updated); err != nil {
+		// Once the money has moved the approval is approved even if it
+		// could not be saved, or confirming it again would move it twice.
+		if updated.Status == joint.StatusApproved {
+			*a = updated
+		}
+		return joint.Approval{}, err
+	}
+	*a = updated
+	if err := cm.recordAudit(p, audit.ActionApprovalGranted, "approval", a.ApprovalID, before, approvalFields(a)); err != nil {
+		return joint.Approval{}, err
+	}
+	return *a, moveErr
+}
+
+// RejectApproval declines a pending debit, which then never moves. Any
+// holder may decline, including the one who asked for it.
+func (cm *CustomerManager) RejectApproval(p *auth.Principal, approvalID int) (joint.Approval, error) {
+	cm.approvalMu.Lock()
+	defer cm.approvalMu.Unlock()
+
+	a, err := cm.pendingApproval(p, approvalID)
+	if err != nil {
+		return joint.Approval{}, err
+	}
+	before := approvalFields(a)
+	rejected := *a
+	rejected.Status = joint.StatusRejected
+	rejected.DecidedBy = p.CustomerID()
+	rejected.DecidedAt = cm.clockNow().UTC()
+	if err := cm.store.SaveApproval(rejected); err != nil {
+		return joint.Approval{}, err
+	}
+	*a = rejected
+	if err := cm.recordAudit(p, audit.ActionApprovalRejected, "approval", a.ApprovalID, before, approvalFields(a)); err != nil {
+		return joint.Approval{}, err
+	}
+	return *a, nil
+}
+
+// executeApproval moves an approved debit with p as the actor audited.
+func (cm *CustomerManager) executeApproval(p *auth.Principal, a *joint.Approval) error {
+	switch a.Operation {
+	case joint.OpWithdrawal:
+		acc, err := cm.openAccount(a.AccountID)
+		if err != nil {
+			return err
+		}
+		return cm.withdraw(p, a.RequestedBy, acc, a.Amount)
+	case joint.OpInternalTransfer:
+		return cm.moveInternally(p, a.RequestedBy, a.AccountID, a.ToAccountID, a.Amount)
+	case joint.OpBeneficiaryTransfer:
+		return cm.payBeneficiary(p, a.RequestedBy, a.AccountID, a.BeneficiaryID, a.Amount, a.IdempotencyKey)
+	}
+	return apperror.NewValidationError("operation", fmt.Sprintf("unknown operation %q", a.Operation))
+}
+
+// holderApproval finds an approval on an account the caller holds. Others
+// are reported as not found. It expects cm.approvalMu to be held.
+func (cm *CustomerManager) holderApproval(p *auth.Principal, approvalID int) (*joint.Approval, error) {
+	if err := cm.authorizeCustomer(p); err != nil {
+		return nil, err
+	}
+	a := cm.approvals[approvalID]
+	if a == nil {
+		return nil, apperror.NewNotFoundError("approval", approvalID)
+	}
+	if acc, err := account.GetAccountById(a.AccountID); err != nil || !acc.IsHolder(p.CustomerID()) {
+		return nil, apperror.NewNotFoundError("approval", approvalID)
+	}
+	return a, nil
+}
+
+func (cm *CustomerManager) pendingApproval(p *auth.Principal, approvalID int) (*joint.Approval, error) {
+	a, err := cm.holderApproval(p, approvalID)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status != joint.StatusPending {
+		return nil, apperror.NewValidationError("approval", "approval "+strconv.Itoa(approvalID)+" was already "+string(a.Status))
+	}
+	return a, nil
+}
+
+// requireSoleMandate refuses a debit made without a holder present, such as
+// a standing instruction, from an account that needs several holders to
+// agree to each one.
+func requireSoleMandate(acc *account.Account) error {
+	if n := acc.ApprovalsRequired(); n > 1 {
+		return apperror.NewValidationError("fromAccountID", fmt.Sprintf("account %d needs %d holders to approve each debit", acc.AccountID, n))
+	}
+	return nil
+}
+
+// heldAccounts lists the accounts customerID holds, alone or jointly. It
+// expects cm.mu to be held.
+func (cm *CustomerManager) heldAccounts(customerID int) []*account.Account {
+	var held []*account.Account
+	for _, c := range cm.customers {
+		for _, acc := range c.Accounts {
+			if acc.IsHolder(customerID) {
+				held = append(held, acc)
+			}
+		}
+	}
+	return held
+}
+
+func holderFields(acc *account.Account) map[string]string {
+	s := acc.Snapshot()
+	fields := map[string]string{
+		"holders": fmt.Sprint(acc.Holders()),
+		"mode":    string(s.Mode),
+	}
+	if s.Mode == account.ModeSole {
+		fields["mode"] = "sole"
+	}
+	if s.Mode == account.ModeAnyNOfM {
+		fields["required_holders"] = strconv.Itoa(s.RequiredHolders)
+	}
+	return fields
+}
+
+func approvalFields(a *joint.Approval) map[string]string {
+	fields := map[string]string{
+		"status":       string(a.Status),
+		"operation":    string(a.Operation),
+		"account_id":   strconv.Itoa(a.AccountID),
+		"amount":       a.Amount.String(),
+		"requested_by": strconv.Itoa(a.RequestedBy),
+		"approved_by":  fmt.Sprint(a.ApprovedBy),
+		"required":     strconv.Itoa(a.Required),
+	}
+	if a.ToAccountID != 0 {
+		fields["to_account_id"] = strconv.Itoa(a.ToAccountID)
+	}
+	if a.BeneficiaryID != 0 {
+		fields["beneficiary_id"] = strconv.Itoa(a.BeneficiaryID)
+	}
+	return fields
+}
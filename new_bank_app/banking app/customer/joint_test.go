@@ -0,0 +1,124 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/beneficiary"
+	"banking-app/clock"
+	"banking-app/joint"
+	"banking-app/repository"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConfirmAgainAfterFailureFollowingTheMoveDoesNotMoveAgain(t *testing.T) {
+	store := &flakyStore{MemoryStore: repository.NewMemoryStore()}
+	cm, admin := newTestManagerOn(t, store)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	shruti, q := newTestCustomer(t, cm, admin, "Shruti")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	if err := cm.AddAccountHolder(admin, acc.AccountID, shruti.CustomerID); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.SetOperatingMode(admin, acc.AccountID, account.ModeJointly, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		fail *atomic.Bool
+		want int64
+	}{
+		{"accounts down", &store.failAccounts, 9000},
+		{"approvals down", &store.failApprovals, 8000},
+	} {
+		var pending *apperror.PendingApprovalError
+		if err := cm.WithDrawMoney(p, rupees(1000), acc.AccountID); !errors.As(err, &pending) {
+			t.Fatalf("%s: withdrawal: err = %v, want PendingApprovalError", tc.name, err)
+		}
+		tc.fail.Store(true)
+		if _, err := cm.ConfirmApproval(q, pending.ApprovalID); err == nil {
+			t.Errorf("%s: no error reported", tc.name)
+		}
+		tc.fail.Store(false)
+		wantBalance(t, acc, rupees(tc.want))
+
+		if _, err := cm.ConfirmApproval(q, pending.ApprovalID); err == nil {
+			t.Errorf("%s: confirmed again", tc.name)
+		}
+		wantBalance(t, acc, rupees(tc.want))
+		if a, err := cm.Approval(p, pending.ApprovalID); err != nil || a.Status != joint.StatusApproved {
+			t.Errorf("%s: approval = %+v, %v, want approved", tc.name, a, err)
+		}
+	}
+}
+
+func TestExpiredKeyDoesNotResolveToAnOldApproval(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sim := clock.NewSimulated(time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC))
+	cm.SetClock(sim.Now)
+	if err := cm.SetIdempotencyTTL(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	shruti, q := newTestCustomer(t, cm, admin, "Shruti")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	if err := cm.AddAccountHolder(admin, acc.AccountID, shruti.CustomerID); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.SetOperatingMode(admin, acc.AccountID, account.ModeJointly, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second *apperror.PendingApprovalError
+	if err := cm.WithDrawMoney(p, rupees(1000), acc.AccountID, "cash"); !errors.As(err, &first) {
+		t.Fatalf("withdrawal: err = %v, want PendingApprovalError", err)
+	}
+	if _, err := cm.ConfirmApproval(q, first.ApprovalID); err != nil {
+		t.Fatal(err)
+	}
+	sim.Advance(2 * time.Hour)
+
+	if err := cm.WithDrawMoney(p, rupees(1000), acc.AccountID, "cash"); !errors.As(err, &second) {
+		t.Fatalf("withdrawal under an expired key: err = %v, want PendingApprovalError", err)
+	}
+	if second.ApprovalID == first.ApprovalID {
+		t.Errorf("withdrawal under an expired key resolved to approval %d again", first.ApprovalID)
+	}
+	wantBalance(t, acc, rupees(9000))
+}
+
+func TestBeneficiaryIsPaidToTheSurvivingHolder(t *testing.T) {
+	cm, admin := newTestManager(t)
+	cm.SetRiskEngine(nil)
+	if err := cm.SetBeneficiaryPolicy(beneficiary.Policy{}); err != nil {
+		t.Fatal(err)
+	}
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	shruti, _ := newTestCustomer(t, cm, admin, "Shruti")
+	meera, _ := newTestCustomer(t, cm, admin, "Meera")
+	from := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	to := newTestAccount(t, cm, admin, shruti, bob, account.ProductSavings, 1000)
+	if err := cm.AddAccountHolder(admin, to.AccountID, meera.CustomerID); err != nil {
+		t.Fatal(err)
+	}
+	payee, err := cm.AddBeneficiary(p, to.Number, "Shruti")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.RecordDeath(admin, shruti.CustomerID, time.Now().AddDate(0, 0, -1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cm.TransferToBeneficiary(p, from.AccountID, payee.BeneficiaryID, rupees(700)); err != nil {
+		t.Fatal(err)
+	}
+	wantBalance(t, from, rupees(9300))
+	wantBalance(t, to, rupees(1700))
+}
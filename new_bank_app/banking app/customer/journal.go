@@ -0,0 +1,211 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/auth"
+	"banking-app/journal"
+	"banking-app/ledger"
+	"banking-app/money"
+	"fmt"
+	"sort"
+)
+
+// restoreJournal loads the journal and, for a store written before the
+// journal existed, brings every balance and outstanding due forward so the
+// journal agrees with the accounts from the start. It runs from restore,
+// after the accounts and dues are loaded.
+func (cm *CustomerManager) restoreJournal() error {
+	entries, err := cm.store.LoadJournal()
+	if err != nil {
+		return err
+	}
+	if err := cm.journal.Restore(entries); err != nil {
+		return err
+	}
+	cm.persistedJournal = len(entries)
+	if len(entries) > 0 {
+		return nil
+	}
+
+	for _, acc := range cm.allAccounts() {
+		acc.BringForward()
+	}
+	if lines := clearingLines(cm.outstandingPositions(), journal.OpeningBalances); len(lines) > 0 {
+		if _, err := cm.journal.Post(journal.Entry{Timestamp: cm.now().UTC(), Memo: "BROUGHT_FORWARD", Lines: lines}); err != nil {
+			return err
+		}
+	}
+	return cm.flushJournal()
+}
+
+// flushJournal appends the entries the store has not seen yet. It expects
+// cm.persistMu to be held, or the manager not yet shared.
+func (cm *CustomerManager) flushJournal() error {
+	pending := cm.journal.Since(cm.persistedJournal)
+	if err := cm.store.AppendJournal(pending); err != nil {
+		return err
+	}
+	cm.persistedJournal += len(pending)
+	return nil
+}
+
+// postSettlement moves each bank's net position out of its clearing account
+// and into its vault: payers' vaults pay out, receivers' vaults take in.
+func (cm *CustomerManager) postSettlement(batch ledger.SettlementBatch) error {
+	lines := clearingLines(batch.NetPositions, journal.Vault)
+	for i := range lines {
+		lines[i].Side = opposite(lines[i].Side)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	_, err := cm.journal.Post(journal.Entry{
+		ReferenceID: fmt.Sprintf("SETTLEMENT%d", batch.BatchID),
+		Timestamp:   batch.ClosedAt,
+		Memo:        "SETTLEMENT",
+		Lines:       lines,
+	})
+	return err
+}
+
+// clearingLines debits each bank's clearing account with what it is owed,
+// credits it with what it owes, and balances each line against contra.
+func clearingLines(positions []ledger.NetPosition, contra func(bankID int) journal.Code) []journal.Line {
+	var lines []journal.Line
+	for _, pos := range positions {
+		clearing := journal.Clearing(pos.BankID)
+		switch {
+		case pos.Net.IsPositive():
+			lines = append(lines, journal.DebitLine(clearing, pos.Net), journal.CreditLine(contra(pos.BankID), pos.Net))
+		case pos.Net.IsNegative():
+			lines = append(lines, journal.CreditLine(clearing, pos.Net.Neg()), journal.DebitLine(contra(pos.BankID), pos.Net.Neg()))
+		}
+	}
+	return lines
+}
+
+func opposite(side journal.Side) journal.Side {
+	if side == journal.Debit {
+		return journal.Credit
+	}
+	return journal.Debit
+}
+
+type bankCurrency struct {
+	bankID   int
+	currency string
+}
+
+// outstandingPositions nets the open window's dues per bank and currency,
+// receivable less owed, in currency and then BankID order.
+func (cm *CustomerManager) outstandingPositions() []ledger.NetPosition {
+	net := make(map[bankCurrency]money.Money)
+	for _, d := range cm.ledger.Dues() {
+		to := bankCurrency{d.ToBankID, d.Amount.Currency}
+		from := bankCurrency{d.FromBankID, d.Amount.Currency}
+		net[to], _ = net[to].Add(d.Amount)
+		net[from], _ = net[from].Sub(d.Amount)
+	}
+	positions := make([]ledger.NetPosition, 0, len(net))
+	for key, n := range net {
+		positions = append(positions, ledger.NetPosition{BankID: key.bankID, Net: n})
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Net.Currency != positions[j].Net.Currency {
+			return positions[i].Net.Currency < positions[j].Net.Currency
+		}
+		return positions[i].BankID < positions[j].BankID
+	})
+	return positions
+}
+
+// TrialBalances draws up one trial balance per currency.
+func (cm *CustomerManager) TrialBalances(p *auth.Principal) ([]journal.TrialBalance, error) {
+	cm.mu.RLock()
+	_, err := cm.authorize(p, auth.PermViewJournal)
+	cm.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	return cm.journal.TrialBalances()
+}
+
+// VerifyJournal proves the journal against the balances it accounts for:
+// the trial balance in every currency must balance, every customer account
+// in the journal must match its Account.Balance, and every clearing account
+// must match the bank's outstanding interbank dues in that currency. A
+// posting committing while this runs can show up as a passing mismatch.
+func (cm *CustomerManager) VerifyJournal(p *auth.Principal) error {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if _, err := cm.authorize(p, auth.PermViewJournal); err != nil {
+		return err
+	}
+	tbs, err := cm.journal.TrialBalances()
+	if err != nil {
+		return err
+	}
+	for _, tb := range tbs {
+		if !tb.Balanced() {
+			return apperror.NewBankError("journal verification", fmt.Sprintf("debits %s do not equal credits %s", tb.TotalDebit, tb.TotalCredit))
+		}
+	}
+
+	for _, acc := range cm.allAccounts() {
+		// Customer accounts are liabilities, so a positive balance is a
+		// credit balance in the journal.
+		code := journal.Customer(acc.AccountID)
+		if err := agrees(code, cm.journal.Balance(code, acc.Currency).Neg(), acc.GetBalance()); err != nil {
+			return err
+		}
+	}
+	outstanding := make(map[bankCurrency]money.Money)
+	for _, pos := range cm.outstandingPositions() {
+		outstanding[bankCurrency{pos.BankID, pos.Net.Currency}] = pos.Net
+	}
+	for _, tb := range tbs {
+		for _, row := range tb.Rows {
+			var bankID int
+			if _, err := fmt.Sscanf(string(row.Account), "clearing:%d", &bankID); err != nil {
+				continue
+			}
+			key := bankCurrency{bankID, tb.Currency}
+			if err := agrees(row.Account, cm.journal.Balance(row.Account, tb.Currency), outstanding[key]); err != nil {
+				return err
+			}
+			delete(outstanding, key)
+		}
+	}
+	for key, net := range outstanding {
+		if err := agrees(journal.Clearing(key.bankID), money.Money{}, net); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func agrees(code journal.Code, journalBalance, expected money.Money) error {
+	if journalBalance.IsZero() && expected.IsZero() {
+		return nil
+	}
+	if cmp, err := journalBalance.Cmp(expected); err == nil && cmp == 0 {
+		return nil
+	}
+	return apperror.NewBankError("journal verification", fmt.Sprintf("%s is %s in the journal but %s in the books", code, journalBalance, expected))
+}
+
+// allAccounts lists every account ever opened, closed ones and those of
+// deleted customers included, in AccountID order. It expects cm.mu to be
+// held, or the manager not yet shared.
+func (cm *CustomerManager) allAccounts() []*account.Account {
+	var accs []*account.Account
+	for _, c := range cm.customers {
+		for _, acc := range c.Accounts {
+			accs = append(accs, acc)
+		}
+	}
+	sort.Slice(accs, func(i, j int) bool { return accs[i].AccountID < accs[j].AccountID })
+	return accs
+}
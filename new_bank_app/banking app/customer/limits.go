@@ -0,0 +1,149 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MaxLimitIncrease is the longest a temporary limit increase may last.
+const MaxLimitIncrease = 30 * 24 * time.Hour
+
+// AccountLimits is what an account may still take out: the limits in force,
+// the temporary increase behind them if any, and the usage counted against
+// them.
+type AccountLimits struct {
+	Limits   account.Limits
+	Increase *account.LimitIncrease
+	Usage    account.Usage
+}
+
+// resolveLimits is the limit resolver of the manager's books. Accounts are
+// locked when it runs, so it reads the copies under limitsMu rather than
+// taking cm.mu. Limits are set in BaseCurrency and converted into the
+// account's own at today's rate.
+func (cm *CustomerManager) resolveLimits(a *account.Account, at time.Time) (account.Limits, error) {
+	cm.limitsMu.RLock()
+	limits, ok := cm.bankLimits[a.BankID][a.Terms.Product]
+	if !ok {
+		limits = account.DefaultLimits(a.Terms.Product)
+	}
+	if increase, ok := cm.limitIncreases[a.AccountID]; ok && increase.ActiveAt(at) {
+		limits = limits.Raise(increase.Limits)
+	}
+	cm.limitsMu.RUnlock()
+	return limits.Convert(cm.inCurrency(a.Currency))
+}
+
+// SetLimits sets the limits a bank applies to every account of a product.
+func (cm *CustomerManager) SetLimits(p *auth.Principal, bankID int, product account.Product, limits account.Limits) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.authorize(p, auth.PermManageLimits, bankID); err != nil {
+		return err
+	}
+	b, err := cm.lookupBank(bankID)
+	if err != nil {
+		return err
+	}
+	before, ok := b.Limits(product)
+	if !ok {
+		before = account.DefaultLimits(product)
+	}
+	if err := b.SetLimits(product, limits); err != nil {
+		return err
+	}
+	if err := cm.store.SaveBank(*b); err != nil {
+		return err
+	}
+	cm.limitsMu.Lock()
+	cm.bankLimits[bankID] = b.ProductLimits
+	cm.limitsMu.Unlock()
+	return cm.recordAudit(p, audit.ActionLimitsSet, "bank", bankID, limitFields(product, before), limitFields(product, limits))
+}
+
+// GrantLimitIncrease raises an account's limits until expiresAt, replacing
+// any increase granted earlier. Limits the increase leaves zero keep their
+// usual value.
+func (cm *CustomerManager) GrantLimitIncrease(p *auth.Principal, accountID int, limits account.Limits, expiresAt time.Time) (account.LimitIncrease, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	acc, err := cm.findOpenAccount(accountID)
+	if err != nil {
+		return account.LimitIncrease{}, err
+	}
+	if _, err := cm.authorize(p, auth.PermManageLimits, acc.BankID); err != nil {
+		return account.LimitIncrease{}, err
+	}
+	if err := limits.Validate(); err != nil {
+		return account.LimitIncrease{}, err
+	}
+	now := cm.now().UTC()
+	if !expiresAt.After(now) {
+		return account.LimitIncrease{}, apperror.NewValidationError("expiresAt", "must be in the future")
+	}
+	if expiresAt.Sub(now) > MaxLimitIncrease {
+		return account.LimitIncrease{}, apperror.NewValidationError("expiresAt", fmt.Sprintf("increases last at most %d days", MaxLimitIncrease/(24*time.Hour)))
+	}
+
+	increase := account.LimitIncrease{
+		AccountID: accountID,
+		Limits:    limits,
+		GrantedBy: p.CustomerID(),
+		GrantedAt: now,
+		ExpiresAt: expiresAt.UTC(),
+	}
+	if err := cm.store.SaveLimitIncrease(increase); err != nil {
+		return account.LimitIncrease{}, err
+	}
+	cm.limitsMu.Lock()
+	cm.limitIncreases[accountID] = increase
+	cm.limitsMu.Unlock()
+
+	after := limitFields(acc.Terms.Product, limits)
+	after["expires_at"] = increase.ExpiresAt.Format(time.RFC3339)
+	return increase, cm.recordAudit(p, audit.ActionLimitIncreased, "account", accountID, nil, after)
+}
+
+// AccountLimits shows the account's owner, or staff who may view it, the
+// limits in force now and how much of them has been used.
+func (cm *CustomerManager) AccountLimits(p *auth.Principal, accountID int) (AccountLimits, error) {
+	acc, err := cm.ViewAccount(p, accountID)
+	if err != nil {
+		return AccountLimits{}, err
+	}
+	now := cm.clockNow()
+	usage, err := acc.UsageAt(now)
+	if err != nil {
+		return AccountLimits{}, err
+	}
+	limits, err := cm.resolveLimits(acc, now)
+	if err != nil {
+		return AccountLimits{}, err
+	}
+	view := AccountLimits{Limits: limits, Usage: usage}
+
+	cm.limitsMu.RLock()
+	if increase, ok := cm.limitIncreases[accountID]; ok && increase.ActiveAt(now) {
+		view.Increase = &increase
+	}
+	cm.limitsMu.RUnlock()
+	return view, nil
+}
+
+func limitFields(product account.Product, l account.Limits) map[string]string {
+	return map[string]string{
+		"product":               string(product),
+		"per_transaction":       l.PerTransaction.String(),
+		"daily_withdrawal":      l.DailyWithdrawal.String(),
+		"monthly_withdrawal":    l.MonthlyWithdrawal.String(),
+		"daily_transfers":       strconv.Itoa(l.DailyTransfers),
+		"daily_transfer_amount": l.DailyTransferAmount.String(),
+	}
+}
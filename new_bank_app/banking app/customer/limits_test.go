@@ -0,0 +1,57 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/clock"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimitIncreaseLiftsTheDailyWithdrawalUntilItExpires(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sim := clock.NewSimulated(time.Date(2025, time.March, 3, 10, 0, 0, 0, time.UTC))
+	cm.SetClock(sim.Now)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 200000)
+
+	wantExceeded := func(step string) {
+		t.Helper()
+		var exceeded *apperror.LimitExceededError
+		if err := cm.WithDrawMoney(p, rupees(1), acc.AccountID); !errors.As(err, &exceeded) {
+			t.Fatalf("%s: err = %v, want LimitExceededError", step, err)
+		}
+		if want := time.Date(2025, time.March, 4, 0, 0, 0, 0, time.UTC); !exceeded.ResetsAt.Equal(want) {
+			t.Errorf("%s: limit resets at %s, want %s", step, exceeded.ResetsAt, want)
+		}
+	}
+
+	// Savings accounts may take out INR 50000.00 a day.
+	if err := cm.WithDrawMoney(p, rupees(50000), acc.AccountID); err != nil {
+		t.Fatal(err)
+	}
+	wantExceeded("at the default limit")
+
+	if _, err := cm.GrantLimitIncrease(admin, acc.AccountID, account.Limits{DailyWithdrawal: rupees(80000)}, sim.Now().Add(-time.Minute)); err == nil {
+		t.Error("increase that has already expired was granted")
+	}
+	if _, err := cm.GrantLimitIncrease(admin, acc.AccountID, account.Limits{DailyWithdrawal: rupees(80000)}, sim.Now().Add(MaxLimitIncrease+time.Hour)); err == nil {
+		t.Error("increase longer than MaxLimitIncrease was granted")
+	}
+	if _, err := cm.GrantLimitIncrease(p, acc.AccountID, account.Limits{DailyWithdrawal: rupees(80000)}, sim.Now().Add(2*time.Hour)); err == nil {
+		t.Error("customer granted their own increase")
+	}
+	if _, err := cm.GrantLimitIncrease(admin, acc.AccountID, account.Limits{DailyWithdrawal: rupees(80000)}, sim.Now().Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.WithDrawMoney(p, rupees(30000), acc.AccountID); err != nil {
+		t.Fatalf("within the increase: %v", err)
+	}
+	wantExceeded("at the increased limit")
+
+	sim.Advance(3 * time.Hour)
+	wantExceeded("after the increase expired")
+	wantBalance(t, acc, rupees(120000))
+}
@@ -0,0 +1,70 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"errors"
+	"testing"
+)
+
+func TestPassbookPages(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	_, stranger := newTestCustomer(t, cm, admin, "Shruti")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 1000)
+	const deposits = PassbookPageSize + 3
+	for i := int64(1); i <= deposits; i++ {
+		if err := cm.DepositMoney(p, rupees(i), acc.AccountID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	passbook := acc.GetPassbook()
+	opening := len(passbook) - deposits
+
+	page := func(pageNo int) []account.Transaction {
+		t.Helper()
+		txns, err := cm.GetPassBook_ById(p, acc.AccountID, pageNo)
+		if err != nil {
+			t.Fatalf("page %d: %v", pageNo, err)
+		}
+		return txns
+	}
+	first, second := page(1), page(2)
+	if len(first) != PassbookPageSize || len(second) != len(passbook)-PassbookPageSize {
+		t.Fatalf("pages hold %d and %d entries, want %d and %d", len(first), len(second), PassbookPageSize, len(passbook)-PassbookPageSize)
+	}
+	// Entries run oldest first, within a page and from one page to the next.
+	for i, txn := range append(first, second...) {
+		if txn.ReferenceID != passbook[i].ReferenceID {
+			t.Errorf("entry %d is %s, want %s", i, txn.ReferenceID, passbook[i].ReferenceID)
+		}
+		if i >= opening && txn.Amount != rupees(int64(i-opening+1)) {
+			t.Errorf("entry %d is a deposit of %s, want %s", i, txn.Amount, rupees(int64(i-opening+1)))
+		}
+		if i > 0 && txn.Timestamp.Before(passbook[i-1].Timestamp) {
+			t.Errorf("entry %d at %s comes before the one ahead of it", i, txn.Timestamp)
+		}
+	}
+
+	// Pages before the first are the first page; pages past the end are
+	// empty rather than an error.
+	for _, pageNo := range []int{0, -1} {
+		if got := page(pageNo); len(got) != len(first) || got[0].ReferenceID != first[0].ReferenceID {
+			t.Errorf("page %d holds %d entries, want the first page's %d", pageNo, len(got), len(first))
+		}
+	}
+	for _, pageNo := range []int{3, 100} {
+		if got := page(pageNo); len(got) != 0 {
+			t.Errorf("page %d holds %d entries, want none", pageNo, len(got))
+		}
+	}
+
+	var notFound *apperror.NotFoundError
+	if _, err := cm.GetPassBook_ById(stranger, acc.AccountID, 1); !errors.As(err, &notFound) {
+		t.Errorf("another customer reading the passbook: err = %v, want NotFoundError", err)
+	}
+	if _, err := cm.GetPassBook_ById(admin, acc.AccountID, 1); err == nil {
+		t.Error("a passbook was read by staff instead of its holder")
+	}
+}
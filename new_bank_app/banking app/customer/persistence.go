@@ -0,0 +1,339 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/auth"
+	"banking-app/bank"
+	"banking-app/repository"
+	"fmt"
+	"sort"
+	"time"
+)
+
+func (c *Customer) record() repository.CustomerRecord {
+	return repository.CustomerRecord{
+		CustomerID: c.CustomerID,
+		FirstName:  c.FirstName,
+		LastName:   c.LastName,
+		Role:       string(c.Role),
+		BankIDs:    append([]int(nil), c.BankIDs...),
+		IsActive:   c.IsActive,
+		DeceasedOn: c.DeceasedOn,
+	}
+}
+
+// restore loads banks, customers, accounts with their passbooks, the
+// interbank dues and settlements, the journal, limit increases, transfer
+// reviews, joint account approvals, account closures, beneficiaries,
+// standing instructions with their history, the idempotency keys, the
+// closed business days and the audit log from the store. It runs before the
+// manager is shared.
+func (cm *CustomerManager) restore() error {
+	banks, err := cm.store.LoadBanks()
+	if err != nil {
+		return err
+	}
+	for _, b := range banks {
+		b := b
+		cm.banks[b.BankID] = &b
+		cm.bankLimits[b.BankID] = b.ProductLimits
+		cm.trackID(b.BankID)
+		for _, branch := range b.Branches {
+			cm.trackID(branch.BranchID)
+		}
+	}
+
+	customers, err := cm.store.LoadCustomers()
+	if err != nil {
+		return err
+	}
+	for _, rec := range customers {
+		role, err := restoreRole(rec)
+		if err != nil {
+			return err
+		}
+		c := &Customer{
+			CustomerID: rec.CustomerID,
+			FirstName:  rec.FirstName,
+			LastName:   rec.LastName,
+			Role:       role,
+			BankIDs:    rec.BankIDs,
+			IsActive:   rec.IsActive,
+			DeceasedOn: rec.DeceasedOn,
+			Accounts:   make(map[int]*account.Account),
+		}
+		cm.customers[c.CustomerID] = c
+		cm.trackID(c.CustomerID)
+	}
+
+	snapshots, err := cm.store.LoadAccounts()
+	if err != nil {
+		return err
+	}
+	for _, s := range snapshots {
+		cm.trackID(s.AccountID)
+	}
+	if err := cm.migrateBanks(); err != nil {
+		return err
+	}
+	for _, s := range snapshots {
+		if b := cm.banks[s.BankID]; b != nil {
+			if s.BranchID == 0 {
+				headOffice, _ := b.HeadOffice()
+				s.BranchID = headOffice.BranchID
+			}
+			// Accounts opened before they had numbers get the one they
+			// would have been given.
+			if branch, ok := b.Branch(s.BranchID); ok && s.Number == "" {
+				if s.Number, err = account.FormatNumber(b.Code, branch.Code, s.AccountID); err != nil {
+					return err
+				}
+			}
+		}
+		txns, err := cm.store.LoadTransactions(s.AccountID)
+		if err != nil {
+			return err
+		}
+		acc, err := account.Restore(cm.books, s, txns)
+		if err != nil {
+			return err
+		}
+		if owner := cm.customers[s.OwnerID]; owner != nil {
+			owner.Accounts[acc.AccountID] = acc
+		}
+		cm.persistedTxns[acc.AccountID] = len(txns)
+		cm.trackID(acc.AccountID)
+	}
+
+	credentials, err := cm.store.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	cm.credentials = credentials
+
+	dues, err := cm.store.LoadDues()
+	if err != nil {
+		return err
+	}
+	cm.ledger.RestoreDues(dues)
+
+	batches, err := cm.store.LoadSettlements()
+	if err != nil {
+		return err
+	}
+	cm.ledger.RestoreBatches(batches)
+
+	if err := cm.restoreJournal(); err != nil {
+		return err
+	}
+
+	increases, err := cm.store.LoadLimitIncreases()
+	if err != nil {
+		return err
+	}
+	for _, i := range increases {
+		cm.limitIncreases[i.AccountID] = i
+	}
+
+	reviews, err := cm.store.LoadReviews()
+	if err != nil {
+		return err
+	}
+	for _, r := range reviews {
+		r := r
+		cm.reviews[r.ReviewID] = &r
+		if r.ReviewID > cm.reviewCounter {
+			cm.reviewCounter = r.ReviewID
+		}
+	}
+
+	approvals, err := cm.store.LoadApprovals()
+	if err != nil {
+		return err
+	}
+	for _, a := range approvals {
+		a := a
+		cm.approvals[a.ApprovalID] = &a
+		if a.ApprovalID > cm.approvalCounter {
+			cm.approvalCounter = a.ApprovalID
+		}
+	}
+
+	closures, err := cm.store.LoadClosures()
+	if err != nil {
+		return err
+	}
+	for _, c := range closures {
+		c := c
+		cm.closures[c.ClosureID] = &c
+		if c.ClosureID > cm.closureCounter {
+			cm.closureCounter = c.ClosureID
+		}
+	}
+
+	beneficiaries, err := cm.store.LoadBeneficiaries()
+	if err != nil {
+		return err
+	}
+	for _, b := range beneficiaries {
+		b := b
+		if acc, err := account.GetAccountById(b.AccountID); err == nil && b.AccountNumber == "" {
+			b.AccountNumber = acc.Number
+		}
+		cm.beneficiaries[b.BeneficiaryID] = &b
+		if b.BeneficiaryID > cm.beneficiaryCounter {
+			cm.beneficiaryCounter = b.BeneficiaryID
+		}
+	}
+
+	instructions, err := cm.store.LoadInstructions()
+	if err != nil {
+		return err
+	}
+	for _, in := range instructions {
+		in := in
+		cm.instructions[in.InstructionID] = &in
+		if in.InstructionID > cm.standingCounter {
+			cm.standingCounter = in.InstructionID
+		}
+	}
+	executions, err := cm.store.LoadExecutions()
+	if err != nil {
+		return err
+	}
+	for _, e := range executions {
+		cm.executions[e.InstructionID] = append(cm.executions[e.InstructionID], e)
+	}
+
+	keys, err := cm.store.LoadIdempotencyKeys()
+	if err != nil {
+		return err
+	}
+	cm.idempotency.Restore(keys)
+
+	runs, err := cm.store.LoadEODRuns()
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		cm.eodRuns[run.BusinessDate.Format(time.DateOnly)] = run
+		if run.BusinessDate.After(cm.lastClosed) {
+			cm.lastClosed = run.BusinessDate
+		}
+	}
+
+	entries, err := cm.store.LoadAudit()
+	if err != nil {
+		return err
+	}
+	head, err := cm.store.LoadAuditHead()
+	if err != nil {
+		return err
+	}
+	return cm.audit.Restore(entries, head)
+}
+
+// restoreRole maps records from before roles existed onto the role model:
+// their single admin becomes a super-admin.
+func restoreRole(rec repository.CustomerRecord) (auth.Role, error) {
+	if rec.Role != "" {
+		return auth.ParseRole(rec.Role)
+	}
+	if rec.IsAdmin {
+		return auth.RoleSuperAdmin, nil
+	}
+	return auth.RoleCustomer, nil
+}
+
+// migrateBanks gives banks stored before they had codes and branches a code
+// and a head office, and saves them so they keep both. It needs every stored
+// ID tracked first.
+func (cm *CustomerManager) migrateBanks() error {
+	ids := make([]int, 0, len(cm.banks))
+	for id := range cm.banks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		b := cm.banks[id]
+		if b.Code != "" && len(b.Branches) > 0 {
+			continue
+		}
+		if b.Code == "" {
+			if b.Code = bank.SuggestCode(b.Name, cm.bankCodeTaken); b.Code == "" {
+				return apperror.NewBankError("restore banks", fmt.Sprintf("no free code for bank %d", id))
+			}
+		}
+		if len(b.Branches) == 0 {
+			if _, err := b.AddBranch(cm.generateCustomerID(), "Head Office", bank.HeadOfficeCode); err != nil {
+				return err
+			}
+		}
+		if err := cm.store.SaveBank(*b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cm *CustomerManager) trackID(id int) {
+	if id > cm.idCounter {
+		cm.idCounter = id
+	}
+}
+
+func (cm *CustomerManager) saveCustomer(c *Customer) error {
+	return cm.store.SaveCustomer(c.record())
+}
+
+// saveAccounts writes the current state of each account together with any
+// passbook and journal entries the store has not seen yet.
+func (cm *CustomerManager) saveAccounts(accs ...*account.Account) error {
+	cm.persistMu.Lock()
+	defer cm.persistMu.Unlock()
+
+	if err := cm.flushJournal(); err != nil {
+		return err
+	}
+	for _, acc := range accs {
+		if err := cm.savePassbook(acc); err != nil {
+			return err
+		}
+		if err := cm.store.SaveAccount(acc.Snapshot()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveAccountAs is saveAccounts for one account, writing s in place of its
+// current state. RecordDeath saves a change this way before making it.
+func (cm *CustomerManager) saveAccountAs(acc *account.Account, s account.Snapshot) error {
+	cm.persistMu.Lock()
+	defer cm.persistMu.Unlock()
+
+	if err := cm.flushJournal(); err != nil {
+		return err
+	}
+	if err := cm.savePassbook(acc); err != nil {
+		return err
+	}
+	return cm.store.SaveAccount(s)
+}
+
+// savePassbook expects cm.persistMu to be held.
+func (cm *CustomerManager) savePassbook(acc *account.Account) error {
+	pending := acc.PassbookSince(cm.persistedTxns[acc.AccountID])
+	if err := cm.store.AppendTransactions(acc.AccountID, pending); err != nil {
+		return err
+	}
+	cm.persistedTxns[acc.AccountID] += len(pending)
+	return nil
+}
+
+func (cm *CustomerManager) saveDues() error {
+	cm.persistMu.Lock()
+	defer cm.persistMu.Unlock()
+	return cm.store.SaveDues(cm.ledger.Dues())
+}
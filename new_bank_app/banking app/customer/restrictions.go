@@ -0,0 +1,116 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/money"
+	"strconv"
+	"time"
+)
+
+// FreezeAccount freezes an account against debits, credits or both, or
+// lifts the freeze with account.FreezeNone.
+func (cm *CustomerManager) FreezeAccount(p *auth.Principal, accountID int, freeze account.Freeze, reason string) error {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	acc, err := cm.restrictableAccount(p, accountID)
+	if err != nil {
+		return err
+	}
+	before := restrictionFields(acc)
+	if err := acc.SetFreeze(freeze, reason); err != nil {
+		return err
+	}
+	if err := cm.saveAccounts(acc); err != nil {
+		return err
+	}
+	return cm.recordAudit(p, audit.ActionFreezeSet, "account", accountID, before, restrictionFields(acc))
+}
+
+// PlaceLien holds amount of an account's balance for reason until
+// expiresAt, or until it is released if expiresAt is zero.
+func (cm *CustomerManager) PlaceLien(p *auth.Principal, accountID int, amount money.Money, reason string, expiresAt time.Time) (account.Lien, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	acc, err := cm.restrictableAccount(p, accountID)
+	if err != nil {
+		return account.Lien{}, err
+	}
+	if !expiresAt.IsZero() {
+		expiresAt = expiresAt.UTC()
+	}
+	lien, err := acc.PlaceLien(account.Lien{
+		Amount:    amount,
+		Reason:    reason,
+		PlacedBy:  p.CustomerID(),
+		PlacedAt:  cm.now().UTC(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return account.Lien{}, err
+	}
+	if err := cm.saveAccounts(acc); err != nil {
+		return account.Lien{}, err
+	}
+	return lien, cm.recordAudit(p, audit.ActionLienPlaced, "account", accountID, nil, lienFields(lien))
+}
+
+// ReleaseLien releases an active lien, making what it held available again.
+func (cm *CustomerManager) ReleaseLien(p *auth.Principal, accountID, lienID int) (account.Lien, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	acc, err := cm.restrictableAccount(p, accountID)
+	if err != nil {
+		return account.Lien{}, err
+	}
+	lien, err := acc.ReleaseLien(lienID, p.CustomerID(), cm.now().UTC())
+	if err != nil {
+		return account.Lien{}, err
+	}
+	if err := cm.saveAccounts(acc); err != nil {
+		return account.Lien{}, err
+	}
+	after := lienFields(lien)
+	after["released_at"] = lien.ReleasedAt.Format(time.RFC3339)
+	return lien, cm.recordAudit(p, audit.ActionLienReleased, "account", accountID, nil, after)
+}
+
+// restrictableAccount expects cm.mu to be held.
+func (cm *CustomerManager) restrictableAccount(p *auth.Principal, accountID int) (*account.Account, error) {
+	acc, err := cm.findOpenAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cm.authorize(p, auth.PermRestrictAccounts, acc.BankID); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+func restrictionFields(acc *account.Account) map[string]string {
+	s := acc.Snapshot()
+	fields := map[string]string{"freeze": string(s.Freeze)}
+	if s.Freeze == account.FreezeNone {
+		fields["freeze"] = "none"
+	}
+	if s.FreezeReason != "" {
+		fields["reason"] = s.FreezeReason
+	}
+	return fields
+}
+
+func lienFields(l account.Lien) map[string]string {
+	fields := map[string]string{
+		"lien_id": strconv.Itoa(l.LienID),
+		"amount":  l.Amount.String(),
+		"reason":  l.Reason,
+	}
+	if !l.ExpiresAt.IsZero() {
+		fields["expires_at"] = l.ExpiresAt.Format(time.RFC3339)
+	}
+	return fields
+}
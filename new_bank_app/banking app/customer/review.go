@@ -0,0 +1,253 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/money"
+	"banking-app/risk"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SetRiskEngine replaces the engine every external transfer is screened
+// by. A nil engine allows everything.
+func (cm *CustomerManager) SetRiskEngine(engine *risk.Engine) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.risk = engine
+}
+
+// screenTransfer reports held when the transfer went to review, where it
+// moves only once approved, and otherwise returns nil when it may move now.
+// A retry under the idempotency key of a transfer already screened gets
+// that transfer's outcome instead of being screened again, and is held, so
+// that a transfer approved in review does not move a second time. Keys
+// expire with the idempotency cache.
+func (cm *CustomerManager) screenTransfer(p *auth.Principal, customerID int, amount money.Money, fromAcc, toAcc *account.Account, toCustomerID int, idempotencyKey string) (held bool, err error) {
+	cm.reviewMu.Lock()
+	defer cm.reviewMu.Unlock()
+
+	if idempotencyKey != "" {
+		for _, r := range cm.reviews {
+			if r.CustomerID != customerID || r.IdempotencyKey != idempotencyKey || !cm.idempotency.Live(r.HeldAt) {
+				continue
+			}
+			if r.FromAccountID != fromAcc.AccountID || r.ToAccountID != toAcc.AccountID || r.ToCustomerID != toCustomerID || r.Amount != amount {
+				return true, apperror.NewValidationError("idempotencyKey", fmt.Sprintf("%q was already used for a different request", idempotencyKey))
+			}
+			return true, reviewOutcome(r)
+		}
+	}
+
+	cm.mu.RLock()
+	engine := cm.risk
+	now := cm.now().UTC()
+	cm.mu.RUnlock()
+	if engine == nil {
+		return false, nil
+	}
+	assessment, err := engine.Evaluate(risk.Transfer{
+		CustomerID:    customerID,
+		FromAccountID: fromAcc.AccountID,
+		ToAccountID:   toAcc.AccountID,
+		Amount:        amount,
+		At:            now,
+		OpenedAt:      fromAcc.OpenedAt,
+		History:       fromAcc.GetPassbook(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	switch assessment.Decision {
+	case risk.Block:
+		after := map[string]string{
+			"amount":        amount.String(),
+			"to_account_id": strconv.Itoa(toAcc.AccountID),
+			"findings":      findingsField(assessment.Findings),
+		}
+		if err := cm.recordAudit(p, audit.ActionTransferBlocked, "account", fromAcc.AccountID, nil, after); err != nil {
+			return false, err
+		}
+		return false, apperror.NewBlockedError("transfer", findingsField(assessment.Findings))
+	case risk.Hold:
+		r := &risk.Review{
+			ReviewID:       cm.reviewCounter + 1,
+			CustomerID:     customerID,
+			ToCustomerID:   toCustomerID,
+			FromAccountID:  fromAcc.AccountID,
+			ToAccountID:    toAcc.AccountID,
+			FromBankID:     fromAcc.BankID,
+			Amount:         amount,
+			IdempotencyKey: idempotencyKey,
+			Findings:       assessment.Findings,
+			Status:         risk.ReviewPending,
+			HeldAt:         now,
+		}
+		if err := cm.store.SaveReview(*r); err != nil {
+			return false, err
+		}
+		cm.reviewCounter = r.ReviewID
+		cm.reviews[r.ReviewID] = r
+		if err := cm.recordAudit(p, audit.ActionTransferHeld, "review", r.ReviewID, nil, reviewFields(r)); err != nil {
+			return true, err
+		}
+		return true, reviewOutcome(r)
+	}
+	return false, nil
+}
+
+// reviewOutcome is what the transfer behind r returns to its sender: nil
+// once it has been approved and moved.
+func reviewOutcome(r *risk.Review) error {
+	switch r.Status {
+	case risk.ReviewApproved:
+		return nil
+	case risk.ReviewRejected:
+		return apperror.NewBlockedError("transfer", "rejected in review "+strconv.Itoa(r.ReviewID))
+	}
+	return apperror.NewHeldForReviewError("transfer", r.ReviewID)
+}
+
+// TransferReviews lists the reviews in status, or all of them when status
+// is empty, oldest first. Bank-scoped reviewers see their banks' only.
+func (cm *CustomerManager) TransferReviews(p *auth.Principal, status risk.ReviewStatus) ([]risk.Review, error) {
+	cm.mu.RLock()
+	viewer, err := cm.authorize(p, auth.PermReviewTransfers)
+	cm.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	cm.reviewMu.Lock()
+	defer cm.reviewMu.Unlock()
+	var reviews []risk.Review
+	for _, r := range cm.reviews {
+		if (status == "" || r.Status == status) && viewer.inScope(r.FromBankID) {
+			reviews = append(reviews, *r)
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].ReviewID < reviews[j].ReviewID })
+	return reviews, nil
+}
+
+func (cm *CustomerManager) TransferReview(p *auth.Principal, reviewID int) (risk.Review, error) {
+	cm.reviewMu.Lock()
+	defer cm.reviewMu.Unlock()
+	r, err := cm.authorizeReview(p, reviewID)
+	if err != nil {
+		return risk.Review{}, err
+	}
+	return *r, nil
+}
+
+// ApproveTransfer moves a held transfer, as it stood when it was held. If
+// it cannot move now, for example because the balance has since fallen, the
+// review stays pending.
+func (cm *CustomerManager) ApproveTransfer(p *auth.Principal, reviewID int, note string) (risk.Review, error) {
+	cm.reviewMu.Lock()
+	defer cm.reviewMu.Unlock()
+
+	r, err := cm.authorizePendingReview(p, reviewID)
+	if err != nil {
+		return risk.Review{}, err
+	}
+	fromAcc, err := cm.openAccount(r.FromAccountID)
+	if err != nil {
+		return risk.Review{}, err
+	}
+	toAcc, err := cm.openAccount(r.ToAccountID)
+	if err != nil {
+		return risk.Review{}, err
+	}
+	// Once the money has moved the review is approved even if saving the
+	// move failed, or approving it again would move it twice.
+	moveErr := cm.moveExternal(p, r.Amount, fromAcc, toAcc, r.CustomerID, r.ToCustomerID)
+	var moved movedError
+	if moveErr != nil && !errors.As(moveErr, &moved) {
+		return risk.Review{}, moveErr
+	}
+	decided, err := cm.decideReview(p, r, risk.ReviewApproved, note, audit.ActionTransferApproved)
+	if err == nil {
+		err = moveErr
+	}
+	return decided, err
+}
+
+func (cm *CustomerManager) RejectTransfer(p *auth.Principal, reviewID int, note string) (risk.Review, error) {
+	cm.reviewMu.Lock()
+	defer cm.reviewMu.Unlock()
+
+	r, err := cm.authorizePendingReview(p, reviewID)
+	if err != nil {
+		return risk.Review{}, err
+	}
+	return cm.decideReview(p, r, risk.ReviewRejected, note, audit.ActionTransferRejected)
+}
+
+// decideReview expects cm.reviewMu to be held.
+func (cm *CustomerManager) decideReview(p *auth.Principal, r *risk.Review, status risk.ReviewStatus, note string, action audit.Action) (risk.Review, error) {
+	before := reviewFields(r)
+	decided := *r
+	decided.Status = status
+	decided.DecidedBy = p.CustomerID()
+	decided.DecidedAt = cm.clockNow().UTC()
+	decided.Note = strings.TrimSpace(note)
+	if err := cm.store.SaveReview(decided); err != nil {
+		return risk.Review{}, err
+	}
+	*r = decided
+	return decided, cm.recordAudit(p, action, "review", r.ReviewID, before, reviewFields(r))
+}
+
+// authorizeReview expects cm.reviewMu to be held.
+func (cm *CustomerManager) authorizeReview(p *auth.Principal, reviewID int) (*risk.Review, error) {
+	r := cm.reviews[reviewID]
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	if _, err := cm.authorize(p, auth.PermReviewTransfers); err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, apperror.NewNotFoundError("review", reviewID)
+	}
+	if _, err := cm.authorize(p, auth.PermReviewTransfers, r.FromBankID); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (cm *CustomerManager) authorizePendingReview(p *auth.Principal, reviewID int) (*risk.Review, error) {
+	r, err := cm.authorizeReview(p, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != risk.ReviewPending {
+		return nil, apperror.NewValidationError("review", "review "+strconv.Itoa(reviewID)+" was already "+string(r.Status))
+	}
+	return r, nil
+}
+
+func reviewFields(r *risk.Review) map[string]string {
+	return map[string]string{
+		"status":          string(r.Status),
+		"amount":          r.Amount.String(),
+		"from_account_id": strconv.Itoa(r.FromAccountID),
+		"to_account_id":   strconv.Itoa(r.ToAccountID),
+		"findings":        findingsField(r.Findings),
+		"note":            r.Note,
+	}
+}
+
+func findingsField(findings []risk.Finding) string {
+	reasons := make([]string, 0, len(findings))
+	for _, f := range findings {
+		reasons = append(reasons, f.Rule+": "+f.Reason)
+	}
+	return strings.Join(reasons, "; ")
+}
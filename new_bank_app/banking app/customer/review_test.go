@@ -0,0 +1,93 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/beneficiary"
+	"banking-app/clock"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryOfApprovedTransferDoesNotPayTwice(t *testing.T) {
+	cm, admin := newTestManager(t)
+	if err := cm.SetBeneficiaryPolicy(beneficiary.Policy{}); err != nil {
+		t.Fatal(err)
+	}
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	shruti, _ := newTestCustomer(t, cm, admin, "Shruti")
+	from := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	to := newTestAccount(t, cm, admin, shruti, bob, account.ProductSavings, 1000)
+	payee, err := cm.AddBeneficiary(p, to.Number, "Shruti")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The account is new, so the default rules hold the transfer.
+	var held *apperror.HeldForReviewError
+	err = cm.TransferToBeneficiary(p, from.AccountID, payee.BeneficiaryID, rupees(2000), "rent-oct")
+	if !errors.As(err, &held) {
+		t.Fatalf("transfer from a new account: err = %v, want HeldForReviewError", err)
+	}
+	wantBalance(t, from, rupees(10000))
+	if _, err := cm.ApproveTransfer(admin, held.ReviewID, "known payee"); err != nil {
+		t.Fatal(err)
+	}
+	wantBalance(t, from, rupees(8000))
+	wantBalance(t, to, rupees(3000))
+
+	for i := 0; i < 2; i++ {
+		if err := cm.TransferToBeneficiary(p, from.AccountID, payee.BeneficiaryID, rupees(2000), "rent-oct"); err != nil {
+			t.Fatalf("retry %d after approval: %v", i+1, err)
+		}
+		wantBalance(t, from, rupees(8000))
+		wantBalance(t, to, rupees(3000))
+	}
+	dues := cm.GetLedger().Dues()
+	if len(dues) != 1 || dues[0].Amount != rupees(2000) {
+		t.Errorf("dues = %+v, want SBI owing Bank of Baroda INR 2000.00 once", dues)
+	}
+}
+
+func TestExpiredKeyDoesNotResolveToAnOldReview(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sim := clock.NewSimulated(time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC))
+	cm.SetClock(sim.Now)
+	if err := cm.SetIdempotencyTTL(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.SetBeneficiaryPolicy(beneficiary.Policy{}); err != nil {
+		t.Fatal(err)
+	}
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	shruti, _ := newTestCustomer(t, cm, admin, "Shruti")
+	from := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	to := newTestAccount(t, cm, admin, shruti, bob, account.ProductSavings, 1000)
+	payee, err := cm.AddBeneficiary(p, to.Number, "Shruti")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second *apperror.HeldForReviewError
+	if err := cm.TransferToBeneficiary(p, from.AccountID, payee.BeneficiaryID, rupees(2000), "rent"); !errors.As(err, &first) {
+		t.Fatalf("transfer from a new account: err = %v, want HeldForReviewError", err)
+	}
+	if _, err := cm.ApproveTransfer(admin, first.ReviewID, "known payee"); err != nil {
+		t.Fatal(err)
+	}
+	sim.Advance(2 * time.Hour)
+
+	// The account is still new, so the transfer sent again is held afresh.
+	if err := cm.TransferToBeneficiary(p, from.AccountID, payee.BeneficiaryID, rupees(2000), "rent"); !errors.As(err, &second) {
+		t.Fatalf("transfer under an expired key: err = %v, want HeldForReviewError", err)
+	}
+	if second.ReviewID == first.ReviewID {
+		t.Errorf("transfer under an expired key resolved to review %d again", first.ReviewID)
+	}
+	wantBalance(t, from, rupees(8000))
+}
@@ -0,0 +1,212 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"fmt"
+	"sort"
+)
+
+// authorize resolves the principal to an active user whose role grants perm
+// at every bank in bankIDs. It expects cm.mu to be held by the caller.
+func (cm *CustomerManager) authorize(p *auth.Principal, perm auth.Permission, bankIDs ...int) (*Customer, error) {
+	if err := cm.tokens.Check(p); err != nil {
+		return nil, err
+	}
+	c := cm.customers[p.CustomerID()]
+	if c == nil || !c.IsActive {
+		return nil, apperror.NewAuthError("act as an inactive or unknown user")
+	}
+	if !c.Role.Can(perm) {
+		return nil, apperror.NewForbiddenError(fmt.Sprintf("use %s as %s", perm, c.Role))
+	}
+	for _, bankID := range bankIDs {
+		if !c.inScope(bankID) {
+			return nil, apperror.NewForbiddenError(fmt.Sprintf("use %s at bank %d", perm, bankID))
+		}
+	}
+	return c, nil
+}
+
+// authorizeCustomerView lets customers look at themselves and staff with
+// PermViewCustomers look at anyone. It expects cm.mu to be held.
+func (cm *CustomerManager) authorizeCustomerView(p *auth.Principal, customerID int) (*Customer, error) {
+	if self, err := cm.requireCustomer(p); err == nil && self.CustomerID == customerID {
+		return self, nil
+	}
+	return cm.authorize(p, auth.PermViewCustomers)
+}
+
+// targetPermission escalates perm to PermManageStaff when the target is a
+// staff member, so onboarding roles cannot edit their colleagues.
+func targetPermission(target *Customer, perm auth.Permission) auth.Permission {
+	if target != nil && target.Role.IsStaff() {
+		return auth.PermManageStaff
+	}
+	return perm
+}
+
+// authorizeOver authorizes perm over target, escalated by targetPermission,
+// at every bank where target holds an account, open or closed, so that
+// bank-scoped staff only act on their own banks' customers. A customer who
+// holds no account yet belongs to no bank, so only staff who serve every
+// bank may act on them. It expects cm.mu to be held.
+func (cm *CustomerManager) authorizeOver(p *auth.Principal, target *Customer, perm auth.Permission) (*Customer, error) {
+	var bankIDs []int
+	if target != nil {
+		for _, acc := range cm.heldAccounts(target.CustomerID) {
+			bankIDs = append(bankIDs, acc.BankID)
+		}
+	}
+	perm = targetPermission(target, perm)
+	c, err := cm.authorize(p, perm, bankIDs...)
+	if err != nil {
+		return nil, err
+	}
+	if len(bankIDs) == 0 && c.Role.IsBankScoped() {
+		return nil, apperror.NewForbiddenError(fmt.Sprintf("use %s on a customer of no bank", perm))
+	}
+	return c, nil
+}
+
+func (c *Customer) inScope(bankID int) bool {
+	if !c.Role.IsBankScoped() {
+		return true
+	}
+	for _, id := range c.BankIDs {
+		if id == bankID {
+			return true
+		}
+	}
+	return false
+}
+
+// viewFor copies c with only the accounts viewer may see.
+func (c *Customer) viewFor(viewer *Customer) Customer {
+	view := *c
+	view.BankIDs = append([]int(nil), c.BankIDs...)
+	view.Accounts = make(map[int]*account.Account, len(c.Accounts))
+	for id, acc := range c.Accounts {
+		if viewer.inScope(acc.BankID) {
+			view.Accounts[id] = acc
+		}
+	}
+	return view
+}
+
+// CreateStaff adds a staff member. Bank-scoped roles must be given the banks
+// they operate; the other roles apply to every bank and take none.
+func (cm *CustomerManager) CreateStaff(p *auth.Principal, firstName, lastName string, role auth.Role, bankIDs ...int) (*Customer, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.authorize(p, auth.PermManageStaff); err != nil {
+		return nil, err
+	}
+	if err := cm.validateStaffRole(role, bankIDs); err != nil {
+		return nil, err
+	}
+	c := &Customer{
+		CustomerID: cm.generateCustomerID(),
+		FirstName:  TrimAndValidateName(firstName),
+		LastName:   TrimAndValidateName(lastName),
+		Role:       role,
+		BankIDs:    append([]int(nil), bankIDs...),
+		IsActive:   true,
+		Accounts:   make(map[int]*account.Account),
+	}
+	if err := cm.saveCustomer(c); err != nil {
+		return nil, err
+	}
+	cm.customers[c.CustomerID] = c
+	if err := cm.recordAudit(p, audit.ActionStaffCreated, "customer", c.CustomerID, nil, c.auditFields()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (cm *CustomerManager) UpdateStaffRole(p *auth.Principal, staffID int, role auth.Role, bankIDs ...int) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if _, err := cm.authorize(p, auth.PermManageStaff); err != nil {
+		return err
+	}
+	c := cm.customers[staffID]
+	if c == nil || !c.IsActive || !c.Role.IsStaff() {
+		return apperror.NewNotFoundError("staff member", staffID)
+	}
+	if err := cm.validateStaffRole(role, bankIDs); err != nil {
+		return err
+	}
+	if err := cm.keepSuperAdmin(c, role); err != nil {
+		return err
+	}
+	before := c.auditFields()
+	c.Role = role
+	c.BankIDs = append([]int(nil), bankIDs...)
+	if err := cm.saveCustomer(c); err != nil {
+		return err
+	}
+	return cm.recordAudit(p, audit.ActionStaffRoleChanged, "customer", staffID, before, c.auditFields())
+}
+
+// SuperAdminID returns the lowest ID among the active super-admins, which is
+// the one NewCustomerManager created on an empty store.
+func (cm *CustomerManager) SuperAdminID() int {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	ids := cm.superAdminIDs()
+	if len(ids) == 0 {
+		return 0
+	}
+	return ids[0]
+}
+
+// superAdminIDs expects cm.mu to be held, or the manager not yet shared.
+func (cm *CustomerManager) superAdminIDs() []int {
+	var ids []int
+	for id, c := range cm.customers {
+		if c.IsActive && c.Role == auth.RoleSuperAdmin {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// keepSuperAdmin refuses to let the last active super-admin lose that role,
+// either by deactivation (newRole empty) or by a role change.
+func (cm *CustomerManager) keepSuperAdmin(c *Customer, newRole auth.Role) error {
+	if c.Role != auth.RoleSuperAdmin || newRole == auth.RoleSuperAdmin {
+		return nil
+	}
+	if len(cm.superAdminIDs()) <= 1 {
+		return apperror.NewValidationError("role", "the last super-admin cannot be removed or demoted")
+	}
+	return nil
+}
+
+func (cm *CustomerManager) validateStaffRole(role auth.Role, bankIDs []int) error {
+	if _, err := auth.ParseRole(string(role)); err != nil {
+		return err
+	}
+	if !role.IsStaff() {
+		return apperror.NewValidationError("role", "customers are created with CreateNewCustomer")
+	}
+	if role.IsBankScoped() && len(bankIDs) == 0 {
+		return apperror.NewValidationError("bank_ids", fmt.Sprintf("role %s must be assigned at least one bank", role))
+	}
+	if !role.IsBankScoped() && len(bankIDs) > 0 {
+		return apperror.NewValidationError("bank_ids", fmt.Sprintf("role %s applies to every bank and takes none", role))
+	}
+	for _, bankID := range bankIDs {
+		if cm.banks[bankID] == nil {
+			return apperror.NewNotFoundError("bank", bankID)
+		}
+	}
+	return nil
+}
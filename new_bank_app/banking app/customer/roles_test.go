@@ -0,0 +1,158 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/auth"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBankScopedStaffOnlyActOnTheirBanksCustomers(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+	riya, _ := newTestCustomer(t, cm, admin, "Riya")
+	newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 1000)
+
+	teller, err := cm.CreateStaff(admin, "Bo", "Teller", auth.RoleTeller, bob.BankID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.SetPassword(admin, teller.CustomerID, testPassword); err != nil {
+		t.Fatal(err)
+	}
+	_, p, err := cm.Login(teller.CustomerID, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var forbidden *apperror.ForbiddenError
+	if err := cm.SetPassword(p, riya.CustomerID, "Stolen@1"); !errors.As(err, &forbidden) {
+		t.Errorf("SetPassword on another bank's customer: err = %v, want ForbiddenError", err)
+	}
+	if _, _, err := cm.Login(riya.CustomerID, "Stolen@1"); err == nil {
+		t.Error("password set by another bank's teller works")
+	}
+	if err := cm.UpdateCustomer(p, riya.CustomerID, "Mallory", ""); !errors.As(err, &forbidden) {
+		t.Errorf("UpdateCustomer on another bank's customer: err = %v, want ForbiddenError", err)
+	}
+	if err := cm.DeleteCustomer(p, riya.CustomerID); !errors.As(err, &forbidden) {
+		t.Errorf("DeleteCustomer on another bank's customer: err = %v, want ForbiddenError", err)
+	}
+
+	shruti, _ := newTestCustomer(t, cm, admin, "Shruti")
+	newTestAccount(t, cm, admin, shruti, bob, account.ProductSavings, 1000)
+	if err := cm.SetPassword(p, shruti.CustomerID, "Reset@12"); err != nil {
+		t.Errorf("SetPassword on own bank's customer: %v", err)
+	}
+	if err := cm.UpdateCustomer(p, shruti.CustomerID, "", "Sahu"); err != nil {
+		t.Errorf("UpdateCustomer on own bank's customer: %v", err)
+	}
+
+	// A customer without accounts is no bank's to manage.
+	meera, err := cm.CreateNewCustomer(admin, "Meera", "Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.SetPassword(p, meera.CustomerID, "Stolen@1"); !errors.As(err, &forbidden) {
+		t.Errorf("SetPassword on a customer of no bank: err = %v, want ForbiddenError", err)
+	}
+	operator, err := cm.CreateStaff(admin, "Bo", "Operator", auth.RoleBankOperator, bob.BankID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.SetPassword(admin, operator.CustomerID, testPassword); err != nil {
+		t.Fatal(err)
+	}
+	_, op, err := cm.Login(operator.CustomerID, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.RecordDeath(op, meera.CustomerID, time.Now().AddDate(0, 0, -1)); !errors.As(err, &forbidden) {
+		t.Errorf("RecordDeath on a customer of no bank: err = %v, want ForbiddenError", err)
+	}
+	if err := cm.SetPassword(admin, meera.CustomerID, "Reset@12"); err != nil {
+		t.Errorf("SetPassword by an admin on a customer of no bank: %v", err)
+	}
+}
+
+func TestAccountReadsNeedAHolderOrTheAccountsBank(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+	riya, holder := newTestCustomer(t, cm, admin, "Riya")
+	_, stranger := newTestCustomer(t, cm, admin, "Shruti")
+	acc := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 1000)
+
+	teller, err := cm.CreateStaff(admin, "Bo", "Teller", auth.RoleTeller, bob.BankID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.SetPassword(admin, teller.CustomerID, testPassword); err != nil {
+		t.Fatal(err)
+	}
+	_, otherBank, err := cm.Login(teller.CustomerID, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, who := range []struct {
+		name string
+		p    *auth.Principal
+		ok   bool
+	}{
+		{"the holder", holder, true},
+		{"an admin", admin, true},
+		{"another customer", stranger, false},
+		{"another bank's teller", otherBank, false},
+	} {
+		balance, err := cm.GetAccount_BalanceBy_Id(who.p, acc.AccountID)
+		byNumber, numberErr := cm.GetAccountByNumber(who.p, acc.Number)
+		if !who.ok {
+			var forbidden *apperror.ForbiddenError
+			if !errors.As(err, &forbidden) || !errors.As(numberErr, &forbidden) {
+				t.Errorf("%s: balance err = %v, by number err = %v; want ForbiddenError", who.name, err, numberErr)
+			}
+			continue
+		}
+		if err != nil || balance != rupees(1000) {
+			t.Errorf("%s: balance = %s, %v; want %s", who.name, balance, err, rupees(1000))
+		}
+		if numberErr != nil || byNumber.AccountID != acc.AccountID {
+			t.Errorf("%s: by number = %v, %v; want account %d", who.name, byNumber, numberErr, acc.AccountID)
+		}
+	}
+}
+
+func TestPasswordChangesEndExistingSessions(t *testing.T) {
+	cm, admin := newTestManager(t)
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	if err := cm.SetPassword(admin, riya.CustomerID, "Reset@12"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.authorizeCustomer(p); err == nil {
+		t.Error("session still works after the password was reset")
+	}
+
+	_, p, err := cm.Login(riya.CustomerID, "Reset@12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := cm.Login(riya.CustomerID, "Reset@12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.ChangePassword(p, "Reset@12", testPassword); err != nil {
+		t.Fatal(err)
+	}
+	for _, session := range []*auth.Principal{p, other} {
+		if err := cm.authorizeCustomer(session); err == nil {
+			t.Error("session still works after the password was changed")
+		}
+	}
+	if _, _, err := cm.Login(riya.CustomerID, testPassword); err != nil {
+		t.Errorf("login with the new password: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package customer
+
+import (
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"fmt"
+)
+
+func (cm *CustomerManager) SetTokenIssuer(tokens *auth.TokenIssuer) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.tokens = tokens
+}
+
+// SetPassword lets onboarding staff assign a password or PIN to a customer,
+// for example when the customer is onboarded or has forgotten theirs, and
+// logs the customer out everywhere. Bank-scoped staff may only do so for
+// customers of their banks. Staff passwords can only be set by those who
+// manage staff.
+func (cm *CustomerManager) SetPassword(p *auth.Principal, customerID int, password string) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	c := cm.customers[customerID]
+	if _, err := cm.authorizeOver(p, c, auth.PermOnboardCustomers); err != nil {
+		return err
+	}
+	if _, err := cm.lookupCustomer(customerID); err != nil {
+		return err
+	}
+	if err := cm.storeCredential(customerID, password); err != nil {
+		return err
+	}
+	cm.tokens.RevokeCustomer(customerID)
+	return cm.recordAudit(p, audit.ActionPasswordSet, "customer", customerID, nil, nil)
+}
+
+// ChangePassword replaces the caller's password and ends all of their
+// sessions, the one it was called from included.
+func (cm *CustomerManager) ChangePassword(p *auth.Principal, oldPassword, newPassword string) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if err := cm.tokens.Check(p); err != nil {
+		return err
+	}
+	if !auth.VerifyPassword(cm.credentials[p.CustomerID()], oldPassword) {
+		return apperror.NewAuthError("change password with a wrong current password")
+	}
+	if err := cm.storeCredential(p.CustomerID(), newPassword); err != nil {
+		return err
+	}
+	cm.tokens.RevokeCustomer(p.CustomerID())
+	return cm.recordAudit(p, audit.ActionPasswordChanged, "customer", p.CustomerID(), nil, nil)
+}
+
+func (cm *CustomerManager) storeCredential(customerID int, password string) error {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := cm.store.SaveCredential(customerID, hash); err != nil {
+		return err
+	}
+	cm.credentials[customerID] = hash
+	return nil
+}
+
+// Login checks the customer's password and starts a session. The returned
+// token is what clients present on later requests.
+func (cm *CustomerManager) Login(customerID int, password string) (string, *auth.Principal, error) {
+	cm.mu.RLock()
+	c := cm.customers[customerID]
+	hash, hasCredential := cm.credentials[customerID]
+	tokens := cm.tokens
+	cm.mu.RUnlock()
+
+	if c == nil || !c.IsActive || !hasCredential || !auth.VerifyPassword(hash, password) {
+		return "", nil, apperror.NewAuthError(fmt.Sprintf("log in as customer %d", customerID))
+	}
+	return tokens.Issue(customerID)
+}
+
+func (cm *CustomerManager) Authenticate(token string) (*auth.Principal, error) {
+	cm.mu.RLock()
+	tokens := cm.tokens
+	cm.mu.RUnlock()
+	return tokens.Verify(token)
+}
+
+func (cm *CustomerManager) Logout(p *auth.Principal) error {
+	cm.mu.RLock()
+	tokens := cm.tokens
+	cm.mu.RUnlock()
+	if err := tokens.Check(p); err != nil {
+		return err
+	}
+	tokens.Revoke(p)
+	return nil
+}
+
+func (cm *CustomerManager) authorizeCustomer(p *auth.Principal) error {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	_, err := cm.requireCustomer(p)
+	return err
+}
+
+// requireCustomer resolves the principal to an active customer acting on
+// their own accounts. It expects cm.mu to be held by the caller.
+func (cm *CustomerManager) requireCustomer(p *auth.Principal) (*Customer, error) {
+	return cm.authorize(p, auth.PermOperateOwnAccounts)
+}
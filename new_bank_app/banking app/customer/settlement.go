@@ -0,0 +1,219 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/ledger"
+	"banking-app/money"
+	"strconv"
+)
+
+// Reconciliation ties a bank's interbank position in one currency to its
+// passbooks. Every transfer that crossed banks is either settled or still
+// outstanding, so Settled plus Outstanding has to equal PassbookNet.
+type Reconciliation struct {
+	BankID   int
+	Currency string
+	// Settled is what the bank received, less what it paid, over every
+	// settled batch.
+	Settled money.Money
+	// Outstanding is receivable less owed in the open window.
+	Outstanding money.Money
+	// PassbookNet is transfers from other banks into the bank's accounts,
+	// less transfers out to other banks, whoever holds the other account. A transfer converted on its way out
+	// counts in the currency it arrived in.
+	PassbookNet money.Money
+	Balanced    bool
+}
+
+// SettleInterbank closes the settlement window and archives the batch. It
+// holds persistMu throughout so that no transfer can save dues between the
+// ledger being cleared and the batch being stored.
+func (cm *CustomerManager) SettleInterbank(p *auth.Principal) (ledger.SettlementBatch, error) {
+	cm.mu.RLock()
+	_, err := cm.authorize(p, auth.PermSettle)
+	closedAt := cm.now().UTC()
+	cm.mu.RUnlock()
+	if err != nil {
+		return ledger.SettlementBatch{}, err
+	}
+
+	cm.persistMu.Lock()
+	batch, err := cm.ledger.Settle(closedAt, cm.store.SaveSettlement)
+	if err == nil {
+		err = cm.postSettlement(batch)
+	}
+	if err == nil {
+		err = cm.flushJournal()
+	}
+	cm.persistMu.Unlock()
+	if err != nil {
+		return ledger.SettlementBatch{}, err
+	}
+	return batch, cm.recordAudit(p, audit.ActionSettlement, "settlement", batch.BatchID, nil, map[string]string{
+		"dues":     strconv.Itoa(len(batch.Dues)),
+		"payments": strconv.Itoa(len(batch.Payments)),
+	})
+}
+
+// SettlementBatches lists the archived batches, oldest first. Bank-scoped
+// viewers only see the parts of each batch that involve their banks.
+func (cm *CustomerManager) SettlementBatches(p *auth.Principal) ([]ledger.SettlementBatch, error) {
+	cm.mu.RLock()
+	viewer, err := cm.authorize(p, auth.PermViewLedger)
+	cm.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	batches := cm.ledger.Batches()
+	for i := range batches {
+		batches[i] = viewer.scopeBatch(batches[i])
+	}
+	return batches, nil
+}
+
+func (cm *CustomerManager) SettlementBatch(p *auth.Principal, batchID int) (ledger.SettlementBatch, error) {
+	cm.mu.RLock()
+	viewer, err := cm.authorize(p, auth.PermViewLedger)
+	cm.mu.RUnlock()
+	if err != nil {
+		return ledger.SettlementBatch{}, err
+	}
+	batch, err := cm.ledger.Batch(batchID)
+	if err != nil {
+		return ledger.SettlementBatch{}, err
+	}
+	return viewer.scopeBatch(batch), nil
+}
+
+// ReconcileBankPosition compares the bank's settled and outstanding
+// interbank amounts in currency with the cross-bank transfers in its
+// passbooks. A transfer committing while this runs can show up as a passing
+// difference.
+func (cm *CustomerManager) ReconcileBankPosition(p *auth.Principal, bankID int, currency string) (Reconciliation, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if _, err := cm.authorize(p, auth.PermViewLedger, bankID); err != nil {
+		return Reconciliation{}, err
+	}
+	if _, err := cm.lookupBank(bankID); err != nil {
+		return Reconciliation{}, err
+	}
+	currency, err := money.ParseCurrency(currency)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+
+	rec := Reconciliation{BankID: bankID, Currency: currency}
+	if rec.Settled, err = cm.ledger.SettledNet(bankID, currency); err != nil {
+		return Reconciliation{}, err
+	}
+	receivable, owed := money.Zero(currency), money.Zero(currency)
+	for _, d := range cm.ledger.Dues() {
+		if d.Amount.Currency != currency {
+			continue
+		}
+		switch bankID {
+		case d.ToBankID:
+			receivable, err = receivable.Add(d.Amount)
+		case d.FromBankID:
+			owed, err = owed.Add(d.Amount)
+		}
+		if err != nil {
+			return Reconciliation{}, err
+		}
+	}
+	if rec.Outstanding, err = receivable.Sub(owed); err != nil {
+		return Reconciliation{}, err
+	}
+	if rec.PassbookNet, err = cm.passbookInterbankNet(bankID, currency); err != nil {
+		return Reconciliation{}, err
+	}
+
+	expected, err := rec.Settled.Add(rec.Outstanding)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+	cmp, err := expected.Cmp(rec.PassbookNet)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+	rec.Balanced = cmp == 0
+	return rec, nil
+}
+
+// passbookInterbankNet walks every account ever opened at bankID, closed
+// ones included, since their transfers were settled all the same. It
+// expects cm.mu to be held.
+func (cm *CustomerManager) passbookInterbankNet(bankID int, currency string) (money.Money, error) {
+	net := money.Zero(currency)
+	for _, c := range cm.customers {
+		for _, acc := range c.Accounts {
+			if acc.BankID != bankID {
+				continue
+			}
+			for _, txn := range acc.GetPassbook() {
+				// A transfer between one customer's accounts at two banks
+				// crosses banks like any other, and a closed account paid
+				// out into another bank sent its balance the same way; cash
+				// payouts have no counterparty.
+				var in bool
+				switch txn.Type {
+				case account.TxnExternalTransferIn, account.TxnInternalTransferIn:
+					in = true
+				case account.TxnExternalTransferOut, account.TxnInternalTransferOut, account.TxnClosure:
+				default:
+					continue
+				}
+				counterparty, err := account.GetAccountById(txn.CounterpartyAccountID)
+				if err != nil || counterparty.BankID == bankID {
+					continue
+				}
+				sent := txn.Amount
+				if txn.FX != nil && !in {
+					sent = txn.CounterpartyAmount
+				}
+				if sent.Currency != currency {
+					continue
+				}
+				if in {
+					net, err = net.Add(sent)
+				} else {
+					net, err = net.Sub(sent)
+				}
+				if err != nil {
+					return money.Money{}, err
+				}
+			}
+		}
+	}
+	return net, nil
+}
+
+// scopeBatch trims a batch to the dues, positions and payments that involve
+// the viewer's banks.
+func (c *Customer) scopeBatch(batch ledger.SettlementBatch) ledger.SettlementBatch {
+	if !c.Role.IsBankScoped() {
+		return batch
+	}
+	scoped := batch
+	scoped.Dues, scoped.NetPositions, scoped.Payments = nil, nil, nil
+	for _, d := range batch.Dues {
+		if c.inScope(d.FromBankID) || c.inScope(d.ToBankID) {
+			scoped.Dues = append(scoped.Dues, d)
+		}
+	}
+	for _, pos := range batch.NetPositions {
+		if c.inScope(pos.BankID) {
+			scoped.NetPositions = append(scoped.NetPositions, pos)
+		}
+	}
+	for _, pay := range batch.Payments {
+		if c.inScope(pay.FromBankID) || c.inScope(pay.ToBankID) {
+			scoped.Payments = append(scoped.Payments, pay)
+		}
+	}
+	return scoped
+}
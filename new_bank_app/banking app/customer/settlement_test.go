@@ -0,0 +1,142 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/auth"
+	"banking-app/beneficiary"
+	"banking-app/closure"
+	"banking-app/fx"
+	"banking-app/money"
+	"testing"
+)
+
+func TestReconcileBankPositionAfterEachTransferType(t *testing.T) {
+	type setup struct {
+		cm                  *CustomerManager
+		p                   *auth.Principal
+		from, sameBank      *account.Account
+		otherBank, otherUSD *account.Account
+		theirs              *account.Account
+	}
+	tests := []struct {
+		name string
+		move func(s setup) error
+		// inr and usd are what SBI's side of the move comes to.
+		inr, usd money.Money
+	}{
+		{
+			name: "internal within a bank",
+			move: func(s setup) error {
+				return s.cm.TransferMoneyInternally(s.p, s.from.AccountID, s.sameBank.Number, rupees(2000))
+			},
+			inr: rupees(0), usd: money.Zero(money.USD),
+		},
+		{
+			name: "internal to another bank",
+			move: func(s setup) error {
+				return s.cm.TransferMoneyInternally(s.p, s.from.AccountID, s.otherBank.Number, rupees(2000))
+			},
+			inr: rupees(-2000), usd: money.Zero(money.USD),
+		},
+		{
+			name: "internal from another bank",
+			move: func(s setup) error {
+				return s.cm.TransferMoneyInternally(s.p, s.otherBank.AccountID, s.from.Number, rupees(500))
+			},
+			inr: rupees(500), usd: money.Zero(money.USD),
+		},
+		{
+			name: "internal converted on its way to another bank",
+			move: func(s setup) error {
+				return s.cm.TransferMoneyInternally(s.p, s.from.AccountID, s.otherUSD.Number, rupees(8000))
+			},
+			inr: rupees(0), usd: money.MustFromMajor(-100, money.USD),
+		},
+		{
+			name: "to a beneficiary at another bank",
+			move: func(s setup) error {
+				payee, err := s.cm.AddBeneficiary(s.p, s.theirs.Number, "Shruti")
+				if err != nil {
+					return err
+				}
+				return s.cm.TransferToBeneficiary(s.p, s.from.AccountID, payee.BeneficiaryID, rupees(700))
+			},
+			inr: rupees(-700), usd: money.Zero(money.USD),
+		},
+		{
+			name: "closure paid out into another bank",
+			move: func(s setup) error {
+				_, err := s.cm.CloseAccount(s.p, s.from.AccountID, closure.Payout{Method: closure.MethodTransfer, ToAccountNumber: s.otherBank.Number})
+				return err
+			},
+			inr: rupees(-10000), usd: money.Zero(money.USD),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cm, admin := newTestManager(t)
+			cm.SetRiskEngine(nil)
+			if err := cm.SetBeneficiaryPolicy(beneficiary.Policy{}); err != nil {
+				t.Fatal(err)
+			}
+			rates, err := fx.NewStatic(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := rates.Set(money.USD, money.INR, 80*fx.RateScale); err != nil {
+				t.Fatal(err)
+			}
+			cm.SetRateProvider(rates)
+			sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+			bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+			riya, p := newTestCustomer(t, cm, admin, "Riya")
+			shruti, _ := newTestCustomer(t, cm, admin, "Shruti")
+			otherUSD, err := cm.CreateAccountForCustomer(admin, riya.CustomerID, bob.BankID, 0, money.USD, account.ProductSavings, money.MustFromMajor(10, money.USD))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := setup{
+				cm:        cm,
+				p:         p,
+				from:      newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000),
+				sameBank:  newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 1000),
+				otherBank: newTestAccount(t, cm, admin, riya, bob, account.ProductSavings, 1000),
+				otherUSD:  otherUSD,
+				theirs:    newTestAccount(t, cm, admin, shruti, bob, account.ProductSavings, 1000),
+			}
+			if err := tc.move(s); err != nil {
+				t.Fatal(err)
+			}
+
+			check := func(stage string, settled bool) {
+				t.Helper()
+				for _, want := range []money.Money{tc.inr, tc.usd} {
+					for _, side := range []struct {
+						bankID int
+						net    money.Money
+					}{{sbi.BankID, want}, {bob.BankID, want.Neg()}} {
+						rec, err := cm.ReconcileBankPosition(admin, side.bankID, want.Currency)
+						if err != nil {
+							t.Fatal(err)
+						}
+						wantSettled, wantOutstanding := money.Zero(want.Currency), side.net
+						if settled {
+							wantSettled, wantOutstanding = side.net, money.Zero(want.Currency)
+						}
+						if !rec.Balanced || rec.PassbookNet.Amount != side.net.Amount || rec.Settled.Amount != wantSettled.Amount || rec.Outstanding.Amount != wantOutstanding.Amount {
+							t.Errorf("%s: bank %d in %s: %+v, want %s settled and %s outstanding", stage, side.bankID, want.Currency, rec, wantSettled, wantOutstanding)
+						}
+					}
+				}
+			}
+			check("before settling", false)
+			if tc.inr.IsZero() && tc.usd.IsZero() {
+				return
+			}
+			if _, err := cm.SettleInterbank(admin); err != nil {
+				t.Fatal(err)
+			}
+			check("after settling", true)
+		})
+	}
+}
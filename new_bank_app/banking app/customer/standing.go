@@ -0,0 +1,320 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/auth"
+	"banking-app/eod"
+	"banking-app/standing"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CreateStandingInstruction sets up a scheduled transfer from one of the
+// caller's accounts, either to another of their accounts or to one of their
+// beneficiaries. It first runs on the first scheduled day on or after the
+// start date, which cannot be in the past.
+func (cm *CustomerManager) CreateStandingInstruction(p *auth.Principal, spec standing.Spec) (standing.Instruction, error) {
+	spec.Name = strings.TrimSpace(spec.Name)
+	if err := spec.Validate(); err != nil {
+		return standing.Instruction{}, err
+	}
+	spec.Schedule.StartDate = eod.BusinessDate(spec.Schedule.StartDate)
+	if !spec.Schedule.EndDate.IsZero() {
+		spec.Schedule.EndDate = eod.BusinessDate(spec.Schedule.EndDate)
+	}
+
+	cm.mu.RLock()
+	_, err := cm.requireCustomer(p)
+	if err == nil {
+		err = cm.checkStandingAccounts(p.CustomerID(), spec)
+	}
+	now := cm.now().UTC()
+	cm.mu.RUnlock()
+	if err != nil {
+		return standing.Instruction{}, err
+	}
+	if spec.Schedule.StartDate.Before(eod.BusinessDate(now)) {
+		return standing.Instruction{}, apperror.NewValidationError("startDate", "cannot be in the past")
+	}
+
+	cm.standingMu.Lock()
+	defer cm.standingMu.Unlock()
+	in := standing.New(spec, cm.standingCounter+1, p.CustomerID(), now)
+	if err := cm.store.SaveInstruction(in); err != nil {
+		return standing.Instruction{}, err
+	}
+	cm.standingCounter = in.InstructionID
+	cm.instructions[in.InstructionID] = &in
+	if err := cm.recordAudit(p, audit.ActionStandingCreated, "standing_instruction", in.InstructionID, nil, instructionFields(&in)); err != nil {
+		return standing.Instruction{}, err
+	}
+	return in, nil
+}
+
+// checkStandingAccounts expects cm.mu to be held.
+func (cm *CustomerManager) checkStandingAccounts(customerID int, spec standing.Spec) error {
+	from, err := cm.ownOpenAccount(customerID, spec.FromAccountID)
+	if err != nil {
+		return err
+	}
+	if err := requireSoleMandate(from); err != nil {
+		return err
+	}
+	if spec.BeneficiaryID != 0 {
+		_, err := cm.beneficiaryOf(customerID, spec.BeneficiaryID)
+		return err
+	}
+	if spec.ToAccountID == spec.FromAccountID {
+		return apperror.NewValidationError("toAccountID", "source and target accounts must differ")
+	}
+	_, err = cm.ownOpenAccount(customerID, spec.ToAccountID)
+	return err
+}
+
+// ownOpenAccount reports accounts customerID does not hold as not found. It
+// expects cm.mu to be held.
+func (cm *CustomerManager) ownOpenAccount(customerID, accountID int) (*account.Account, error) {
+	acc, err := cm.findOpenAccount(accountID)
+	if err != nil || !acc.IsHolder(customerID) {
+		return nil, apperror.NewNotFoundError("account", accountID)
+	}
+	return acc, nil
+}
+
+// StandingInstructions lists the caller's instructions, including those
+// that have completed or been cancelled.
+func (cm *CustomerManager) StandingInstructions(p *auth.Principal) ([]standing.Instruction, error) {
+	if err := cm.authorizeCustomer(p); err != nil {
+		return nil, err
+	}
+	cm.standingMu.Lock()
+	defer cm.standingMu.Unlock()
+
+	var list []standing.Instruction
+	for _, in := range cm.instructions {
+		if in.CustomerID == p.CustomerID() {
+			list = append(list, *in)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].InstructionID < list[j].InstructionID })
+	return list, nil
+}
+
+func (cm *CustomerManager) StandingInstruction(p *auth.Principal, instructionID int) (standing.Instruction, error) {
+	if err := cm.authorizeCustomer(p); err != nil {
+		return standing.Instruction{}, err
+	}
+	cm.standingMu.Lock()
+	defer cm.standingMu.Unlock()
+
+	in, err := cm.ownInstruction(p, instructionID)
+	if err != nil {
+		return standing.Instruction{}, err
+	}
+	return *in, nil
+}
+
+// StandingExecutions is the history of an instruction's runs, oldest first,
+// including retries and failures.
+func (cm *CustomerManager) StandingExecutions(p *auth.Principal, instructionID int) ([]standing.Execution, error) {
+	if err := cm.authorizeCustomer(p); err != nil {
+		return nil, err
+	}
+	cm.standingMu.Lock()
+	defer cm.standingMu.Unlock()
+
+	if _, err := cm.ownInstruction(p, instructionID); err != nil {
+		return nil, err
+	}
+	return append([]standing.Execution(nil), cm.executions[instructionID]...), nil
+}
+
+func (cm *CustomerManager) CancelStandingInstruction(p *auth.Principal, instructionID int) (standing.Instruction, error) {
+	return cm.changeInstruction(p, instructionID, audit.ActionStandingCancelled, func(in *standing.Instruction, now time.Time) error {
+		if in.Status != standing.StatusActive && in.Status != standing.StatusSuspended {
+			return apperror.NewValidationError("instruction", fmt.Sprintf("instruction %d is already %s", in.InstructionID, in.Status))
+		}
+		in.Status = standing.StatusCancelled
+		return nil
+	})
+}
+
+// ResumeStandingInstruction reactivates an instruction suspended after
+// repeated failures. Runs missed while it was suspended are skipped.
+func (cm *CustomerManager) ResumeStandingInstruction(p *auth.Principal, instructionID int) (standing.Instruction, error) {
+	return cm.changeInstruction(p, instructionID, audit.ActionStandingResumed, func(in *standing.Instruction, now time.Time) error {
+		if in.Status != standing.StatusSuspended {
+			return apperror.NewValidationError("instruction", fmt.Sprintf("instruction %d is %s, not suspended", in.InstructionID, in.Status))
+		}
+		in.Resume(now)
+		return nil
+	})
+}
+
+func (cm *CustomerManager) changeInstruction(p *auth.Principal, instructionID int, action audit.Action, change func(in *standing.Instruction, now time.Time) error) (standing.Instruction, error) {
+	if err := cm.authorizeCustomer(p); err != nil {
+		return standing.Instruction{}, err
+	}
+	now := cm.clockNow().UTC()
+	cm.standingMu.Lock()
+	defer cm.standingMu.Unlock()
+
+	in, err := cm.ownInstruction(p, instructionID)
+	if err != nil {
+		return standing.Instruction{}, err
+	}
+	changed := *in
+	if err := change(&changed, now); err != nil {
+		return standing.Instruction{}, err
+	}
+	if err := cm.store.SaveInstruction(changed); err != nil {
+		return standing.Instruction{}, err
+	}
+	before := instructionFields(in)
+	*in = changed
+	if err := cm.recordAudit(p, action, "standing_instruction", instructionID, before, instructionFields(in)); err != nil {
+		return standing.Instruction{}, err
+	}
+	return *in, nil
+}
+
+// ownInstruction reports other customers' instructions as not found. It
+// expects cm.standingMu to be held.
+func (cm *CustomerManager) ownInstruction(p *auth.Principal, instructionID int) (*standing.Instruction, error) {
+	in := cm.instructions[instructionID]
+	if in == nil || in.CustomerID != p.CustomerID() {
+		return nil, apperror.NewNotFoundError("standing instruction", instructionID)
+	}
+	return in, nil
+}
+
+// RunStandingInstructions runs every instruction that is due by the
+// manager's clock, as the system on behalf of its customer.
+func (cm *CustomerManager) RunStandingInstructions(p *auth.Principal) ([]standing.Execution, error) {
+	if err := cm.authorizeEndOfDay(p); err != nil {
+		return nil, err
+	}
+	return cm.runDueInstructions()
+}
+
+// StartStandingInstructionScheduler runs due instructions every interval
+// until the returned scheduler is stopped. Failures to record a run go to
+// onError; failed transfers are only recorded in the instruction's history.
+func (cm *CustomerManager) StartStandingInstructionScheduler(interval time.Duration, onError func(error)) *eod.Scheduler {
+	return eod.Start(interval, func() {
+		if _, err := cm.runDueInstructions(); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
+
+// runDueInstructions runs instructions in ID order. An instruction that
+// missed runs, for example while the executor was stopped, makes them up in
+// order.
+func (cm *CustomerManager) runDueInstructions() ([]standing.Execution, error) {
+	cm.standingMu.Lock()
+	defer cm.standingMu.Unlock()
+
+	ids := make([]int, 0, len(cm.instructions))
+	for id := range cm.instructions {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var executions []standing.Execution
+	for _, id := range ids {
+		in := cm.instructions[id]
+		for {
+			now := cm.clockNow().UTC()
+			if !in.DueAt(now) {
+				break
+			}
+			e, err := cm.execute(in, now)
+			if err != nil {
+				return executions, err
+			}
+			executions = append(executions, e)
+		}
+	}
+	return executions, nil
+}
+
+// execute expects cm.standingMu to be held. A run whose money moved counts
+// as succeeded even if saving or auditing the move failed, and the run is
+// kept before it is saved, so a store that keeps failing cannot make every
+// tick pay the same due date again.
+func (cm *CustomerManager) execute(in *standing.Instruction, now time.Time) (standing.Execution, error) {
+	updated := *in
+	runErr := cm.runInstruction(in)
+	outcome := runErr
+	var moved movedError
+	if errors.As(runErr, &moved) {
+		outcome = nil
+	}
+	e := updated.Record(now, outcome)
+	if runErr != nil {
+		e.Error = runErr.Error()
+	}
+	e.Sequence = len(cm.executions[in.InstructionID]) + 1
+	cm.executions[in.InstructionID] = append(cm.executions[in.InstructionID], e)
+	before := instructionFields(in)
+	suspended := in.Status != standing.StatusSuspended && updated.Status == standing.StatusSuspended
+	*in = updated
+	if err := cm.store.AppendExecution(e); err != nil {
+		return standing.Execution{}, err
+	}
+	if err := cm.store.SaveInstruction(updated); err != nil {
+		return standing.Execution{}, err
+	}
+	if suspended {
+		if err := cm.recordAudit(nil, audit.ActionStandingSuspended, "standing_instruction", in.InstructionID, before, instructionFields(in)); err != nil {
+			return standing.Execution{}, err
+		}
+	}
+	return e, nil
+}
+
+func (cm *CustomerManager) runInstruction(in *standing.Instruction) error {
+	cm.mu.RLock()
+	c := cm.customers[in.CustomerID]
+	active := c != nil && c.IsActive
+	cm.mu.RUnlock()
+	if !active {
+		return apperror.NewNotFoundError("customer", in.CustomerID)
+	}
+	from, err := account.GetAccountById(in.FromAccountID)
+	if err != nil {
+		return err
+	}
+	if err := requireSoleMandate(from); err != nil {
+		return err
+	}
+	if in.BeneficiaryID != 0 {
+		return cm.payBeneficiary(nil, in.CustomerID, in.FromAccountID, in.BeneficiaryID, in.Amount, "")
+	}
+	return cm.moveInternally(nil, in.CustomerID, in.FromAccountID, in.ToAccountID, in.Amount)
+}
+
+func instructionFields(in *standing.Instruction) map[string]string {
+	fields := map[string]string{
+		"status":          string(in.Status),
+		"from_account_id": strconv.Itoa(in.FromAccountID),
+		"amount":          in.Amount.String(),
+		"frequency":       string(in.Schedule.Frequency),
+	}
+	if in.BeneficiaryID != 0 {
+		fields["beneficiary_id"] = strconv.Itoa(in.BeneficiaryID)
+	} else {
+		fields["to_account_id"] = strconv.Itoa(in.ToAccountID)
+	}
+	if in.Name != "" {
+		fields["name"] = in.Name
+	}
+	return fields
+}
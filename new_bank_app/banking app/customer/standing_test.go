@@ -0,0 +1,66 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/clock"
+	"banking-app/repository"
+	"banking-app/standing"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStandingRunIsNotRepeatedWhenSavingItFails(t *testing.T) {
+	store := &flakyStore{MemoryStore: repository.NewMemoryStore()}
+	cm, admin := newTestManagerOn(t, store)
+	sim := clock.NewSimulated(time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC))
+	cm.SetClock(sim.Now)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	savings := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	current := newTestAccount(t, cm, admin, riya, sbi, account.ProductCurrent, 0)
+
+	for i, tc := range []struct {
+		name string
+		fail *atomic.Bool
+	}{
+		{"accounts down", &store.failAccounts},
+		{"executions down", &store.failExecutions},
+	} {
+		in, err := cm.CreateStandingInstruction(p, standing.Spec{
+			FromAccountID: savings.AccountID,
+			ToAccountID:   current.AccountID,
+			Amount:        rupees(1000),
+			Schedule:      standing.Schedule{Frequency: standing.Weekly, StartDate: sim.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tc.fail.Store(true)
+		for tick := 0; tick < 3; tick++ {
+			cm.RunStandingInstructions(admin)
+		}
+		tc.fail.Store(false)
+		want := int64(1000 * (i + 1))
+		wantBalance(t, current, rupees(want))
+		wantBalance(t, savings, rupees(10000-want))
+
+		got, err := cm.StandingInstruction(p, in.InstructionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next := sim.Now().AddDate(0, 0, 7); !got.NextRunAt.After(sim.Now()) || got.NextRunAt.After(next) {
+			t.Errorf("%s: next run at %s, want within the next week", tc.name, got.NextRunAt)
+		}
+		if got.ConsecutiveFailures != 0 {
+			t.Errorf("%s: %d consecutive failures for a run that paid", tc.name, got.ConsecutiveFailures)
+		}
+		runs, err := cm.StandingExecutions(p, in.InstructionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 1 || runs[0].Outcome != standing.OutcomeSucceeded {
+			t.Errorf("%s: runs = %+v, want one that succeeded", tc.name, runs)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package customer
+
+import (
+	"banking-app/account"
+	"banking-app/money"
+	"testing"
+)
+
+func TestInternalTransferAcrossBanksLeavesADue(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	from := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	to := newTestAccount(t, cm, admin, riya, bob, account.ProductSavings, 1000)
+
+	if err := cm.TransferMoneyInternally(p, from.AccountID, to.Number, rupees(2000)); err != nil {
+		t.Fatal(err)
+	}
+	wantBalance(t, from, rupees(8000))
+	wantBalance(t, to, rupees(3000))
+	dues := cm.GetLedger().Dues()
+	if len(dues) != 1 || dues[0].FromBankID != sbi.BankID || dues[0].ToBankID != bob.BankID || dues[0].Amount != rupees(2000) {
+		t.Errorf("dues = %+v, want SBI owing Bank of Baroda INR 2000.00", dues)
+	}
+}
+
+func TestInternalTransferAcrossBanksReconciles(t *testing.T) {
+	cm, admin := newTestManager(t)
+	sbi := newTestBank(t, cm, admin, "State Bank of India", "SBIN")
+	bob := newTestBank(t, cm, admin, "Bank of Baroda", "BARB")
+	riya, p := newTestCustomer(t, cm, admin, "Riya")
+	from := newTestAccount(t, cm, admin, riya, sbi, account.ProductSavings, 10000)
+	to := newTestAccount(t, cm, admin, riya, bob, account.ProductSavings, 1000)
+
+	if err := cm.TransferMoneyInternally(p, from.AccountID, to.Number, rupees(2000)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		bankID int
+		net    int64
+	}{{sbi.BankID, -2000}, {bob.BankID, 2000}} {
+		rec, err := cm.ReconcileBankPosition(admin, tc.bankID, money.INR)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rec.Balanced || rec.PassbookNet != rupees(tc.net) || rec.Outstanding != rupees(tc.net) {
+			t.Errorf("bank %d: %+v, want outstanding and passbook net of %s", tc.bankID, rec, rupees(tc.net))
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package eod
+
+import (
+	"banking-app/money"
+	"sync"
+	"time"
+)
+
+// Run is the record of one closed business day. Its interest totals are in
+// the bank's base currency, whatever the accounts were in.
+type Run struct {
+	BusinessDate     time.Time
+	CompletedAt      time.Time
+	AccountsAccrued  int
+	InterestAccrued  money.Money
+	AccountsCredited int
+	InterestCredited money.Money
+}
+
+// BusinessDate is the calendar day t falls on, as midnight UTC.
+func BusinessDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func IsMonthEnd(date time.Time) bool {
+	return date.AddDate(0, 0, 1).Day() == 1
+}
+
+// Scheduler calls its job every interval until stopped.
+type Scheduler struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func Start(interval time.Duration, job func()) *Scheduler {
+	s := &Scheduler{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			job()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// Stop waits for a job already running to finish.
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
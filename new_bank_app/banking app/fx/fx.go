@@ -0,0 +1,176 @@
+package fx
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+)
+
+// RateScale is what a Rate counts in: rates carry six decimal places.
+const RateScale = 1000000
+
+// Rate is how many units of one currency buy one unit of another, in
+// millionths.
+type Rate int64
+
+func ParseRate(s string) (Rate, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() <= 0 {
+		return 0, apperror.NewValidationError("rate", fmt.Sprintf("%q is not a positive decimal number", s))
+	}
+	// Round half up to the nearest millionth.
+	r.Mul(r, big.NewRat(2*RateScale, 1))
+	scaled := new(big.Int).Add(r.Num(), r.Denom())
+	scaled.Quo(scaled, new(big.Int).Mul(r.Denom(), big.NewInt(2)))
+	if !scaled.IsInt64() {
+		return 0, apperror.NewValidationError("rate", fmt.Sprintf("%q is too large", s))
+	}
+	if scaled.Sign() == 0 {
+		return 0, apperror.NewValidationError("rate", fmt.Sprintf("%q is too small", s))
+	}
+	return Rate(scaled.Int64()), nil
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d.%06d", r/RateScale, r%RateScale)
+}
+
+// Quote is the price of converting From into To. The customer is charged
+// SpreadBPS below the mid-market rate, so Rate is what a conversion uses.
+type Quote struct {
+	From      string
+	To        string
+	Mid       Rate
+	SpreadBPS int64
+	Rate      Rate
+}
+
+// Identity is the quote for keeping money in currency.
+func Identity(currency string) Quote {
+	return Quote{From: currency, To: currency, Mid: RateScale, Rate: RateScale}
+}
+
+func (q Quote) IsIdentity() bool {
+	return q.From == q.To
+}
+
+// Convert prices amount, which must be in From, in To. Fractions of To's
+// minor unit are kept by the bank.
+func (q Quote) Convert(amount money.Money) (money.Money, error) {
+	if amount.Currency != q.From && !(amount.Currency == "" && amount.IsZero()) {
+		return money.Money{}, apperror.NewValidationError("currency", fmt.Sprintf("cannot convert %s at a %s/%s quote", amount.Currency, q.From, q.To))
+	}
+	if q.IsIdentity() {
+		return amount, nil
+	}
+	return amount.Convert(q.To, int64(q.Rate), RateScale, money.RoundDown)
+}
+
+// Provider prices conversions between currencies.
+type Provider interface {
+	Quote(from, to string) (Quote, error)
+}
+
+// Static is a Provider with fixed mid-market rates and one spread for every
+// pair. A pair set one way is also quoted the other way, at the inverse
+// rate.
+type Static struct {
+	mu        sync.RWMutex
+	spreadBPS int64
+	rates     map[[2]string]Rate
+}
+
+func NewStatic(spreadBPS int64) (*Static, error) {
+	if spreadBPS < 0 || spreadBPS >= 10000 {
+		return nil, apperror.NewValidationError("spreadBPS", "must be at least 0 and below 10000 basis points")
+	}
+	return &Static{spreadBPS: spreadBPS, rates: make(map[[2]string]Rate)}, nil
+}
+
+// Set makes mid the rate for converting from into to.
+func (s *Static) Set(from, to string, mid Rate) error {
+	from, err := money.ParseCurrency(from)
+	if err != nil {
+		return err
+	}
+	if to, err = money.ParseCurrency(to); err != nil {
+		return err
+	}
+	if from == to {
+		return apperror.NewValidationError("currency", fmt.Sprintf("%s converts to itself at 1", from))
+	}
+	if mid <= 0 {
+		return apperror.NewValidationError("rate", "must be positive")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rates[[2]string{from, to}] = mid
+	return nil
+}
+
+func (s *Static) Quote(from, to string) (Quote, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return Identity(from), nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mid, ok := s.rates[[2]string{from, to}]
+	if !ok {
+		inverse, ok := s.rates[[2]string{to, from}]
+		if !ok {
+			return Quote{}, apperror.NewValidationError("currency", fmt.Sprintf("no exchange rate from %s to %s", from, to))
+		}
+		mid = Rate((RateScale*RateScale + int64(inverse)/2) / int64(inverse))
+	}
+	return Quote{
+		From:      from,
+		To:        to,
+		Mid:       mid,
+		SpreadBPS: s.spreadBPS,
+		Rate:      Rate(int64(mid) * (10000 - s.spreadBPS) / 10000),
+	}, nil
+}
+
+type rateFile struct {
+	SpreadBPS int64             `json:"spread_bps"`
+	Rates     map[string]string `json:"rates"`
+}
+
+// LoadFile reads a Static provider from a JSON file such as
+//
+//	{"spread_bps": 50, "rates": {"USD/INR": "83.25", "EUR/INR": "90.10"}}
+func LoadFile(path string) (*Static, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, apperror.NewBankError("load exchange rates", path, err)
+	}
+	var f rateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, apperror.NewBankError("load exchange rates", path, err)
+	}
+	s, err := NewStatic(f.SpreadBPS)
+	if err != nil {
+		return nil, err
+	}
+	for pair, value := range f.Rates {
+		from, to, ok := strings.Cut(pair, "/")
+		if !ok {
+			return nil, apperror.NewValidationError("rates", fmt.Sprintf("%q is not a pair like USD/INR", pair))
+		}
+		mid, err := ParseRate(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Set(from, to, mid); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,167 @@
+package idempotency
+
+import (
+	"banking-app/apperror"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const DefaultTTL = 24 * time.Hour
+
+// Record is a request that completed under a client-supplied key. Keys are
+// scoped to the customer who sent them, so two clients cannot collide.
+type Record struct {
+	CustomerID  int
+	Key         string
+	Fingerprint string
+	CreatedAt   time.Time
+	// Outcome is the error the request returned, empty if it succeeded.
+	Outcome string
+
+	// err is the error itself, which does not survive a restart.
+	err error
+}
+
+// replayed is what a retry of r returns: the same error as the request, or
+// one carrying its message once the error itself has been lost.
+func (r Record) replayed() error {
+	switch {
+	case r.err != nil:
+		return r.err
+	case r.Outcome != "":
+		return apperror.NewBankError("replay request", fmt.Sprintf("the request under %q went through but failed: %s", r.Key, r.Outcome))
+	}
+	return nil
+}
+
+type scope struct {
+	customerID int
+	key        string
+}
+
+// Fingerprint identifies a request by its operation and arguments, so a key
+// reused for a different request can be told apart from a retry.
+func Fingerprint(operation string, args ...interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s%v", operation, args)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Cache remembers completed requests until they are ttl old. A key is held
+// while its request runs, so a retry racing the original is refused instead
+// of running twice.
+type Cache struct {
+	mu       sync.Mutex
+	records  map[scope]Record
+	inFlight map[scope]string
+	ttl      time.Duration
+	now      func() time.Time
+	persist  func(Record) error
+}
+
+func NewCache(ttl time.Duration, persist func(Record) error) *Cache {
+	return &Cache{
+		records:  make(map[scope]Record),
+		inFlight: make(map[scope]string),
+		ttl:      ttl,
+		now:      time.Now,
+		persist:  persist,
+	}
+}
+
+func (c *Cache) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func (c *Cache) SetTTL(ttl time.Duration) error {
+	if ttl <= 0 {
+		return apperror.NewValidationError("ttl", "must be positive")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+	return nil
+}
+
+// Restore loads persisted records. Expired ones are dropped on the next
+// Begin, once the clock and TTL have been configured.
+func (c *Cache) Restore(records []Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range records {
+		c.records[scope{r.CustomerID, r.Key}] = r
+	}
+}
+
+// Begin claims key for a request. It reports replay when the same request
+// already completed under key, in which case it must not run again and err
+// is what the request returned. Every claim that is not a replay has to be
+// released with Finish.
+func (c *Cache) Begin(customerID int, key, fingerprint string) (replay bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire()
+
+	s := scope{customerID, key}
+	if r, ok := c.records[s]; ok {
+		if r.Fingerprint != fingerprint {
+			return false, apperror.NewValidationError("idempotencyKey", fmt.Sprintf("%q was already used for a different request", key))
+		}
+		return true, r.replayed()
+	}
+	if running, ok := c.inFlight[s]; ok {
+		if running != fingerprint {
+			return false, apperror.NewValidationError("idempotencyKey", fmt.Sprintf("%q is in use by a different request", key))
+		}
+		return false, apperror.NewBankError("replay request", fmt.Sprintf("the request under %q is still in progress", key))
+	}
+	c.inFlight[s] = fingerprint
+	return false, nil
+}
+
+// Finish releases key. A request that went through is remembered with the
+// outcome it returned, so a retry under the same key gets that outcome
+// without running again; one that did not is forgotten, so a retry runs
+// afresh. A request is remembered even when its record cannot be persisted,
+// in which case the error says so.
+func (c *Cache) Finish(customerID int, key, fingerprint string, remember bool, outcome error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := scope{customerID, key}
+	delete(c.inFlight, s)
+	if !remember {
+		return nil
+	}
+	r := Record{CustomerID: customerID, Key: key, Fingerprint: fingerprint, CreatedAt: c.now().UTC(), err: outcome}
+	if outcome != nil {
+		r.Outcome = outcome.Error()
+	}
+	c.records[s] = r
+	if err := c.persist(r); err != nil {
+		return apperror.NewBankError("persist idempotency key", fmt.Sprintf("the request under %q went through but its key was not saved", key), err)
+	}
+	return nil
+}
+
+// Live reports whether something created at createdAt under a key is still
+// within the TTL, so that other records kept by key expire with the cache.
+func (c *Cache) Live(createdAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return createdAt.After(c.now().Add(-c.ttl))
+}
+
+// expire expects c.mu to be held.
+func (c *Cache) expire() {
+	cutoff := c.now().Add(-c.ttl)
+	for s, r := range c.records {
+		if !r.CreatedAt.After(cutoff) {
+			delete(c.records, s)
+		}
+	}
+}
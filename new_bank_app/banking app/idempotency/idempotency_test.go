@@ -0,0 +1,54 @@
+package idempotency
+
+import (
+	"banking-app/apperror"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayReturnsTheOutcomeAcrossARestart(t *testing.T) {
+	var saved []Record
+	persist := func(r Record) error {
+		saved = append(saved, r)
+		return nil
+	}
+	c := NewCache(DefaultTTL, persist)
+	failed := apperror.NewBankError("persist", "store unavailable")
+	for _, key := range []string{"ok", "failed"} {
+		if replay, err := c.Begin(7, key, "fp"); replay || err != nil {
+			t.Fatalf("Begin(%q) = %v, %v on a fresh key", key, replay, err)
+		}
+	}
+	if err := c.Finish(7, "ok", "fp", true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Finish(7, "failed", "fp", true, failed); err != nil {
+		t.Fatal(err)
+	}
+	if replay, err := c.Begin(7, "failed", "fp"); !replay || err != failed {
+		t.Errorf("replay = %v, %v, want the original error", replay, err)
+	}
+
+	restarted := NewCache(DefaultTTL, persist)
+	restarted.Restore(saved)
+	if replay, err := restarted.Begin(7, "ok", "fp"); !replay || err != nil {
+		t.Errorf("replay of a success after a restart = %v, %v, want true, nil", replay, err)
+	}
+	replay, err := restarted.Begin(7, "failed", "fp")
+	if !replay || err == nil || !strings.Contains(err.Error(), failed.Error()) {
+		t.Errorf("replay of a failure after a restart = %v, %v, want its message", replay, err)
+	}
+}
+
+func TestLiveFollowsTheTTL(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+	c := NewCache(time.Hour, func(Record) error { return nil })
+	c.SetClock(func() time.Time { return now })
+	if !c.Live(now.Add(-59 * time.Minute)) {
+		t.Error("a key 59 minutes old has expired under a one-hour TTL")
+	}
+	if c.Live(now.Add(-time.Hour)) {
+		t.Error("a key an hour old is still live under a one-hour TTL")
+	}
+}
@@ -0,0 +1,70 @@
+package joint
+
+import (
+	"banking-app/money"
+	"time"
+)
+
+// Operation is the kind of debit an approval is for.
+type Operation string
+
+const (
+	OpWithdrawal          Operation = "withdrawal"
+	OpInternalTransfer    Operation = "internal-transfer"
+	OpBeneficiaryTransfer Operation = "beneficiary-transfer"
+)
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+)
+
+// Approval is a debit from a joint account that needs more than one holder
+// to agree to it. Nothing has moved while it is pending; it moves once
+// Required holders, the one who asked included, have approved it.
+type Approval struct {
+	ApprovalID    int
+	AccountID     int
+	Operation     Operation
+	Amount        money.Money
+	ToAccountID   int
+	BeneficiaryID int
+	RequestedBy   int
+	// IdempotencyKey is the key the debit was asked for with, if any, so a
+	// retry finds this approval instead of opening another.
+	IdempotencyKey string
+	// Required is fixed when the debit is asked for; changing the account's
+	// operating mode later does not change it.
+	Required    int
+	ApprovedBy  []int
+	Status      Status
+	RequestedAt time.Time
+	DecidedBy   int
+	DecidedAt   time.Time
+}
+
+func (a Approval) HasApproved(customerID int) bool {
+	for _, id := range a.ApprovedBy {
+		if id == customerID {
+			return true
+		}
+	}
+	return false
+}
+
+// Outstanding is how many more holders have to approve.
+func (a Approval) Outstanding() int {
+	if n := a.Required - len(a.ApprovedBy); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// SameRequest reports whether b asks for the same debit as a.
+func (a Approval) SameRequest(b Approval) bool {
+	return a.AccountID == b.AccountID && a.Operation == b.Operation && a.Amount == b.Amount &&
+		a.ToAccountID == b.ToAccountID && a.BeneficiaryID == b.BeneficiaryID
+}
@@ -0,0 +1,260 @@
+package journal
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Code names an account in the chart of accounts, such as "customer:1006"
+// or "vault:1002".
+type Code string
+
+// Customer accounts are the bank's liabilities to its depositors. Every
+// other code is kept per bank.
+func Customer(accountID int) Code     { return Code(fmt.Sprintf("customer:%d", accountID)) }
+func Vault(bankID int) Code           { return Code(fmt.Sprintf("vault:%d", bankID)) }
+func Clearing(bankID int) Code        { return Code(fmt.Sprintf("clearing:%d", bankID)) }
+func FeeIncome(bankID int) Code       { return Code(fmt.Sprintf("fee-income:%d", bankID)) }
+func InterestExpense(bankID int) Code { return Code(fmt.Sprintf("interest-expense:%d", bankID)) }
+func InterestPayable(bankID int) Code { return Code(fmt.Sprintf("interest-payable:%d", bankID)) }
+func Adjustments(bankID int) Code     { return Code(fmt.Sprintf("adjustments:%d", bankID)) }
+func FXPosition(bankID int) Code      { return Code(fmt.Sprintf("fx-position:%d", bankID)) }
+func OpeningBalances(bankID int) Code { return Code(fmt.Sprintf("opening-balances:%d", bankID)) }
+
+type Side string
+
+const (
+	Debit  Side = "debit"
+	Credit Side = "credit"
+)
+
+type Line struct {
+	Account Code
+	Side    Side
+	Amount  money.Money
+}
+
+func DebitLine(account Code, amount money.Money) Line {
+	return Line{Account: account, Side: Debit, Amount: amount}
+}
+
+func CreditLine(account Code, amount money.Money) Line {
+	return Line{Account: account, Side: Credit, Amount: amount}
+}
+
+// Entry is one balanced posting. ReferenceID ties it to the passbook
+// transactions it accounts for.
+type Entry struct {
+	EntryID     int
+	ReferenceID string
+	Timestamp   time.Time
+	Memo        string
+	Lines       []Line
+}
+
+// Validate reports whether lines form a balanced entry: every amount is
+// positive and the debits equal the credits in each currency. An entry that
+// converts between currencies balances each side through an FX position
+// account.
+func Validate(lines []Line) error {
+	if len(lines) < 2 {
+		return apperror.NewValidationError("lines", "an entry needs at least one debit and one credit")
+	}
+	debits := make(map[string]money.Money)
+	credits := make(map[string]money.Money)
+	for _, l := range lines {
+		if !l.Amount.IsPositive() {
+			return apperror.NewValidationError("lines", fmt.Sprintf("%s %s must be positive", l.Side, l.Account))
+		}
+		currency := l.Amount.Currency
+		var err error
+		switch l.Side {
+		case Debit:
+			debits[currency], err = debits[currency].Add(l.Amount)
+		case Credit:
+			credits[currency], err = credits[currency].Add(l.Amount)
+		default:
+			return apperror.NewValidationError("lines", fmt.Sprintf("unknown side %q", l.Side))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for currency, debit := range debits {
+		if debit.Amount != credits[currency].Amount {
+			return apperror.NewValidationError("lines", fmt.Sprintf("debits %s do not equal credits %s", debit, credits[currency]))
+		}
+	}
+	for currency, credit := range credits {
+		if _, ok := debits[currency]; !ok {
+			return apperror.NewValidationError("lines", fmt.Sprintf("debits %s do not equal credits %s", money.Zero(currency), credit))
+		}
+	}
+	return nil
+}
+
+// Journal is the append-only general ledger. It keeps a running balance per
+// code and currency, debit-positive, so lookups do not replay the entries.
+type Journal struct {
+	mu       sync.RWMutex
+	entries  []Entry
+	balances map[balanceKey]money.Money
+}
+
+type balanceKey struct {
+	account  Code
+	currency string
+}
+
+func New() *Journal {
+	return &Journal{balances: make(map[balanceKey]money.Money)}
+}
+
+func (j *Journal) Post(e Entry) (Entry, error) {
+	if err := Validate(e.Lines); err != nil {
+		return Entry{}, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e.EntryID = len(j.entries) + 1
+	e.Lines = append([]Line(nil), e.Lines...)
+	if err := j.apply(e); err != nil {
+		return Entry{}, err
+	}
+	j.entries = append(j.entries, e)
+	return e, nil
+}
+
+// Restore replaces the journal with entries loaded from storage.
+func (j *Journal) Restore(entries []Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = nil
+	j.balances = make(map[balanceKey]money.Money)
+	for _, e := range entries {
+		if err := Validate(e.Lines); err != nil {
+			return apperror.NewBankError("restore journal", fmt.Sprintf("entry %d is unbalanced", e.EntryID), err)
+		}
+		if err := j.apply(e); err != nil {
+			return err
+		}
+		j.entries = append(j.entries, e)
+	}
+	return nil
+}
+
+// apply works out every new balance before changing any, so a failed entry
+// leaves the balances untouched. It expects j.mu to be held.
+func (j *Journal) apply(e Entry) error {
+	updated := make(map[balanceKey]money.Money, len(e.Lines))
+	for _, l := range e.Lines {
+		key := balanceKey{l.Account, l.Amount.Currency}
+		balance, ok := updated[key]
+		if !ok {
+			balance = j.balances[key]
+		}
+		var err error
+		if l.Side == Debit {
+			balance, err = balance.Add(l.Amount)
+		} else {
+			balance, err = balance.Sub(l.Amount)
+		}
+		if err != nil {
+			return err
+		}
+		updated[key] = balance
+	}
+	for key, balance := range updated {
+		j.balances[key] = balance
+	}
+	return nil
+}
+
+// Balance is the debit balance of account in currency; credit balances are
+// negative.
+func (j *Journal) Balance(account Code, currency string) money.Money {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.balances[balanceKey{account, currency}]
+}
+
+func (j *Journal) Len() int {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return len(j.entries)
+}
+
+// Since returns the entries posted after the first n.
+func (j *Journal) Since(n int) []Entry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if n >= len(j.entries) {
+		return nil
+	}
+	return append([]Entry(nil), j.entries[n:]...)
+}
+
+type TrialBalanceRow struct {
+	Account Code
+	Debit   money.Money
+	Credit  money.Money
+}
+
+// TrialBalance lists each account's balance in one currency on the side it
+// falls, in code order. TotalDebit equals TotalCredit whenever the journal
+// is sound.
+type TrialBalance struct {
+	Currency    string
+	Rows        []TrialBalanceRow
+	TotalDebit  money.Money
+	TotalCredit money.Money
+}
+
+// TrialBalances draws up a trial balance for every currency the journal
+// holds, in currency order.
+func (j *Journal) TrialBalances() ([]TrialBalance, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	byCurrency := make(map[string]*TrialBalance)
+	for key, balance := range j.balances {
+		if balance.IsZero() {
+			continue
+		}
+		tb := byCurrency[key.currency]
+		if tb == nil {
+			tb = &TrialBalance{Currency: key.currency, TotalDebit: money.Zero(key.currency), TotalCredit: money.Zero(key.currency)}
+			byCurrency[key.currency] = tb
+		}
+		row := TrialBalanceRow{Account: key.account, Debit: money.Zero(key.currency), Credit: money.Zero(key.currency)}
+		var err error
+		if balance.IsPositive() {
+			row.Debit = balance
+			tb.TotalDebit, err = tb.TotalDebit.Add(balance)
+		} else {
+			row.Credit = balance.Neg()
+			tb.TotalCredit, err = tb.TotalCredit.Add(row.Credit)
+		}
+		if err != nil {
+			return nil, err
+		}
+		tb.Rows = append(tb.Rows, row)
+	}
+
+	tbs := make([]TrialBalance, 0, len(byCurrency))
+	for _, tb := range byCurrency {
+		sort.Slice(tb.Rows, func(a, b int) bool { return tb.Rows[a].Account < tb.Rows[b].Account })
+		tbs = append(tbs, *tb)
+	}
+	sort.Slice(tbs, func(a, b int) bool { return tbs[a].Currency < tbs[b].Currency })
+	return tbs, nil
+}
+
+func (tb TrialBalance) Balanced() bool {
+	cmp, err := tb.TotalDebit.Cmp(tb.TotalCredit)
+	return err == nil && cmp == 0
+}
@@ -1,112 +1,224 @@
-package ledger
-
-import (
-	"fmt"
-)
-
-type Ledger struct {
-	balances            map[int]map[int]float64
-	getBankTotalBalance func(bankID int) (float64, error)
-}
-
-func NewLedger(getBalanceFunc func(bankID int) (float64, error)) *Ledger {
-	return &Ledger{
-		balances:            make(map[int]map[int]float64),
-		getBankTotalBalance: getBalanceFunc,
-	}
-}
-
-func (l *Ledger) RecordTransfer(fromBankID, toBankID int, amount float64) error {
-	if fromBankID == toBankID {
-		return fmt.Errorf("invalid transfer: cannot transfer to the same bank (Bank ID: %d)", fromBankID)
-	}
-	if amount <= 0 {
-		return fmt.Errorf("invalid transfer: amount must be positive (Amount: %.2f)", amount)
-	}
-
-	remainingAmount := l.settleOppositeBalance(fromBankID, toBankID, amount)
-
-	if remainingAmount > 0 {
-		l.addTransferBalance(fromBankID, toBankID, remainingAmount)
-	}
-	return nil
-}
-
-func (l *Ledger) OwedAmount(fromBankID, toBankID int) float64 {
-	if _, ok := l.balances[fromBankID]; !ok {
-		return 0
-	}
-	return l.balances[fromBankID][toBankID]
-}
-
-func (l *Ledger) AllBalances() map[int]map[int]float64 {
-	copyMap := make(map[int]map[int]float64, len(l.balances))
-	for from, inner := range l.balances {
-		innerCopy := make(map[int]float64, len(inner))
-		for to, amt := range inner {
-			innerCopy[to] = amt
-		}
-		copyMap[from] = innerCopy
-	}
-	return copyMap
-}
-
-func (l *Ledger) GetNetBankPosition(bankID int) (actualBalance, totalReceivable, totalOwed float64, err error) {
-	totalOwed = l.calculateTotalOwed(bankID)
-	totalReceivable = l.calculateTotalReceivable(bankID)
-
-	actualBalance, err = l.getBankTotalBalance(bankID)
-	if err != nil {
-		return 0, 0, 0, fmt.Errorf("failed to retrieve actual bank balance for Bank ID %d: %w", bankID, err)
-	}
-	return actualBalance, totalReceivable, totalOwed, nil
-}
-
-func (l *Ledger) settleOppositeBalance(fromID, toID int, amount float64) float64 {
-	oppositeAmt, exists := l.balances[toID][fromID]
-	if !exists || oppositeAmt <= 0 {
-		return amount
-	}
-
-	if amount >= oppositeAmt {
-		delete(l.balances[toID], fromID)
-		if len(l.balances[toID]) == 0 {
-			delete(l.balances, toID)
-		}
-		return amount - oppositeAmt
-	} else {
-		l.balances[toID][fromID] -= amount
-		return 0
-	}
-}
-
-func (l *Ledger) addTransferBalance(fromID, toID int, amount float64) {
-	if amount <= 0 {
-		return
-	}
-	if l.balances[fromID] == nil {
-		l.balances[fromID] = make(map[int]float64)
-	}
-	l.balances[fromID][toID] += amount
-}
-
-func (l *Ledger) calculateTotalOwed(fromID int) float64 {
-	var total float64
-	if debts, ok := l.balances[fromID]; ok {
-		for _, amt := range debts {
-			total += amt
-		}
-	}
-	return total
-}
-
-func (l *Ledger) calculateTotalReceivable(toID int) float64 {
-	var total float64
-	for fromID, debts := range l.balances {
-		if fromID == toID {
-			continue
-		}
-		total += debts[toID]
-	}
-	return total
-}
+package ledger
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"banking-app/unitofwork"
+	"fmt"
+	"sync"
+)
+
+// Ledger keeps the interbank dues of each currency apart: dues are netted
+// and settled only against dues in the same currency.
+type Ledger struct {
+	mu sync.RWMutex
+	// balances holds what one bank owes another, by currency, then debtor,
+	// then creditor.
+	balances            map[string]map[int]map[int]money.Money
+	batches             []SettlementBatch
+	getBankTotalBalance func(bankID int, currency string) (money.Money, error)
+}
+
+func NewLedger(getBalanceFunc func(bankID int, currency string) (money.Money, error)) *Ledger {
+	return &Ledger{
+		balances:            make(map[string]map[int]map[int]money.Money),
+		getBankTotalBalance: getBalanceFunc,
+	}
+}
+
+func (l *Ledger) RecordTransfer(fromBankID, toBankID int, amount money.Money) error {
+	uow := unitofwork.New()
+	if err := l.StageTransfer(uow, fromBankID, toBankID, amount); err != nil {
+		return err
+	}
+	return uow.Commit()
+}
+
+// StageTransfer enlists the interbank due for a transfer in uow so that it is
+// recorded only if the rest of the unit of work commits.
+func (l *Ledger) StageTransfer(uow *unitofwork.UnitOfWork, fromBankID, toBankID int, amount money.Money) error {
+	if fromBankID == toBankID {
+		return apperror.NewValidationError("toBankID", fmt.Sprintf("cannot transfer to the same bank (Bank ID: %d)", fromBankID))
+	}
+	if !amount.IsPositive() {
+		return apperror.NewValidationError("amount", fmt.Sprintf("must be positive (Amount: %s)", amount))
+	}
+	uow.Enlist(&stagedTransfer{ledger: l, fromID: fromBankID, toID: toBankID, amount: amount})
+	return nil
+}
+
+type stagedTransfer struct {
+	ledger     *Ledger
+	fromID     int
+	toID       int
+	amount     money.Money
+	newOwed    money.Money
+	newOpposed money.Money
+}
+
+func (t *stagedTransfer) Prepare() error {
+	t.ledger.mu.Lock()
+	var err error
+	t.newOwed, t.newOpposed, err = t.ledger.settleOppositeBalance(t.fromID, t.toID, t.amount)
+	if err != nil {
+		t.ledger.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (t *stagedTransfer) Commit() {
+	t.ledger.setBalance(t.amount.Currency, t.toID, t.fromID, t.newOpposed)
+	t.ledger.setBalance(t.amount.Currency, t.fromID, t.toID, t.newOwed)
+	t.ledger.mu.Unlock()
+}
+
+func (t *stagedTransfer) Abort() {
+	t.ledger.mu.Unlock()
+}
+
+func (l *Ledger) OwedAmount(fromBankID, toBankID int, currency string) money.Money {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.balances[currency][fromBankID][toBankID]
+}
+
+// AllBalances copies the dues by currency, then debtor, then creditor.
+func (l *Ledger) AllBalances() map[string]map[int]map[int]money.Money {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	copyMap := make(map[string]map[int]map[int]money.Money, len(l.balances))
+	for currency, book := range l.balances {
+		bookCopy := make(map[int]map[int]money.Money, len(book))
+		for from, inner := range book {
+			innerCopy := make(map[int]money.Money, len(inner))
+			for to, amt := range inner {
+				innerCopy[to] = amt
+			}
+			bookCopy[from] = innerCopy
+		}
+		copyMap[currency] = bookCopy
+	}
+	return copyMap
+}
+
+type Due struct {
+	FromBankID int
+	ToBankID   int
+	Amount     money.Money
+}
+
+func (l *Ledger) Dues() []Due {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var dues []Due
+	for _, book := range l.balances {
+		for from, inner := range book {
+			for to, amt := range inner {
+				dues = append(dues, Due{FromBankID: from, ToBankID: to, Amount: amt})
+			}
+		}
+	}
+	return dues
+}
+
+// RestoreDues replaces the ledger contents with dues loaded from storage.
+func (l *Ledger) RestoreDues(dues []Due) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.balances = make(map[string]map[int]map[int]money.Money)
+	for _, d := range dues {
+		l.setBalance(d.Amount.Currency, d.FromBankID, d.ToBankID, d.Amount)
+	}
+}
+
+// GetNetBankPosition is the bank's position in one currency.
+func (l *Ledger) GetNetBankPosition(bankID int, currency string) (actualBalance, totalReceivable, totalOwed money.Money, err error) {
+	l.mu.RLock()
+	totalOwed, err = l.calculateTotalOwed(currency, bankID)
+	if err == nil {
+		totalReceivable, err = l.calculateTotalReceivable(currency, bankID)
+	}
+	l.mu.RUnlock()
+	if err != nil {
+		return money.Money{}, money.Money{}, money.Money{}, err
+	}
+
+	// The balance callback takes its own locks, so it runs outside ours.
+
+	actualBalance, err = l.getBankTotalBalance(bankID, currency)
+	if err != nil {
+		return money.Money{}, money.Money{}, money.Money{}, apperror.NewBankError("net position", fmt.Sprintf("failed to retrieve actual bank balance for Bank ID %d", bankID), err)
+	}
+	return actualBalance, totalReceivable, totalOwed, nil
+}
+
+// settleOppositeBalance nets a new transfer against whatever toID already
+// owes fromID and returns the resulting dues in both directions without
+// changing the ledger.
+func (l *Ledger) settleOppositeBalance(fromID, toID int, amount money.Money) (owed, opposite money.Money, err error) {
+	book := l.balances[amount.Currency]
+	owed = book[fromID][toID]
+	opposite = book[toID][fromID]
+
+	remaining, err := amount.Sub(opposite)
+	if err != nil {
+		return money.Money{}, money.Money{}, err
+	}
+	if remaining.IsNegative() {
+		return owed, remaining.Neg(), nil
+	}
+	if owed, err = owed.Add(remaining); err != nil {
+		return money.Money{}, money.Money{}, err
+	}
+	return owed, money.Zero(amount.Currency), nil
+}
+
+func (l *Ledger) setBalance(currency string, fromID, toID int, amount money.Money) {
+	book := l.balances[currency]
+	if !amount.IsPositive() {
+		delete(book[fromID], toID)
+		if len(book[fromID]) == 0 {
+			delete(book, fromID)
+		}
+		if len(book) == 0 {
+			delete(l.balances, currency)
+		}
+		return
+	}
+	if book == nil {
+		book = make(map[int]map[int]money.Money)
+		l.balances[currency] = book
+	}
+	if book[fromID] == nil {
+		book[fromID] = make(map[int]money.Money)
+	}
+	book[fromID][toID] = amount
+}
+
+func (l *Ledger) calculateTotalOwed(currency string, fromID int) (money.Money, error) {
+	total := money.Zero(currency)
+	if debts, ok := l.balances[currency][fromID]; ok {
+		for _, amt := range debts {
+			var err error
+			if total, err = total.Add(amt); err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+	return total, nil
+}
+
+func (l *Ledger) calculateTotalReceivable(currency string, toID int) (money.Money, error) {
+	total := money.Zero(currency)
+	for fromID, debts := range l.balances[currency] {
+		if fromID == toID {
+			continue
+		}
+		var err error
+		if total, err = total.Add(debts[toID]); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,237 @@
+package ledger
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"sort"
+	"time"
+)
+
+// NetPosition is what a bank receives (positive) or pays (negative) in one
+// currency when a batch settles.
+type NetPosition struct {
+	BankID int
+	Net    money.Money
+}
+
+type Payment struct {
+	FromBankID int
+	ToBankID   int
+	Amount     money.Money
+}
+
+// SettlementBatch archives one closed settlement window: the bilateral dues
+// outstanding when it closed, every bank's multilateral net position, and
+// the payments that discharge them.
+type SettlementBatch struct {
+	BatchID      int
+	WindowStart  time.Time
+	ClosedAt     time.Time
+	Dues         []Due
+	NetPositions []NetPosition
+	Payments     []Payment
+}
+
+// Settle closes the current settlement window. The outstanding dues in each
+// currency are netted across all banks at once, so each bank makes or
+// receives a single net amount per currency, and the ledger is cleared for
+// the next window. The batch is handed to save first; if save fails the
+// window stays open with its dues as they were.
+func (l *Ledger) Settle(closedAt time.Time, save func(SettlementBatch) error) (SettlementBatch, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dues := l.sortedDues()
+	if len(dues) == 0 {
+		return SettlementBatch{}, apperror.NewBankError("settlement", "no dues are outstanding")
+	}
+	positions, err := netPositions(dues)
+	if err != nil {
+		return SettlementBatch{}, err
+	}
+	payments, err := settlementPayments(positions)
+	if err != nil {
+		return SettlementBatch{}, err
+	}
+
+	batch := SettlementBatch{
+		BatchID:      len(l.batches) + 1,
+		WindowStart:  l.windowStart(),
+		ClosedAt:     closedAt,
+		Dues:         dues,
+		NetPositions: positions,
+		Payments:     payments,
+	}
+	if err := save(batch); err != nil {
+		return SettlementBatch{}, err
+	}
+	l.batches = append(l.batches, batch)
+	l.balances = make(map[string]map[int]map[int]money.Money)
+	return batch, nil
+}
+
+func (l *Ledger) Batches() []SettlementBatch {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]SettlementBatch(nil), l.batches...)
+}
+
+func (l *Ledger) Batch(batchID int) (SettlementBatch, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if batchID <= 0 || batchID > len(l.batches) {
+		return SettlementBatch{}, apperror.NewNotFoundError("settlement batch", batchID)
+	}
+	return l.batches[batchID-1], nil
+}
+
+// RestoreBatches replaces the settlement history with batches loaded from
+// storage, oldest first.
+func (l *Ledger) RestoreBatches(batches []SettlementBatch) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.batches = append([]SettlementBatch(nil), batches...)
+}
+
+// SettledNet totals what bankID has received in currency, less what it has
+// paid, over every settled batch.
+func (l *Ledger) SettledNet(bankID int, currency string) (money.Money, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	total := money.Zero(currency)
+	for _, batch := range l.batches {
+		for _, pos := range batch.NetPositions {
+			if pos.BankID != bankID || pos.Net.Currency != currency {
+				continue
+			}
+			var err error
+			if total, err = total.Add(pos.Net); err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// windowStart expects l.mu to be held.
+func (l *Ledger) windowStart() time.Time {
+	if len(l.batches) == 0 {
+		return time.Time{}
+	}
+	return l.batches[len(l.batches)-1].ClosedAt
+}
+
+// sortedDues expects l.mu to be held.
+func (l *Ledger) sortedDues() []Due {
+	var dues []Due
+	for _, book := range l.balances {
+		for from, inner := range book {
+			for to, amt := range inner {
+				dues = append(dues, Due{FromBankID: from, ToBankID: to, Amount: amt})
+			}
+		}
+	}
+	sort.Slice(dues, func(i, j int) bool {
+		if dues[i].Amount.Currency != dues[j].Amount.Currency {
+			return dues[i].Amount.Currency < dues[j].Amount.Currency
+		}
+		if dues[i].FromBankID != dues[j].FromBankID {
+			return dues[i].FromBankID < dues[j].FromBankID
+		}
+		return dues[i].ToBankID < dues[j].ToBankID
+	})
+	return dues
+}
+
+type position struct {
+	currency string
+	bankID   int
+}
+
+// netPositions nets the dues per bank and currency, in currency and then
+// BankID order.
+func netPositions(dues []Due) ([]NetPosition, error) {
+	nets := make(map[position]money.Money)
+	for _, d := range dues {
+		from := position{d.Amount.Currency, d.FromBankID}
+		to := position{d.Amount.Currency, d.ToBankID}
+		var err error
+		if nets[from], err = nets[from].Sub(d.Amount); err != nil {
+			return nil, err
+		}
+		if nets[to], err = nets[to].Add(d.Amount); err != nil {
+			return nil, err
+		}
+	}
+	positions := make([]NetPosition, 0, len(nets))
+	for pos, net := range nets {
+		positions = append(positions, NetPosition{BankID: pos.bankID, Net: net})
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Net.Currency != positions[j].Net.Currency {
+			return positions[i].Net.Currency < positions[j].Net.Currency
+		}
+		return positions[i].BankID < positions[j].BankID
+	})
+	return positions, nil
+}
+
+// settlementPayments settles each currency separately.
+func settlementPayments(positions []NetPosition) ([]Payment, error) {
+	var payments []Payment
+	for start := 0; start < len(positions); {
+		end := start
+		for end < len(positions) && positions[end].Net.Currency == positions[start].Net.Currency {
+			end++
+		}
+		settled, err := currencyPayments(positions[start:end])
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, settled...)
+		start = end
+	}
+	return payments, nil
+}
+
+// currencyPayments matches payers to receivers in BankID order. Every
+// payment either clears a payer or fills a receiver, so n banks settle in
+// at most n-1 payments.
+func currencyPayments(positions []NetPosition) ([]Payment, error) {
+	var payers, receivers []NetPosition
+	for _, pos := range positions {
+		switch {
+		case pos.Net.IsNegative():
+			payers = append(payers, NetPosition{BankID: pos.BankID, Net: pos.Net.Neg()})
+		case pos.Net.IsPositive():
+			receivers = append(receivers, pos)
+		}
+	}
+
+	var payments []Payment
+	for i, j := 0, 0; i < len(payers) && j < len(receivers); {
+		amount := payers[i].Net
+		if less, err := receivers[j].Net.LessThan(amount); err != nil {
+			return nil, err
+		} else if less {
+			amount = receivers[j].Net
+		}
+		payments = append(payments, Payment{FromBankID: payers[i].BankID, ToBankID: receivers[j].BankID, Amount: amount})
+
+		var err error
+		if payers[i].Net, err = payers[i].Net.Sub(amount); err != nil {
+			return nil, err
+		}
+		if receivers[j].Net, err = receivers[j].Net.Sub(amount); err != nil {
+			return nil, err
+		}
+		if payers[i].Net.IsZero() {
+			i++
+		}
+		if receivers[j].Net.IsZero() {
+			j++
+		}
+	}
+	return payments, nil
+}
@@ -0,0 +1,185 @@
+package ledger
+
+import (
+	"banking-app/money"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func inr(major int64) money.Money { return money.MustFromMajor(major, money.INR) }
+func usd(major int64) money.Money { return money.MustFromMajor(major, money.USD) }
+
+type transfer struct {
+	from, to int
+	amount   money.Money
+}
+
+func newTestLedger(t *testing.T, transfers ...transfer) *Ledger {
+	t.Helper()
+	l := NewLedger(nil)
+	for _, tr := range transfers {
+		if err := l.RecordTransfer(tr.from, tr.to, tr.amount); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return l
+}
+
+func saveNothing(SettlementBatch) error { return nil }
+
+func TestSettleNetsEveryBankAtOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		transfers []transfer
+		dues      []Due
+		positions []NetPosition
+		payments  []Payment
+	}{
+		{
+			name:      "dues in both directions offset",
+			transfers: []transfer{{1, 2, inr(300)}, {2, 1, inr(100)}},
+			dues:      []Due{{FromBankID: 1, ToBankID: 2, Amount: inr(200)}},
+			positions: []NetPosition{{1, inr(-200)}, {2, inr(200)}},
+			payments:  []Payment{{1, 2, inr(200)}},
+		},
+		{
+			name:      "a cycle nets to nothing",
+			transfers: []transfer{{1, 2, inr(100)}, {2, 3, inr(100)}, {3, 1, inr(100)}},
+			dues: []Due{
+				{FromBankID: 1, ToBankID: 2, Amount: inr(100)},
+				{FromBankID: 2, ToBankID: 3, Amount: inr(100)},
+				{FromBankID: 3, ToBankID: 1, Amount: inr(100)},
+			},
+			positions: []NetPosition{{1, inr(0)}, {2, inr(0)}, {3, inr(0)}},
+		},
+		{
+			name:      "four banks settle in three payments",
+			transfers: []transfer{{1, 2, inr(500)}, {1, 3, inr(300)}, {2, 3, inr(200)}, {4, 1, inr(100)}, {3, 4, inr(50)}},
+			dues: []Due{
+				{FromBankID: 1, ToBankID: 2, Amount: inr(500)},
+				{FromBankID: 1, ToBankID: 3, Amount: inr(300)},
+				{FromBankID: 2, ToBankID: 3, Amount: inr(200)},
+				{FromBankID: 3, ToBankID: 4, Amount: inr(50)},
+				{FromBankID: 4, ToBankID: 1, Amount: inr(100)},
+			},
+			positions: []NetPosition{{1, inr(-700)}, {2, inr(300)}, {3, inr(450)}, {4, inr(-50)}},
+			payments:  []Payment{{1, 2, inr(300)}, {1, 3, inr(400)}, {4, 3, inr(50)}},
+		},
+		{
+			name:      "currencies settle apart",
+			transfers: []transfer{{1, 2, inr(1000)}, {3, 1, usd(50)}, {2, 3, inr(400)}, {2, 1, usd(20)}},
+			dues: []Due{
+				{FromBankID: 1, ToBankID: 2, Amount: inr(1000)},
+				{FromBankID: 2, ToBankID: 3, Amount: inr(400)},
+				{FromBankID: 2, ToBankID: 1, Amount: usd(20)},
+				{FromBankID: 3, ToBankID: 1, Amount: usd(50)},
+			},
+			positions: []NetPosition{{1, inr(-1000)}, {2, inr(600)}, {3, inr(400)}, {1, usd(70)}, {2, usd(-20)}, {3, usd(-50)}},
+			payments:  []Payment{{1, 2, inr(600)}, {1, 3, inr(400)}, {2, 1, usd(20)}, {3, 1, usd(50)}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newTestLedger(t, tc.transfers...)
+			closedAt := time.Date(2025, time.March, 14, 18, 0, 0, 0, time.UTC)
+			batch, err := l.Settle(closedAt, saveNothing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(batch.Dues, tc.dues) {
+				t.Errorf("dues = %v, want %v", batch.Dues, tc.dues)
+			}
+			if !slices.Equal(batch.NetPositions, tc.positions) {
+				t.Errorf("positions = %v, want %v", batch.NetPositions, tc.positions)
+			}
+			if !slices.Equal(batch.Payments, tc.payments) {
+				t.Errorf("payments = %v, want %v", batch.Payments, tc.payments)
+			}
+
+			// Whatever the plan, each bank pays or receives exactly its net
+			// position.
+			paid := make(map[position]money.Money)
+			for _, pay := range batch.Payments {
+				from, to := position{pay.Amount.Currency, pay.FromBankID}, position{pay.Amount.Currency, pay.ToBankID}
+				if paid[from], err = paid[from].Sub(pay.Amount); err != nil {
+					t.Fatal(err)
+				}
+				if paid[to], err = paid[to].Add(pay.Amount); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, pos := range batch.NetPositions {
+				if got := paid[position{pos.Net.Currency, pos.BankID}]; got.Amount != pos.Net.Amount {
+					t.Errorf("bank %d: payments net to %s, position is %s", pos.BankID, got, pos.Net)
+				}
+				settled, err := l.SettledNet(pos.BankID, pos.Net.Currency)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if settled != pos.Net {
+					t.Errorf("bank %d: settled net = %s, want %s", pos.BankID, settled, pos.Net)
+				}
+			}
+			if dues := l.Dues(); len(dues) != 0 {
+				t.Errorf("dues after settling = %v, want none", dues)
+			}
+		})
+	}
+}
+
+func TestSettleArchivesTheClosedWindow(t *testing.T) {
+	l := newTestLedger(t)
+	first := time.Date(2025, time.March, 14, 18, 0, 0, 0, time.UTC)
+	second := first.AddDate(0, 0, 1)
+	if _, err := l.Settle(first, saveNothing); err == nil {
+		t.Fatal("settled a window with no dues")
+	}
+
+	if err := l.RecordTransfer(1, 2, inr(500)); err != nil {
+		t.Fatal(err)
+	}
+	unavailable := errors.New("store unavailable")
+	if _, err := l.Settle(first, func(SettlementBatch) error { return unavailable }); !errors.Is(err, unavailable) {
+		t.Fatalf("err = %v, want the save's", err)
+	}
+	if owed := l.OwedAmount(1, 2, money.INR); owed != inr(500) {
+		t.Errorf("owed after a failed save = %s, want %s", owed, inr(500))
+	}
+	var saved []SettlementBatch
+	save := func(b SettlementBatch) error { saved = append(saved, b); return nil }
+	one, err := l.Settle(first, save)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.RecordTransfer(2, 1, inr(200)); err != nil {
+		t.Fatal(err)
+	}
+	two, err := l.Settle(second, save)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if one.BatchID != 1 || !one.WindowStart.IsZero() || !one.ClosedAt.Equal(first) {
+		t.Errorf("first batch %d covers %s to %s, want batch 1 from the start to %s", one.BatchID, one.WindowStart, one.ClosedAt, first)
+	}
+	if two.BatchID != 2 || !two.WindowStart.Equal(first) || !two.ClosedAt.Equal(second) {
+		t.Errorf("second batch %d covers %s to %s, want batch 2 from %s to %s", two.BatchID, two.WindowStart, two.ClosedAt, first, second)
+	}
+	if len(saved) != 2 || saved[0].BatchID != 1 || saved[1].BatchID != 2 {
+		t.Errorf("saved %d batches, want batches 1 and 2", len(saved))
+	}
+	if got := l.Batches(); len(got) != 2 || got[1].BatchID != 2 {
+		t.Errorf("archived %d batches, want 2", len(got))
+	}
+	if got, err := l.Batch(2); err != nil || !slices.Equal(got.Payments, []Payment{{2, 1, inr(200)}}) {
+		t.Errorf("batch 2 = %+v, %v; want bank 2 paying bank 1 %s", got, err, inr(200))
+	}
+	if _, err := l.Batch(3); err == nil {
+		t.Error("found a batch that was never settled")
+	}
+	if net, err := l.SettledNet(1, money.INR); err != nil || net != inr(-300) {
+		t.Errorf("bank 1 settled net = %s, %v; want %s", net, err, inr(-300))
+	}
+}
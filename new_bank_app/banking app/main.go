@@ -1,140 +1,607 @@
-package main
-
-import (
-	"banking-app/customer"
-	"fmt"
-)
-
-func main() {
-	manager := customer.NewCustomerManager("Pragnesh", "Sheth")
-	fmt.Println("Admin created successfully.")
-
-	bank1, err := manager.CreateNewBank("State Bank of India")
-	if err != nil {
-		fmt.Println("Error creating State Bank of India:", err)
-	}
-
-	bank2, err := manager.CreateNewBank("Bank of Baroda")
-	if err != nil {
-		fmt.Println("Error creating Bank of Baroda:", err)
-	}
-
-	customer1, err := manager.CreateNewCustomer("Riya", "Parekh")
-	if err != nil {
-		fmt.Println("Error creating customer Riya:", err)
-	}
-
-	customer2, err := manager.CreateNewCustomer("Shruti", "Sahu")
-	if err != nil {
-		fmt.Println("Error creating customer Shruti:", err)
-	}
-
-	var acc1ID, acc2ID int
-
-	if customer1 != nil {
-		acc1, err := manager.CreateAccountForCustomer(customer1.CustomerID, bank1.BankID)
-		if err != nil {
-			fmt.Println("Error creating account for Riya:", err)
-		} else {
-			acc1ID = acc1.AccountID
-		}
-	}
-
-	if customer2 != nil {
-		acc2, err := manager.CreateAccountForCustomer(customer2.CustomerID, bank2.BankID)
-		if err != nil {
-			fmt.Println("Error creating account for Shruti:", err)
-		} else {
-			acc2ID = acc2.AccountID
-		}
-	}
-
-	fmt.Println("\n--- All Banks ---")
-	for _, b := range manager.GetAllBanks() {
-		if b.IsActive {
-			fmt.Printf("ID: %d | Name: %s | Abbreviation: %s\n", b.BankID, b.Name, b.Abbreviation)
-		}
-	}
-
-	fmt.Println("\n--- All Customers ---")
-	for _, c := range manager.GetAllCustomers() {
-		role := "Customer"
-		if c.IsAdmin {
-			role = "Admin"
-		}
-		fmt.Printf("ID: %d | Name: %s %s | Role: %s\n", c.CustomerID, c.FirstName, c.LastName, role)
-		for _, acc := range c.Accounts {
-			if acc.IsActive {
-				fmt.Printf("   AccountID: %d | Balance: %.2f | BankID: %d\n", acc.AccountID, acc.Balance, acc.BankID)
-			}
-		}
-	}
-
-	err = manager.UpdateBankName(bank2.BankID, "Bank of Bharat")
-	if err != nil {
-		fmt.Println("Error updating bank name:", err)
-	}
-
-	if customer1 != nil {
-		err = manager.UpdateCustomerNameById(customer1.CustomerID, "Riya", "Sharma")
-		if err != nil {
-			fmt.Println("Error updating customer name:", err)
-		}
-	}
-
-	if acc1ID != 0 {
-		err = manager.DepositMoney(5000, acc1ID)
-		if err != nil {
-			fmt.Println("Error depositing:", err)
-		}
-
-		err = manager.WithDrawMoney(1000, acc1ID)
-		if err != nil {
-			fmt.Println("Error withdrawing:", err)
-		}
-	}
-
-	if acc1ID != 0 && acc2ID != 0 {
-		err = manager.TransferMoney_To_External(1500, customer1.CustomerID, customer2.CustomerID, acc1ID, acc2ID)
-		if err != nil {
-			fmt.Println("Error in interbank transfer:", err)
-		}
-	}
-
-	fmt.Println("\n--- Interbank Ledger Balances ---")
-	allBalances := manager.GetLedger().AllBalances()
-	for fromBankID, debts := range allBalances {
-		for toBankID, amt := range debts {
-			fmt.Printf("Bank %d owes Bank %d: ₹%.2f\n", fromBankID, toBankID, amt)
-		}
-	}
-
-	fmt.Println("\n--- Net Bank Positions ---")
-	for _, bank := range manager.GetAllBanks() {
-		if bank.IsActive {
-			actual, receivable, owed, err := manager.GetLedger().GetNetBankPosition(bank.BankID)
-			if err != nil {
-				fmt.Printf("Error getting net position for Bank ID %d: %v\n", bank.BankID, err)
-				continue
-			}
-			fmt.Printf("Bank ID: %d | Actual: ₹%.2f | Receivable: ₹%.2f | Owed: ₹%.2f\n", bank.BankID, actual, receivable, owed)
-		}
-	}
-
-	fmt.Println("\n--- Updated Banks ---")
-	for _, b := range manager.GetAllBanks() {
-		if b.IsActive {
-			fmt.Printf("ID: %d | Name: %s | Abbreviation: %s\n", b.BankID, b.Name, b.Abbreviation)
-		}
-	}
-
-	fmt.Println("\n--- Updated Customers ---")
-	for _, c := range manager.GetAllCustomers() {
-		fmt.Printf("ID: %d | Name: %s %s\n", c.CustomerID, c.FirstName, c.LastName)
-		for _, acc := range c.Accounts {
-			if acc.IsActive {
-				fmt.Printf("    AccountID: %d | Balance: %.2f | BankID: %d\n", acc.AccountID, acc.Balance, acc.BankID)
-			}
-		}
-	}
-}
+package main
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/auth"
+	"banking-app/bank"
+	"banking-app/beneficiary"
+	"banking-app/clock"
+	"banking-app/closure"
+	"banking-app/customer"
+	"banking-app/fx"
+	"banking-app/money"
+	"banking-app/repository"
+	"banking-app/standing"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+func main() {
+	storePath := flag.String("store", "", "path of the append-only store file (in-memory when empty)")
+	flag.Parse()
+
+	var store repository.Store = repository.NewMemoryStore()
+	if *storePath != "" {
+		fileStore, err := repository.OpenFileStore(*storePath)
+		if err != nil {
+			fmt.Println("Error opening store:", err)
+			return
+		}
+		defer fileStore.Close()
+		store = fileStore
+	}
+
+	manager, err := customer.NewCustomerManager(store, "Pragnesh", "Sheth", "admin@123")
+	if err != nil {
+		fmt.Println("Error creating customer manager:", err)
+		return
+	}
+	fmt.Println("Admin created successfully.")
+
+	// The demo runs on a simulated clock so it can skip ahead to month end.
+	sim := clock.NewSimulated(time.Now())
+	manager.SetClock(sim.Now)
+
+	_, admin, err := manager.Login(manager.SuperAdminID(), "admin@123")
+	if err != nil {
+		fmt.Println("Error logging in as admin:", err)
+		return
+	}
+
+	bank1, err := openBank(manager, admin, "State Bank of India", "SBIN")
+	if err != nil {
+		fmt.Println("Error creating State Bank of India:", err)
+		return
+	}
+
+	bank2, err := openBank(manager, admin, "Bank of Baroda", "BARB")
+	if err != nil {
+		fmt.Println("Error creating Bank of Baroda:", err)
+		return
+	}
+
+	customer1, err := manager.CreateNewCustomer(admin, "Riya", "Parekh")
+	if err != nil {
+		fmt.Println("Error creating customer Riya:", err)
+	}
+
+	customer2, err := manager.CreateNewCustomer(admin, "Shruti", "Sahu")
+	if err != nil {
+		fmt.Println("Error creating customer Shruti:", err)
+	}
+
+	var acc1ID, acc2ID int
+	var acc2Number string
+
+	if customer1 != nil {
+		acc1, err := manager.CreateAccountForCustomer(admin, customer1.CustomerID, bank1.BankID, 0, money.INR, account.ProductSavings, money.MustFromMajor(1000, money.INR))
+		if err != nil {
+			fmt.Println("Error creating account for Riya:", err)
+		} else {
+			acc1ID = acc1.AccountID
+		}
+	}
+
+	if customer2 != nil {
+		acc2, err := manager.CreateAccountForCustomer(admin, customer2.CustomerID, bank2.BankID, 0, money.INR, account.ProductSavings, money.MustFromMajor(1000, money.INR))
+		if err != nil {
+			fmt.Println("Error creating account for Shruti:", err)
+		} else {
+			acc2ID = acc2.AccountID
+			acc2Number = acc2.Number
+		}
+	}
+
+	fmt.Println("\n--- All Banks ---")
+	for _, b := range manager.GetAllBanks() {
+		if b.IsActive {
+			fmt.Printf("ID: %d | Name: %s | Code: %s\n", b.BankID, b.Name, b.Code)
+		}
+	}
+
+	if bank1 != nil {
+		fmt.Println("\n--- Branches ---")
+		if _, err := manager.CreateNewBank(admin, "Stanley India", "sbin"); err != nil {
+			fmt.Println("Duplicate bank code refused:", err)
+		}
+		if _, err := manager.OpenBranch(admin, bank1.BankID, "Ahmedabad Main", "000123"); err != nil {
+			fmt.Println("Error opening branch:", err)
+		}
+		if b, branch, err := manager.GetBranchByRoutingCode("sbin0000123"); err != nil {
+			fmt.Println("Error looking up routing code:", err)
+		} else {
+			fmt.Printf("%s is %s, %s\n", branch.RoutingCode, b.Name, branch.Name)
+		}
+	}
+
+	fmt.Println("\n--- All Customers ---")
+	allCustomers, err := manager.GetAllCustomers(admin)
+	if err != nil {
+		fmt.Println("Error listing customers:", err)
+	}
+	for _, c := range allCustomers {
+		fmt.Printf("ID: %d | Name: %s %s | Role: %s\n", c.CustomerID, c.FirstName, c.LastName, c.Role)
+		for _, acc := range c.Accounts {
+			if acc.IsOpen() {
+				fmt.Printf("   AccountID: %d | %s | %s | Balance: %s | BankID: %d\n", acc.AccountID, acc.Number, acc.Terms.Product, acc.GetBalance(), acc.BankID)
+			}
+		}
+	}
+
+	err = manager.UpdateBankName(admin, bank2.BankID, "Bank of Bharat")
+	if err != nil {
+		fmt.Println("Error updating bank name:", err)
+	}
+
+	if customer1 != nil {
+		err = manager.UpdateCustomerNameById(admin, customer1.CustomerID, "Riya", "Sharma")
+		if err != nil {
+			fmt.Println("Error updating customer name:", err)
+		}
+	}
+
+	if customer1 != nil {
+		if err := manager.SetPassword(admin, customer1.CustomerID, "riya@123"); err != nil {
+			fmt.Println("Error setting Riya's password:", err)
+		}
+	}
+
+	var riya *auth.Principal
+	if customer1 != nil {
+		_, riya, err = manager.Login(customer1.CustomerID, "riya@123")
+		if err != nil {
+			fmt.Println("Error logging in as Riya:", err)
+		}
+	}
+
+	if acc1ID != 0 {
+		err = manager.DepositMoney(riya, money.MustFromMajor(5000, money.INR), acc1ID)
+		if err != nil {
+			fmt.Println("Error depositing:", err)
+		}
+
+		err = manager.WithDrawMoney(riya, money.MustFromMajor(1000, money.INR), acc1ID)
+		if err != nil {
+			fmt.Println("Error withdrawing:", err)
+		}
+
+		err = manager.WithDrawMoney(riya, money.MustFromMajor(1000000, money.INR), acc1ID)
+		if errors.Is(err, apperror.ErrLimitExceeded) {
+			fmt.Println("Large withdrawal refused:", err)
+		}
+	}
+
+	var shruti beneficiary.Beneficiary
+	if acc1ID != 0 && acc2ID != 0 {
+		// Swapping two digits breaks the check digits, so the typo never
+		// reaches another customer's account.
+		typo := []byte(acc2Number)
+		typo[len(typo)-4], typo[len(typo)-3] = typo[len(typo)-3], typo[len(typo)-4]
+		if _, err := manager.AddBeneficiary(riya, string(typo), "Shruti"); err != nil {
+			fmt.Println("Mistyped account number refused:", err)
+		}
+		shruti, err = manager.AddBeneficiary(riya, acc2Number, "Shruti")
+		if err != nil {
+			fmt.Println("Error adding beneficiary:", err)
+		}
+		// A new beneficiary only receives a small amount until it cools off.
+		err = manager.TransferToBeneficiary(riya, acc1ID, shruti.BeneficiaryID, money.MustFromMajor(6000, money.INR))
+		if errors.Is(err, apperror.ErrLimitExceeded) {
+			fmt.Println("Transfer to new beneficiary refused:", err)
+		}
+	}
+
+	if shruti.BeneficiaryID != 0 {
+		// Money leaving a newly opened account is held for review. The
+		// client retries under the same key and finds the same review; the
+		// money only moves once an admin approves it.
+		var held *apperror.HeldForReviewError
+		for attempt := 1; attempt <= 2; attempt++ {
+			err = manager.TransferToBeneficiary(riya, acc1ID, shruti.BeneficiaryID, money.MustFromMajor(1500, money.INR), "riya-transfer-1")
+			if errors.As(err, &held) {
+				fmt.Printf("Attempt %d: transfer held for review %d\n", attempt, held.ReviewID)
+			} else if err != nil {
+				fmt.Println("Error in interbank transfer:", err)
+			}
+		}
+		err = manager.TransferToBeneficiary(riya, acc1ID, shruti.BeneficiaryID, money.MustFromMajor(2500, money.INR), "riya-transfer-1")
+		if err != nil {
+			fmt.Println("Reused idempotency key refused:", err)
+		}
+		if held != nil {
+			review, err := manager.ApproveTransfer(admin, held.ReviewID, "customer confirmed by phone")
+			if err != nil {
+				fmt.Println("Error approving transfer:", err)
+			} else {
+				fmt.Printf("Review %d %s: %s moved\n", review.ReviewID, review.Status, review.Amount)
+			}
+		}
+	}
+
+	if customer1 != nil && bank1 != nil {
+		fmt.Println("\n--- Account products ---")
+		current, err := manager.CreateAccountForCustomer(admin, customer1.CustomerID, bank1.BankID, 0, money.INR, account.ProductCurrent, money.Zero(money.INR))
+		if err != nil {
+			fmt.Println("Error opening current account:", err)
+		} else if err := manager.WithDrawMoney(riya, money.MustFromMajor(2000, money.INR), current.AccountID); err != nil {
+			fmt.Println("Error drawing on overdraft:", err)
+		} else {
+			fmt.Printf("Current account %d overdrawn to %s\n", current.AccountID, current.GetBalance())
+		}
+
+		fd, err := manager.CreateAccountForCustomer(admin, customer1.CustomerID, bank1.BankID, 0, money.INR, account.ProductFixedDeposit, money.MustFromMajor(10000, money.INR))
+		if err != nil {
+			fmt.Println("Error opening fixed deposit:", err)
+		} else if err := manager.WithDrawMoney(riya, money.MustFromMajor(5000, money.INR), fd.AccountID); err != nil {
+			fmt.Println("Error breaking fixed deposit:", err)
+		} else {
+			fmt.Printf("Fixed deposit %d broken before %s, balance %s after penalty\n", fd.AccountID, fd.MaturesAt().Format("2006-01-02"), fd.GetBalance())
+		}
+
+		if acc1ID != 0 {
+			balance, _ := manager.GetAccount_BalanceBy_Id(riya, acc1ID)
+			if err := manager.WithDrawMoney(riya, balance, acc1ID); errors.Is(err, apperror.ErrInsufficientFunds) {
+				fmt.Println("Savings minimum balance kept:", err)
+			}
+		}
+	}
+
+	if acc1ID != 0 && bank1 != nil {
+		fmt.Println("\n--- Limits ---")
+		limits := account.DefaultLimits(account.ProductSavings)
+		limits.DailyWithdrawal = money.MustFromMajor(1000, money.INR)
+		if err := manager.SetLimits(admin, bank1.BankID, account.ProductSavings, limits); err != nil {
+			fmt.Println("Error setting limits:", err)
+		}
+		// Riya already withdrew INR 1000 today.
+		err := manager.WithDrawMoney(riya, money.MustFromMajor(500, money.INR), acc1ID)
+		if errors.Is(err, apperror.ErrLimitExceeded) {
+			fmt.Println("Withdrawal refused:", err)
+		}
+		increase := account.Limits{DailyWithdrawal: money.MustFromMajor(5000, money.INR)}
+		if _, err := manager.GrantLimitIncrease(admin, acc1ID, increase, time.Now().Add(24*time.Hour)); err != nil {
+			fmt.Println("Error granting limit increase:", err)
+		} else if err := manager.WithDrawMoney(riya, money.MustFromMajor(500, money.INR), acc1ID); err != nil {
+			fmt.Println("Error withdrawing after increase:", err)
+		} else {
+			fmt.Println("Withdrawal of INR 500.00 allowed under a temporary daily limit of INR 5000.00")
+		}
+
+		fmt.Println("\n--- Liens and freezes ---")
+		balance, _ := manager.GetAccount_BalanceBy_Id(riya, acc1ID)
+		lien, err := manager.PlaceLien(admin, acc1ID, balance, "Court order 1142/2024", time.Time{})
+		if err != nil {
+			fmt.Println("Error placing lien:", err)
+		} else if err := manager.WithDrawMoney(riya, money.MustFromMajor(100, money.INR), acc1ID); errors.Is(err, apperror.ErrInsufficientFunds) {
+			fmt.Printf("Lien %d holds %s; withdrawal refused: %v\n", lien.LienID, lien.Amount, err)
+		}
+		if _, err := manager.ReleaseLien(admin, acc1ID, lien.LienID); err != nil {
+			fmt.Println("Error releasing lien:", err)
+		}
+		if err := manager.FreezeAccount(admin, acc1ID, account.FreezeDebits, "KYC documents expired"); err != nil {
+			fmt.Println("Error freezing account:", err)
+		} else if err := manager.WithDrawMoney(riya, money.MustFromMajor(100, money.INR), acc1ID); errors.Is(err, apperror.ErrFrozen) {
+			fmt.Println("Withdrawal refused:", err)
+		}
+		if err := manager.DepositMoney(riya, money.MustFromMajor(100, money.INR), acc1ID); err != nil {
+			fmt.Println("Error depositing into debit-frozen account:", err)
+		} else {
+			fmt.Println("Deposit of INR 100.00 still accepted while only debits are frozen")
+		}
+		if err := manager.FreezeAccount(admin, acc1ID, account.FreezeNone, ""); err != nil {
+			fmt.Println("Error lifting freeze:", err)
+		}
+	}
+
+	if customer1 != nil && customer2 != nil && bank1 != nil && bank2 != nil && acc1ID != 0 {
+		fmt.Println("\n--- Foreign currency ---")
+		rates, err := fx.NewStatic(50)
+		if err == nil {
+			err = rates.Set(money.USD, money.INR, 83250000)
+		}
+		if err != nil {
+			fmt.Println("Error setting exchange rates:", err)
+		}
+		manager.SetRateProvider(rates)
+
+		dollars, err := manager.CreateAccountForCustomer(admin, customer1.CustomerID, bank1.BankID, 0, money.USD, account.ProductSavings, money.MustFromMajor(50, money.USD))
+		if err != nil {
+			fmt.Println("Error opening USD account:", err)
+		} else if err := manager.TransferMoneyInternally(riya, acc1ID, dollars.Number, money.MustFromMajor(1000, money.INR)); err != nil {
+			fmt.Println("Error buying dollars:", err)
+		} else {
+			passbook := dollars.GetPassbook()
+			txn := passbook[len(passbook)-1]
+			fmt.Printf("INR 1000.00 bought %s at %s (mid %s less %d bps); USD balance %s\n", txn.Amount, txn.FX.Rate, txn.FX.Mid, txn.FX.SpreadBPS, dollars.GetBalance())
+		}
+
+		usd2, err := manager.CreateAccountForCustomer(admin, customer2.CustomerID, bank2.BankID, 0, money.USD, account.ProductSavings, money.MustFromMajor(10, money.USD))
+		if err != nil {
+			fmt.Println("Error opening Shruti's USD account:", err)
+		} else if payee, err := manager.AddBeneficiary(riya, usd2.Number, "Shruti USD"); err != nil {
+			fmt.Println("Error adding USD beneficiary:", err)
+		} else {
+			// A first payment to a new beneficiary is held, like any other.
+			var held *apperror.HeldForReviewError
+			err := manager.TransferToBeneficiary(riya, acc1ID, payee.BeneficiaryID, money.MustFromMajor(500, money.INR))
+			if errors.As(err, &held) {
+				_, err = manager.ApproveTransfer(admin, held.ReviewID, "customer confirmed by phone")
+			}
+			if err != nil {
+				fmt.Println("Error sending dollars to Bank of Bharat:", err)
+			} else {
+				passbook := usd2.GetPassbook()
+				fmt.Printf("INR 500.00 arrived at Bank of Bharat as %s; balance %s\n", passbook[len(passbook)-1].Amount, usd2.GetBalance())
+			}
+		}
+		if total, err := manager.GetTotalBalanceBy_Customer_Id(admin, customer1.CustomerID); err == nil {
+			fmt.Printf("Riya holds %s across INR and USD accounts at the mid rate\n", total)
+		}
+	}
+
+	var karan *auth.Principal
+	var household *account.Account
+	if customer1 != nil && bank1 != nil {
+		fmt.Println("\n--- Joint account ---")
+		husband, err := manager.CreateNewCustomer(admin, "Karan", "Parekh")
+		if err == nil {
+			err = manager.SetPassword(admin, husband.CustomerID, "karan@123")
+		}
+		if err == nil {
+			_, karan, err = manager.Login(husband.CustomerID, "karan@123")
+		}
+		if err != nil {
+			fmt.Println("Error setting up Karan:", err)
+		}
+		household, err = manager.CreateAccountForCustomer(admin, customer1.CustomerID, bank1.BankID, 0, money.INR, account.ProductSavings, money.MustFromMajor(20000, money.INR))
+		if err == nil && karan != nil {
+			err = manager.AddAccountHolder(admin, household.AccountID, karan.CustomerID())
+		}
+		if err == nil {
+			err = manager.SetOperatingMode(admin, household.AccountID, account.ModeJointly, 0)
+		}
+		if err != nil {
+			fmt.Println("Error opening joint account:", err)
+		} else {
+			fmt.Printf("Account %d is held by %v, operated %s\n", household.AccountID, household.Holders(), household.Snapshot().Mode)
+			// Neither holder can take money out alone; a retry under the
+			// same key finds the same approval.
+			var pending *apperror.PendingApprovalError
+			for attempt := 1; attempt <= 2; attempt++ {
+				err = manager.WithDrawMoney(karan, money.MustFromMajor(800, money.INR), household.AccountID, "karan-groceries")
+				if errors.As(err, &pending) {
+					fmt.Printf("Attempt %d: withdrawal waits as approval %d\n", attempt, pending.ApprovalID)
+				}
+			}
+			if pending != nil {
+				if approval, err := manager.ConfirmApproval(riya, pending.ApprovalID); err != nil {
+					fmt.Println("Error approving withdrawal:", err)
+				} else {
+					fmt.Printf("Approval %d %s by %v; balance %s\n", approval.ApprovalID, approval.Status, approval.ApprovedBy, household.GetBalance())
+				}
+			}
+			if err := manager.WithDrawMoney(karan, money.MustFromMajor(900, money.INR), household.AccountID); errors.As(err, &pending) {
+				if approval, err := manager.RejectApproval(riya, pending.ApprovalID); err == nil {
+					fmt.Printf("Approval %d %s by Riya; balance still %s\n", approval.ApprovalID, approval.Status, household.GetBalance())
+				}
+			}
+		}
+	}
+
+	if acc1ID != 0 {
+		fmt.Println("\n--- Passbook for Riya ---")
+		passbook, err := manager.GetPassBook_ById(riya, acc1ID, 1)
+		if err != nil {
+			fmt.Println("Error fetching passbook:", err)
+		}
+		for _, txn := range passbook {
+			fmt.Printf("%s | %s | %-21s | Counterparty: %d | Amount: %s | Balance: %s\n",
+				txn.ReferenceID, txn.Timestamp.Format("2006-01-02 15:04:05"), txn.Type, txn.CounterpartyAccountID, txn.Amount, txn.Balance)
+		}
+	}
+
+	if bank1 != nil && bank2 != nil {
+		operator, err := manager.CreateStaff(admin, "Amit", "Shah", auth.RoleBankOperator, bank2.BankID)
+		if err != nil {
+			fmt.Println("Error creating Bank of Bharat operator:", err)
+		} else if err := manager.SetPassword(admin, operator.CustomerID, "amit@123"); err != nil {
+			fmt.Println("Error setting operator password:", err)
+		} else if _, amit, err := manager.Login(operator.CustomerID, "amit@123"); err != nil {
+			fmt.Println("Error logging in as operator:", err)
+		} else if acc1ID != 0 {
+			fmt.Println("\n--- Operator scoped to Bank of Bharat ---")
+			if err := manager.UpdateAccount(amit, acc1ID, money.Zero(money.INR)); err != nil {
+				fmt.Println("State Bank account override refused:", err)
+			}
+		}
+	}
+
+	var sip standing.Instruction
+	if shruti.BeneficiaryID != 0 {
+		sip, err = manager.CreateStandingInstruction(riya, standing.Spec{
+			Name:          "Weekly SIP",
+			FromAccountID: acc1ID,
+			BeneficiaryID: shruti.BeneficiaryID,
+			Amount:        money.MustFromMajor(100, money.INR),
+			Schedule:      standing.Schedule{Frequency: standing.Weekly, StartDate: sim.Now()},
+			Retry:         standing.Retry{Attempts: 2, Interval: 6 * time.Hour},
+		})
+		if err != nil {
+			fmt.Println("Error creating standing instruction:", err)
+		}
+	}
+
+	if bank1 != nil && acc1ID != 0 {
+		fmt.Println("\n--- End of day ---")
+		if err := manager.SetInterestRate(admin, bank1.BankID, account.ProductSavings, 400); err != nil {
+			fmt.Println("Error setting savings rate:", err)
+		}
+		// The executor runs due instructions as the clock moves through the
+		// month.
+		for day := 0; day < 31; day++ {
+			if _, err := manager.RunStandingInstructions(admin); err != nil {
+				fmt.Println("Error running standing instructions:", err)
+			}
+			sim.AdvanceDays(1)
+		}
+		runs, err := manager.CloseDueDays(admin)
+		if err != nil {
+			fmt.Println("Error running end of day:", err)
+		}
+		for _, run := range runs {
+			if run.AccountsCredited > 0 {
+				fmt.Printf("%s: credited %s to %d accounts\n", run.BusinessDate.Format("2006-01-02"), run.InterestCredited, run.AccountsCredited)
+			}
+		}
+		balance, _ := manager.GetAccount_BalanceBy_Id(riya, acc1ID)
+		fmt.Printf("Closed %d business days; Riya's savings balance is now %s\n", len(runs), balance)
+	}
+
+	if sip.InstructionID != 0 {
+		fmt.Println("\n--- Standing instruction ---")
+		executions, err := manager.StandingExecutions(riya, sip.InstructionID)
+		if err != nil {
+			fmt.Println("Error fetching executions:", err)
+		}
+		for _, e := range executions {
+			fmt.Printf("%s attempt %d: %s %s\n", e.DueDate.Format("2006-01-02"), e.Attempt, e.Outcome, e.Error)
+		}
+		if sip, err = manager.StandingInstruction(riya, sip.InstructionID); err == nil {
+			fmt.Printf("%q is %s\n", sip.Name, sip.Status)
+		}
+	}
+
+	if karan != nil && household != nil && acc1ID != 0 {
+		fmt.Println("\n--- Closing accounts ---")
+		acc1, _ := manager.ViewAccount(riya, acc1ID)
+		if _, err := manager.CloseAccount(riya, household.AccountID, closure.Payout{Method: closure.MethodCash}); err != nil {
+			fmt.Println("Riya cannot close the joint account alone:", err)
+		}
+		savings, err := manager.CreateAccountForCustomer(admin, karan.CustomerID(), bank1.BankID, 0, money.INR, account.ProductSavings, money.MustFromMajor(5001, money.INR))
+		if err == nil {
+			_, err = manager.SetNominees(karan, savings.AccountID, []account.Nominee{
+				{Name: "Riya Parekh", Relationship: "spouse", SharePercent: 50},
+				{Name: "Meera Parekh", Relationship: "daughter", SharePercent: 50},
+			})
+		}
+		if err == nil {
+			err = manager.RecordDeath(admin, karan.CustomerID(), sim.Now().AddDate(0, 0, -1))
+		}
+		if err != nil {
+			fmt.Println("Error recording Karan's death:", err)
+		} else {
+			// The joint account survives to Riya, who may now close it on
+			// her own.
+			fmt.Printf("Account %d passes to %v as sole holder\n", household.AccountID, household.Holders())
+			if c, err := manager.CloseAccount(riya, household.AccountID, closure.Payout{Method: closure.MethodTransfer, ToAccountNumber: acc1.Number}); err != nil {
+				fmt.Println("Error closing the joint account:", err)
+			} else {
+				fmt.Printf("Closure %d: interest %s, %s %s into %s; Riya's savings now %s\n", c.ClosureID, c.Interest, c.Payouts[0].Method, c.Payouts[0].Amount, c.Payouts[0].ToAccountNumber, acc1.GetBalance())
+			}
+			c, err := manager.SettleNomineeClaim(admin, savings.AccountID, []closure.Payout{
+				{NomineeID: 1, Method: closure.MethodTransfer, ToAccountNumber: acc1.Number},
+				{NomineeID: 2, Method: closure.MethodCash},
+			})
+			if err != nil {
+				fmt.Println("Error settling the nominee claim:", err)
+			}
+			for _, po := range c.Payouts {
+				fmt.Printf("Claim on account %d paid %s %s %s\n", c.AccountID, po.NomineeName, po.Amount, po.Method)
+			}
+		}
+	}
+
+	fmt.Println("\n--- Interbank Ledger Balances ---")
+	allBalances := manager.GetLedger().AllBalances()
+	for _, currency := range []string{money.INR, money.USD} {
+		for fromBankID, debts := range allBalances[currency] {
+			for toBankID, amt := range debts {
+				fmt.Printf("Bank %d owes Bank %d: %s\n", fromBankID, toBankID, amt)
+			}
+		}
+	}
+
+	fmt.Println("\n--- Net Bank Positions ---")
+	for _, bank := range manager.GetAllBanks() {
+		if !bank.IsActive {
+			continue
+		}
+		for _, currency := range []string{money.INR, money.USD} {
+			actual, receivable, owed, err := manager.GetLedger().GetNetBankPosition(bank.BankID, currency)
+			if err != nil {
+				fmt.Printf("Error getting net position for Bank ID %d: %v\n", bank.BankID, err)
+				continue
+			}
+			fmt.Printf("Bank ID: %d | Actual: %s | Receivable: %s | Owed: %s\n", bank.BankID, actual, receivable, owed)
+		}
+	}
+
+	fmt.Println("\n--- Settlement ---")
+	batch, err := manager.SettleInterbank(admin)
+	if err != nil {
+		fmt.Println("Error settling:", err)
+	} else {
+		for _, pay := range batch.Payments {
+			fmt.Printf("Batch %d: Bank %d pays Bank %d %s\n", batch.BatchID, pay.FromBankID, pay.ToBankID, pay.Amount)
+		}
+		for _, pos := range batch.NetPositions {
+			rec, err := manager.ReconcileBankPosition(admin, pos.BankID, pos.Net.Currency)
+			if err != nil {
+				fmt.Printf("Error reconciling Bank ID %d: %v\n", pos.BankID, err)
+				continue
+			}
+			fmt.Printf("Bank ID: %d | Settled: %s | Outstanding: %s | Reconciled: %t\n", rec.BankID, rec.Settled, rec.Outstanding, rec.Balanced)
+		}
+	}
+
+	fmt.Println("\n--- Trial Balance ---")
+	tbs, err := manager.TrialBalances(admin)
+	if err != nil {
+		fmt.Println("Error building trial balance:", err)
+	}
+	for _, tb := range tbs {
+		fmt.Println(tb.Currency)
+		for _, row := range tb.Rows {
+			fmt.Printf("%-22s Dr %12s  Cr %12s\n", row.Account, row.Debit.Decimal(), row.Credit.Decimal())
+		}
+		fmt.Printf("%-22s Dr %12s  Cr %12s\n", "Total", tb.TotalDebit.Decimal(), tb.TotalCredit.Decimal())
+	}
+	if err := manager.VerifyJournal(admin); err != nil {
+		fmt.Println("Journal does not agree with the books:", err)
+	} else {
+		fmt.Println("Journal agrees with every account balance and interbank due")
+	}
+
+	fmt.Println("\n--- Updated Banks ---")
+	for _, b := range manager.GetAllBanks() {
+		if b.IsActive {
+			fmt.Printf("ID: %d | Name: %s | Code: %s\n", b.BankID, b.Name, b.Code)
+		}
+	}
+
+	fmt.Println("\n--- Updated Customers ---")
+	allCustomers, err = manager.GetAllCustomers(admin)
+	if err != nil {
+		fmt.Println("Error listing customers:", err)
+	}
+	for _, c := range allCustomers {
+		fmt.Printf("ID: %d | Name: %s %s\n", c.CustomerID, c.FirstName, c.LastName)
+		for _, acc := range c.Accounts {
+			if acc.IsOpen() {
+				fmt.Printf("    AccountID: %d | %s | Balance: %s | BankID: %d\n", acc.AccountID, acc.Terms.Product, acc.GetBalance(), acc.BankID)
+			}
+		}
+	}
+}
+
+// openBank creates the bank under code, or finds it when the store already
+// has it from an earlier run.
+func openBank(manager *customer.CustomerManager, admin *auth.Principal, name, code string) (*bank.Bank, error) {
+	if b, err := manager.GetBankByCode(code); err == nil {
+		return b, nil
+	}
+	return manager.CreateNewBank(admin, name, code)
+}
@@ -0,0 +1,271 @@
+package money
+
+import (
+	"banking-app/apperror"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	INR = "INR"
+	USD = "USD"
+	EUR = "EUR"
+)
+
+// minorUnits holds how many decimal places each supported currency carries.
+var minorUnits = map[string]int{
+	"INR": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+}
+
+type RoundingMode int
+
+const (
+	RoundHalfUp RoundingMode = iota
+	RoundHalfEven
+	RoundDown
+	RoundUp
+)
+
+// Money is an exact amount stored as integer minor units (paisa for INR).
+// The zero value has no currency and adopts the currency of whatever it is
+// first combined with, so it can be used as an accumulator.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func New(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: strings.ToUpper(currency)}
+}
+
+func FromMajor(major int64, currency string) (Money, error) {
+	scale := pow10(Exponent(currency))
+	if major > math.MaxInt64/scale || major < math.MinInt64/scale {
+		return Money{}, apperror.NewValidationError("amount", "overflows the money range")
+	}
+	return New(major*scale, currency), nil
+}
+
+func MustFromMajor(major int64, currency string) Money {
+	m, err := FromMajor(major, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// ParseCurrency accepts the code of a supported currency in any case.
+func ParseCurrency(s string) (string, error) {
+	currency := strings.ToUpper(strings.TrimSpace(s))
+	if _, ok := minorUnits[currency]; !ok {
+		return "", apperror.NewValidationError("currency", fmt.Sprintf("unsupported currency %q", s))
+	}
+	return currency, nil
+}
+
+func Exponent(currency string) int {
+	if exp, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+var plainDecimal = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// Parse reads a plain decimal string such as "1500.25" without going through
+// float64. Exponents, fractions and more fractional digits than the
+// currency's minor unit are refused rather than rounded.
+func Parse(value, currency string) (Money, error) {
+	s := strings.TrimSpace(value)
+	if !plainDecimal.MatchString(s) {
+		return Money{}, apperror.NewValidationError("amount", fmt.Sprintf("%q is not a decimal number", value))
+	}
+	exp := Exponent(currency)
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > exp {
+		return Money{}, apperror.NewValidationError("amount", fmt.Sprintf("%q has more than %d decimal places for %s", value, exp, strings.ToUpper(currency)))
+	}
+	minor, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", exp-len(frac)), 10)
+	if !ok || !minor.IsInt64() {
+		return Money{}, apperror.NewValidationError("amount", "overflows the money range")
+	}
+	return New(minor.Int64(), currency), nil
+}
+
+// FromFloat converts a float64 using its shortest exact decimal form and
+// then rounds to the currency's minor unit.
+func FromFloat(value float64, currency string, mode RoundingMode) (Money, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return Money{}, apperror.NewValidationError("amount", "must be a finite number")
+	}
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	return fromRat(r, currency, mode)
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.commonCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, apperror.NewValidationError("amount", "addition overflows the money range")
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, apperror.NewValidationError("amount", "subtraction overflows the money range")
+	}
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// MulRat multiplies by num/den and rounds the result back to minor units.
+// It is used for interest, fees and FX conversion where the factor is not
+// a whole number.
+func (m Money) MulRat(num, den int64, mode RoundingMode) (Money, error) {
+	if den == 0 {
+		return Money{}, apperror.NewValidationError("denominator", "cannot be zero")
+	}
+	r := new(big.Rat).SetFrac(big.NewInt(m.Amount), big.NewInt(1))
+	r.Mul(r, big.NewRat(num, den))
+	r.Quo(r, new(big.Rat).SetInt64(pow10(Exponent(m.Currency))))
+	return fromRat(r, m.Currency, mode)
+}
+
+// Convert prices m in currency at num/den units of currency per unit of m's
+// own, rounding to currency's minor unit.
+func (m Money) Convert(currency string, num, den int64, mode RoundingMode) (Money, error) {
+	if den == 0 {
+		return Money{}, apperror.NewValidationError("denominator", "cannot be zero")
+	}
+	r := new(big.Rat).SetFrac(big.NewInt(m.Amount), big.NewInt(pow10(Exponent(m.Currency))))
+	r.Mul(r, big.NewRat(num, den))
+	return fromRat(r, currency, mode)
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+func (m Money) Cmp(other Money) (int, error) {
+	if _, err := m.commonCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) LessThan(other Money) (bool, error) {
+	c, err := m.Cmp(other)
+	return c < 0, err
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) String() string {
+	currency := m.Currency
+	if currency == "" {
+		currency = INR
+	}
+	return fmt.Sprintf("%s %s", currency, m.Decimal())
+}
+
+// Decimal formats the amount in major units without the currency code.
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+	sign := ""
+	amount := new(big.Int).SetInt64(m.Amount)
+	if amount.Sign() < 0 {
+		sign = "-"
+		amount.Neg(amount)
+	}
+	if exp == 0 {
+		return sign + amount.String()
+	}
+	digits := fmt.Sprintf("%0*s", exp+1, amount.String())
+	return fmt.Sprintf("%s%s.%s", sign, digits[:len(digits)-exp], digits[len(digits)-exp:])
+}
+
+func (m Money) commonCurrency(other Money) (string, error) {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Amount == 0:
+		return other.Currency, nil
+	case other.Currency == "" && other.Amount == 0:
+		return m.Currency, nil
+	}
+	return "", apperror.NewValidationError("currency", fmt.Sprintf("cannot combine %s with %s", m.Currency, other.Currency))
+}
+
+func fromRat(r *big.Rat, currency string, mode RoundingMode) (Money, error) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt64(pow10(Exponent(currency))))
+	minor := roundRat(scaled, mode)
+	if !minor.IsInt64() {
+		return Money{}, apperror.NewValidationError("amount", "overflows the money range")
+	}
+	return New(minor.Int64(), currency), nil
+}
+
+func roundRat(r *big.Rat, mode RoundingMode) *big.Int {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+	away := big.NewInt(int64(r.Sign()))
+	twiceRem := new(big.Int).Abs(rem)
+	twiceRem.Lsh(twiceRem, 1)
+	half := twiceRem.Cmp(r.Denom())
+
+	switch mode {
+	case RoundDown:
+	case RoundUp:
+		quo.Add(quo, away)
+	case RoundHalfEven:
+		if half > 0 || (half == 0 && quo.Bit(0) == 1) {
+			quo.Add(quo, away)
+		}
+	default:
+		if half >= 0 {
+			quo.Add(quo, away)
+		}
+	}
+	return quo
+}
+
+func pow10(exp int) int64 {
+	result := int64(1)
+	for i := 0; i < exp; i++ {
+		result *= 10
+	}
+	return result
+}
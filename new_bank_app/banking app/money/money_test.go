@@ -0,0 +1,152 @@
+package money
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{value: "1500.25", currency: INR, want: New(150025, INR)},
+		{value: "1500.2", currency: INR, want: New(150020, INR)},
+		{value: "1500", currency: INR, want: New(150000, INR)},
+		{value: " -0.05 ", currency: "inr", want: New(-5, INR)},
+		{value: "1500", currency: "JPY", want: New(1500, "JPY")},
+		{value: "92233720368547758.07", currency: INR, want: New(math.MaxInt64, INR)},
+		{value: "1500.255", currency: INR, wantErr: true},
+		{value: "1500.5", currency: "JPY", wantErr: true},
+		{value: "92233720368547758.08", currency: INR, wantErr: true},
+		{value: "", currency: INR, wantErr: true},
+		{value: "1e3", currency: INR, wantErr: true},
+		{value: "1/2", currency: INR, wantErr: true},
+		{value: "+5", currency: INR, wantErr: true},
+		{value: ".5", currency: INR, wantErr: true},
+		{value: "5.", currency: INR, wantErr: true},
+		{value: "1,500", currency: INR, wantErr: true},
+		{value: "0x10", currency: INR, wantErr: true},
+		{value: "NaN", currency: INR, wantErr: true},
+		{value: "१२", currency: INR, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.value, tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q, %s) = %s, want an error", tt.value, tt.currency, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q, %s) = %s, %v; want %s", tt.value, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestAddAndSubRefuseOverflow(t *testing.T) {
+	max, min := New(math.MaxInt64, INR), New(math.MinInt64, INR)
+	one := New(1, INR)
+	tests := []struct {
+		name    string
+		op      func() (Money, error)
+		want    Money
+		wantErr bool
+	}{
+		{name: "add", op: func() (Money, error) { return New(150, INR).Add(New(25, INR)) }, want: New(175, INR)},
+		{name: "sub below zero", op: func() (Money, error) { return New(25, INR).Sub(New(150, INR)) }, want: New(-125, INR)},
+		{name: "add up to max", op: func() (Money, error) { return New(math.MaxInt64-1, INR).Add(one) }, want: max},
+		{name: "add past max", op: func() (Money, error) { return max.Add(one) }, wantErr: true},
+		{name: "add past min", op: func() (Money, error) { return min.Add(one.Neg()) }, wantErr: true},
+		{name: "sub past min", op: func() (Money, error) { return min.Sub(one) }, wantErr: true},
+		{name: "sub past max", op: func() (Money, error) { return max.Sub(one.Neg()) }, wantErr: true},
+		{name: "sub min", op: func() (Money, error) { return Zero(INR).Sub(min) }, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.op()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s = %s, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s = %s, %v; want %s", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestMulRatRounding(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		num, den int64
+		mode     RoundingMode
+		want     int64
+	}{
+		// 105 paise / 2 is exactly half a paisa over 52.
+		{105, 1, 2, RoundHalfUp, 53},
+		{105, 1, 2, RoundHalfEven, 52},
+		{105, 1, 2, RoundDown, 52},
+		{105, 1, 2, RoundUp, 53},
+		{115, 1, 2, RoundHalfEven, 58},
+		{-105, 1, 2, RoundHalfUp, -53},
+		{-105, 1, 2, RoundHalfEven, -52},
+		{-105, 1, 2, RoundDown, -52},
+		{-105, 1, 2, RoundUp, -53},
+		// 101 paise / 4 is a quarter of a paisa over 25.
+		{101, 1, 4, RoundHalfUp, 25},
+		{101, 1, 4, RoundHalfEven, 25},
+		{101, 1, 4, RoundDown, 25},
+		{101, 1, 4, RoundUp, 26},
+		// 103 paise / 4 is three quarters of a paisa over 25.
+		{103, 1, 4, RoundHalfUp, 26},
+		{103, 1, 4, RoundHalfEven, 26},
+		{103, 1, 4, RoundDown, 25},
+		{103, 1, 4, RoundUp, 26},
+		// Whole results are never rounded.
+		{100, 1, 2, RoundUp, 50},
+		{100, 3, 1, RoundDown, 300},
+	}
+	for _, tt := range tests {
+		got, err := New(tt.amount, INR).MulRat(tt.num, tt.den, tt.mode)
+		if err != nil || got != New(tt.want, INR) {
+			t.Errorf("%d paise * %d/%d in mode %d = %s, %v; want %d paise", tt.amount, tt.num, tt.den, tt.mode, got, err, tt.want)
+		}
+	}
+	if _, err := New(100, INR).MulRat(1, 0, RoundHalfEven); err == nil {
+		t.Error("MulRat by x/0 did not fail")
+	}
+	if _, err := New(math.MaxInt64, INR).MulRat(2, 1, RoundHalfEven); err == nil {
+		t.Error("MulRat past the money range did not fail")
+	}
+}
+
+func TestCurrenciesDoNotMix(t *testing.T) {
+	inr, usd := New(100, INR), New(100, USD)
+	if _, err := inr.Add(usd); err == nil {
+		t.Error("INR + USD did not fail")
+	}
+	if _, err := inr.Sub(usd); err == nil {
+		t.Error("INR - USD did not fail")
+	}
+	if _, err := inr.Cmp(usd); err == nil {
+		t.Error("comparing INR with USD did not fail")
+	}
+	if _, err := inr.LessThan(usd); err == nil {
+		t.Error("INR < USD did not fail")
+	}
+
+	// The zero value takes on the other side's currency.
+	if got, err := (Money{}).Add(usd); err != nil || got != usd {
+		t.Errorf("zero value + USD = %s, %v; want %s", got, err, usd)
+	}
+	if got, err := inr.Sub(Money{}); err != nil || got != inr {
+		t.Errorf("INR - zero value = %s, %v; want %s", got, err, inr)
+	}
+	// A zero amount in a currency is still in that currency.
+	if _, err := Zero(INR).Add(usd); err == nil {
+		t.Error("INR 0.00 + USD did not fail")
+	}
+}
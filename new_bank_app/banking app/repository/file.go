@@ -0,0 +1,445 @@
+package repository
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/audit"
+	"banking-app/bank"
+	"banking-app/beneficiary"
+	"banking-app/closure"
+	"banking-app/eod"
+	"banking-app/idempotency"
+	"banking-app/joint"
+	"banking-app/journal"
+	"banking-app/ledger"
+	"banking-app/risk"
+	"banking-app/standing"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	kindBank        = "bank"
+	kindBankDeleted = "bank_deleted"
+	kindCustomer    = "customer"
+	kindAccount     = "account"
+	kindDues        = "dues"
+	kindSettlement  = "settlement"
+	kindTransaction = "transactions"
+	kindCredential  = "credential"
+	kindAudit       = "audit"
+	kindJournal     = "journal"
+	kindIdempotency = "idempotency_key"
+	kindLimitRaised = "limit_increase"
+	kindReview      = "review"
+	kindApproval    = "joint_approval"
+	kindClosure     = "account_closure"
+	kindBeneficiary = "beneficiary"
+	kindInstruction = "standing_instruction"
+	kindExecution   = "standing_execution"
+	kindEODRun      = "eod_run"
+)
+
+type credentialRecord struct {
+	CustomerID   int
+	PasswordHash string
+}
+
+type fileRecord struct {
+	Kind      string          `json:"kind"`
+	AccountID int             `json:"account_id,omitempty"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// FileStore is an append-only JSON-lines store. Every save appends a record
+// and is applied to an in-memory copy; opening the file replays the records
+// in order, so the latest record for an entity wins. The audit log's head
+// is kept beside the file, in path.audit-head, so cutting entries off the
+// end of the file does not take the head with them.
+type FileStore struct {
+	*MemoryStore
+	mu   sync.Mutex
+	file storeFile
+	// size is where the last complete record ends. broken is set once a
+	// failed append could not be cut off again, and refuses every write
+	// after it.
+	size     int64
+	broken   error
+	headPath string
+}
+
+// storeFile is the part of *os.File the store writes through.
+type storeFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{MemoryStore: NewMemoryStore(), headPath: path + ".audit-head"}
+	if err := s.replay(path); err != nil {
+		return nil, err
+	}
+	if err := s.loadAuditHead(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, apperror.NewBankError("open store", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, apperror.NewBankError("open store", path, err)
+	}
+	s.file, s.size = f, info.Size()
+	return s, nil
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+func (s *FileStore) SaveBank(b bank.Bank) error {
+	return s.append(kindBank, 0, b, func() error { return s.MemoryStore.SaveBank(b) })
+}
+
+func (s *FileStore) DeleteBank(bankID int) error {
+	return s.append(kindBankDeleted, 0, bankID, func() error { return s.MemoryStore.DeleteBank(bankID) })
+}
+
+func (s *FileStore) SaveCustomer(c CustomerRecord) error {
+	return s.append(kindCustomer, 0, c, func() error { return s.MemoryStore.SaveCustomer(c) })
+}
+
+func (s *FileStore) SaveAccount(a account.Snapshot) error {
+	return s.append(kindAccount, 0, a, func() error { return s.MemoryStore.SaveAccount(a) })
+}
+
+func (s *FileStore) SaveDues(dues []ledger.Due) error {
+	return s.append(kindDues, 0, dues, func() error { return s.MemoryStore.SaveDues(dues) })
+}
+
+func (s *FileStore) SaveSettlement(b ledger.SettlementBatch) error {
+	return s.append(kindSettlement, 0, b, func() error { return s.MemoryStore.SaveSettlement(b) })
+}
+
+func (s *FileStore) AppendTransactions(accountID int, txns []account.Transaction) error {
+	if len(txns) == 0 {
+		return nil
+	}
+	return s.append(kindTransaction, accountID, txns, func() error { return s.MemoryStore.AppendTransactions(accountID, txns) })
+}
+
+func (s *FileStore) SaveCredential(customerID int, passwordHash string) error {
+	rec := credentialRecord{CustomerID: customerID, PasswordHash: passwordHash}
+	return s.append(kindCredential, 0, rec, func() error { return s.MemoryStore.SaveCredential(customerID, passwordHash) })
+}
+
+func (s *FileStore) AppendAudit(e audit.Entry) error {
+	return s.append(kindAudit, 0, e, func() error { return s.MemoryStore.AppendAudit(e) })
+}
+
+// SaveAuditHead replaces the head file through a rename, so it is always
+// either the old head or the new one.
+func (s *FileStore) SaveAuditHead(h audit.Head) error {
+	raw, err := json.Marshal(h)
+	if err != nil {
+		return apperror.NewBankError("persist audit head", "cannot encode head", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.headPath + ".tmp"
+	if err := writeSynced(tmp, raw); err != nil {
+		return apperror.NewBankError("persist audit head", "cannot write head", err)
+	}
+	if err := os.Rename(tmp, s.headPath); err != nil {
+		return apperror.NewBankError("persist audit head", "cannot replace head", err)
+	}
+	return s.MemoryStore.SaveAuditHead(h)
+}
+
+func (s *FileStore) loadAuditHead() error {
+	raw, err := os.ReadFile(s.headPath)
+	if os.IsNotExist(err) {
+		// Stores written before the head was kept have none.
+		return nil
+	}
+	if err != nil {
+		return apperror.NewBankError("open store", s.headPath, err)
+	}
+	var h audit.Head
+	if err := json.Unmarshal(raw, &h); err != nil {
+		return apperror.NewBankError("open store", "the audit head is corrupt", err)
+	}
+	return s.MemoryStore.SaveAuditHead(h)
+}
+
+func writeSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *FileStore) AppendJournal(entries []journal.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return s.append(kindJournal, 0, entries, func() error { return s.MemoryStore.AppendJournal(entries) })
+}
+
+func (s *FileStore) SaveIdempotencyKey(r idempotency.Record) error {
+	return s.append(kindIdempotency, 0, r, func() error { return s.MemoryStore.SaveIdempotencyKey(r) })
+}
+
+func (s *FileStore) SaveLimitIncrease(i account.LimitIncrease) error {
+	return s.append(kindLimitRaised, 0, i, func() error { return s.MemoryStore.SaveLimitIncrease(i) })
+}
+
+func (s *FileStore) SaveReview(r risk.Review) error {
+	return s.append(kindReview, 0, r, func() error { return s.MemoryStore.SaveReview(r) })
+}
+
+func (s *FileStore) SaveApproval(a joint.Approval) error {
+	return s.append(kindApproval, 0, a, func() error { return s.MemoryStore.SaveApproval(a) })
+}
+
+func (s *FileStore) SaveClosure(c closure.Closure) error {
+	return s.append(kindClosure, 0, c, func() error { return s.MemoryStore.SaveClosure(c) })
+}
+
+func (s *FileStore) SaveBeneficiary(b beneficiary.Beneficiary) error {
+	return s.append(kindBeneficiary, 0, b, func() error { return s.MemoryStore.SaveBeneficiary(b) })
+}
+
+func (s *FileStore) SaveInstruction(in standing.Instruction) error {
+	return s.append(kindInstruction, 0, in, func() error { return s.MemoryStore.SaveInstruction(in) })
+}
+
+func (s *FileStore) AppendExecution(e standing.Execution) error {
+	return s.append(kindExecution, 0, e, func() error { return s.MemoryStore.AppendExecution(e) })
+}
+
+func (s *FileStore) SaveEODRun(r eod.Run) error {
+	return s.append(kindEODRun, 0, r, func() error { return s.MemoryStore.SaveEODRun(r) })
+}
+
+func (s *FileStore) append(kind string, accountID int, data interface{}, apply func() error) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return apperror.NewBankError("persist "+kind, "cannot encode record", err)
+	}
+	line, err := json.Marshal(fileRecord{Kind: kind, AccountID: accountID, Data: raw})
+	if err != nil {
+		return apperror.NewBankError("persist "+kind, "cannot encode record", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.broken != nil {
+		return apperror.NewBankError("persist "+kind, "the store has to be reopened after a failed write", s.broken)
+	}
+	line = append(line, '\n')
+	if _, err := s.file.Write(line); err != nil {
+		return s.cutOff(kind, "cannot write record", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return s.cutOff(kind, "cannot sync store", err)
+	}
+	s.size += int64(len(line))
+	return apply()
+}
+
+// cutOff truncates the file back to its last complete record after a
+// failed append, so that whatever part of the record reached the file is
+// not replayed as if it had been saved. It expects s.mu to be held.
+func (s *FileStore) cutOff(kind, reason string, err error) error {
+	if truncErr := s.file.Truncate(s.size); truncErr != nil {
+		s.broken = truncErr
+		return apperror.NewBankError("persist "+kind, reason+", and cannot cut the partial record off", err)
+	}
+	return apperror.NewBankError("persist "+kind, reason, err)
+}
+
+func (s *FileStore) replay(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return apperror.NewBankError("open store", path, err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	var complete int64
+	for lineNo := 1; ; lineNo++ {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) == 0 {
+				return nil
+			}
+			// Each record is written together with its newline, so a last
+			// line without one was cut short by a crash mid-append and was
+			// never acknowledged. Cut it off so the next append starts on a
+			// line of its own.
+			if err := os.Truncate(path, complete); err != nil {
+				return apperror.NewBankError("replay store", fmt.Sprintf("cannot drop the incomplete line %d", lineNo), err)
+			}
+			return nil
+		}
+		if err != nil {
+			return apperror.NewBankError("replay store", path, err)
+		}
+		complete += int64(len(line))
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			continue
+		}
+		var rec fileRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return apperror.NewBankError("replay store", fmt.Sprintf("line %d is corrupt", lineNo), err)
+		}
+		if err := s.applyRecord(rec); err != nil {
+			return apperror.NewBankError("replay store", fmt.Sprintf("line %d cannot be applied", lineNo), err)
+		}
+	}
+}
+
+func (s *FileStore) applyRecord(rec fileRecord) error {
+	switch rec.Kind {
+	case kindBank:
+		var b bank.Bank
+		if err := json.Unmarshal(rec.Data, &b); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveBank(b)
+	case kindBankDeleted:
+		var id int
+		if err := json.Unmarshal(rec.Data, &id); err != nil {
+			return err
+		}
+		return s.MemoryStore.DeleteBank(id)
+	case kindCustomer:
+		var c CustomerRecord
+		if err := json.Unmarshal(rec.Data, &c); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveCustomer(c)
+	case kindAccount:
+		var a account.Snapshot
+		if err := json.Unmarshal(rec.Data, &a); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveAccount(a)
+	case kindDues:
+		var dues []ledger.Due
+		if err := json.Unmarshal(rec.Data, &dues); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveDues(dues)
+	case kindSettlement:
+		var b ledger.SettlementBatch
+		if err := json.Unmarshal(rec.Data, &b); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveSettlement(b)
+	case kindTransaction:
+		var txns []account.Transaction
+		if err := json.Unmarshal(rec.Data, &txns); err != nil {
+			return err
+		}
+		return s.MemoryStore.AppendTransactions(rec.AccountID, txns)
+	case kindCredential:
+		var c credentialRecord
+		if err := json.Unmarshal(rec.Data, &c); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveCredential(c.CustomerID, c.PasswordHash)
+	case kindAudit:
+		var e audit.Entry
+		if err := json.Unmarshal(rec.Data, &e); err != nil {
+			return err
+		}
+		return s.MemoryStore.AppendAudit(e)
+	case kindJournal:
+		var entries []journal.Entry
+		if err := json.Unmarshal(rec.Data, &entries); err != nil {
+			return err
+		}
+		return s.MemoryStore.AppendJournal(entries)
+	case kindIdempotency:
+		var r idempotency.Record
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveIdempotencyKey(r)
+	case kindLimitRaised:
+		var i account.LimitIncrease
+		if err := json.Unmarshal(rec.Data, &i); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveLimitIncrease(i)
+	case kindReview:
+		var r risk.Review
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveReview(r)
+	case kindApproval:
+		var a joint.Approval
+		if err := json.Unmarshal(rec.Data, &a); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveApproval(a)
+	case kindClosure:
+		var c closure.Closure
+		if err := json.Unmarshal(rec.Data, &c); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveClosure(c)
+	case kindBeneficiary:
+		var b beneficiary.Beneficiary
+		if err := json.Unmarshal(rec.Data, &b); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveBeneficiary(b)
+	case kindInstruction:
+		var in standing.Instruction
+		if err := json.Unmarshal(rec.Data, &in); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveInstruction(in)
+	case kindExecution:
+		var e standing.Execution
+		if err := json.Unmarshal(rec.Data, &e); err != nil {
+			return err
+		}
+		return s.MemoryStore.AppendExecution(e)
+	case kindEODRun:
+		var r eod.Run
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return err
+		}
+		return s.MemoryStore.SaveEODRun(r)
+	}
+	return fmt.Errorf("unknown record kind %q", rec.Kind)
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"banking-app/audit"
+	"banking-app/bank"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeBanks(t *testing.T, path string, names ...string) {
+	t.Helper()
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for i, name := range names {
+		if err := s.SaveBank(bank.Bank{BankID: i + 1, Name: name, IsActive: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReplayDropsTornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.jsonl")
+	writeBanks(t, path, "State Bank of India")
+	whole, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	torn := append(append([]byte(nil), whole...), `{"kind":"bank","data":{"BankID":2,"Na`...)
+	if err := os.WriteFile(path, torn, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("torn last line refused the store: %v", err)
+	}
+	if err := s.SaveBank(bank.Bank{BankID: 3, Name: "Bank of Baroda", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("store written after the torn line does not reopen: %v", err)
+	}
+	defer s.Close()
+	banks, err := s.LoadBanks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(banks) != 2 || banks[0].BankID != 1 || banks[1].BankID != 3 {
+		t.Fatalf("banks = %+v, want banks 1 and 3", banks)
+	}
+}
+
+func TestReplayRefusesCorruptLineInTheMiddle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.jsonl")
+	writeBanks(t, path, "State Bank of India")
+	whole, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := append([]byte("{\"kind\":\"bank\",\"da\n"), whole...)
+	if err := os.WriteFile(path, corrupt, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenFileStore(path); err == nil {
+		t.Fatal("store with a corrupt line before its last opened")
+	}
+}
+
+func TestAuditHeadOutlivesEntriesCutOffTheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.jsonl")
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := audit.NewLog(s.AppendAudit, s.SaveAuditHead)
+	for id := 1; id <= 3; id++ {
+		if _, err := log.Append(audit.SystemActor, audit.ActionBankCreated, "bank", id, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	whole, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.SplitAfter(whole, []byte("\n"))
+	if err := os.WriteFile(path, bytes.Join(lines[:2], nil), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	entries, err := s.LoadAudit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := s.LoadAuditHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || head.Sequence != 3 {
+		t.Fatalf("reopened with %d entries and head %d, want 2 and 3", len(entries), head.Sequence)
+	}
+	if err := audit.NewLog(nil, nil).Restore(entries, head); err == nil {
+		t.Error("audit log missing its last entry was restored")
+	}
+}
+
+// failingFile writes only half of the next record and reports the failure,
+// as a full disk would.
+type failingFile struct {
+	*os.File
+	fail         bool
+	failTruncate bool
+}
+
+func (f *failingFile) Write(p []byte) (int, error) {
+	if !f.fail {
+		return f.File.Write(p)
+	}
+	f.fail = false
+	n, _ := f.File.Write(p[:len(p)/2])
+	return n, errors.New("no space left on device")
+}
+
+func (f *failingFile) Truncate(size int64) error {
+	if f.failTruncate {
+		return errors.New("read-only file system")
+	}
+	return f.File.Truncate(size)
+}
+
+func TestFailedAppendLeavesNoPartialRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.jsonl")
+	writeBanks(t, path, "State Bank of India")
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := &failingFile{File: s.file.(*os.File), fail: true}
+	s.file = file
+	if err := s.SaveBank(bank.Bank{BankID: 2, Name: "Bank of Baroda", IsActive: true}); err == nil {
+		t.Fatal("save that failed halfway reported success")
+	}
+	if err := s.SaveBank(bank.Bank{BankID: 3, Name: "Canara Bank", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("store does not reopen after a failed write: %v", err)
+	}
+	defer s.Close()
+	banks, err := s.LoadBanks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(banks) != 2 || banks[0].BankID != 1 || banks[1].BankID != 3 {
+		t.Fatalf("banks = %+v, want banks 1 and 3", banks)
+	}
+}
+
+func TestStoreRefusesWritesAfterAPartialRecordCannotBeCutOff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.jsonl")
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.file = &failingFile{File: s.file.(*os.File), fail: true, failTruncate: true}
+	if err := s.SaveBank(bank.Bank{BankID: 1, Name: "State Bank of India", IsActive: true}); err == nil {
+		t.Fatal("save that failed halfway reported success")
+	}
+	if err := s.SaveBank(bank.Bank{BankID: 2, Name: "Bank of Baroda", IsActive: true}); err == nil {
+		t.Fatal("store wrote after a partial record it could not cut off")
+	}
+}
@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"banking-app/account"
+	"banking-app/audit"
+	"banking-app/bank"
+	"banking-app/beneficiary"
+	"banking-app/closure"
+	"banking-app/eod"
+	"banking-app/idempotency"
+	"banking-app/joint"
+	"banking-app/journal"
+	"banking-app/ledger"
+	"banking-app/risk"
+	"banking-app/standing"
+	"sort"
+	"sync"
+)
+
+type MemoryStore struct {
+	mu            sync.RWMutex
+	banks         map[int]bank.Bank
+	customers     map[int]CustomerRecord
+	accounts      map[int]account.Snapshot
+	dues          []ledger.Due
+	settlements   []ledger.SettlementBatch
+	transactions  map[int][]account.Transaction
+	credentials   map[int]string
+	audit         []audit.Entry
+	auditHead     audit.Head
+	journal       []journal.Entry
+	idempotency   []idempotency.Record
+	increases     map[int]account.LimitIncrease
+	reviews       map[int]risk.Review
+	approvals     map[int]joint.Approval
+	closures      map[int]closure.Closure
+	beneficiaries map[int]beneficiary.Beneficiary
+	instructions  map[int]standing.Instruction
+	executions    []standing.Execution
+	eodRuns       []eod.Run
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		banks:         make(map[int]bank.Bank),
+		customers:     make(map[int]CustomerRecord),
+		accounts:      make(map[int]account.Snapshot),
+		transactions:  make(map[int][]account.Transaction),
+		credentials:   make(map[int]string),
+		increases:     make(map[int]account.LimitIncrease),
+		reviews:       make(map[int]risk.Review),
+		approvals:     make(map[int]joint.Approval),
+		closures:      make(map[int]closure.Closure),
+		beneficiaries: make(map[int]beneficiary.Beneficiary),
+		instructions:  make(map[int]standing.Instruction),
+	}
+}
+
+func (s *MemoryStore) SaveBank(b bank.Bank) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.banks[b.BankID] = b
+	return nil
+}
+
+func (s *MemoryStore) DeleteBank(bankID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.banks, bankID)
+	return nil
+}
+
+func (s *MemoryStore) LoadBanks() ([]bank.Bank, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	banks := make([]bank.Bank, 0, len(s.banks))
+	for _, b := range s.banks {
+		banks = append(banks, b)
+	}
+	sort.Slice(banks, func(i, j int) bool { return banks[i].BankID < banks[j].BankID })
+	return banks, nil
+}
+
+func (s *MemoryStore) SaveCustomer(c CustomerRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.customers[c.CustomerID] = c
+	return nil
+}
+
+func (s *MemoryStore) LoadCustomers() ([]CustomerRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	customers := make([]CustomerRecord, 0, len(s.customers))
+	for _, c := range s.customers {
+		customers = append(customers, c)
+	}
+	sort.Slice(customers, func(i, j int) bool { return customers[i].CustomerID < customers[j].CustomerID })
+	return customers, nil
+}
+
+func (s *MemoryStore) SaveAccount(a account.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[a.AccountID] = a
+	return nil
+}
+
+func (s *MemoryStore) LoadAccounts() ([]account.Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	accounts := make([]account.Snapshot, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		accounts = append(accounts, a)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountID < accounts[j].AccountID })
+	return accounts, nil
+}
+
+func (s *MemoryStore) SaveDues(dues []ledger.Due) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dues = append([]ledger.Due(nil), dues...)
+	return nil
+}
+
+func (s *MemoryStore) LoadDues() ([]ledger.Due, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]ledger.Due(nil), s.dues...), nil
+}
+
+func (s *MemoryStore) SaveSettlement(b ledger.SettlementBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settlements = append(s.settlements, b)
+	s.dues = nil
+	return nil
+}
+
+func (s *MemoryStore) LoadSettlements() ([]ledger.SettlementBatch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]ledger.SettlementBatch(nil), s.settlements...), nil
+}
+
+func (s *MemoryStore) AppendTransactions(accountID int, txns []account.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transactions[accountID] = append(s.transactions[accountID], txns...)
+	return nil
+}
+
+func (s *MemoryStore) LoadTransactions(accountID int) ([]account.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]account.Transaction(nil), s.transactions[accountID]...), nil
+}
+
+func (s *MemoryStore) SaveCredential(customerID int, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.credentials[customerID] = passwordHash
+	return nil
+}
+
+func (s *MemoryStore) LoadCredentials() (map[int]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	credentials := make(map[int]string, len(s.credentials))
+	for id, hash := range s.credentials {
+		credentials[id] = hash
+	}
+	return credentials, nil
+}
+
+func (s *MemoryStore) AppendAudit(e audit.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audit = append(s.audit, e)
+	return nil
+}
+
+func (s *MemoryStore) LoadAudit() ([]audit.Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]audit.Entry(nil), s.audit...), nil
+}
+
+func (s *MemoryStore) SaveAuditHead(h audit.Head) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditHead = h
+	return nil
+}
+
+func (s *MemoryStore) LoadAuditHead() (audit.Head, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.auditHead, nil
+}
+
+func (s *MemoryStore) AppendJournal(entries []journal.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journal = append(s.journal, entries...)
+	return nil
+}
+
+func (s *MemoryStore) LoadJournal() ([]journal.Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]journal.Entry(nil), s.journal...), nil
+}
+
+func (s *MemoryStore) SaveIdempotencyKey(r idempotency.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idempotency = append(s.idempotency, r)
+	return nil
+}
+
+func (s *MemoryStore) LoadIdempotencyKeys() ([]idempotency.Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]idempotency.Record(nil), s.idempotency...), nil
+}
+
+func (s *MemoryStore) SaveLimitIncrease(i account.LimitIncrease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.increases[i.AccountID] = i
+	return nil
+}
+
+func (s *MemoryStore) LoadLimitIncreases() ([]account.LimitIncrease, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	increases := make([]account.LimitIncrease, 0, len(s.increases))
+	for _, i := range s.increases {
+		increases = append(increases, i)
+	}
+	return increases, nil
+}
+
+func (s *MemoryStore) SaveReview(r risk.Review) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reviews[r.ReviewID] = r
+	return nil
+}
+
+func (s *MemoryStore) LoadReviews() ([]risk.Review, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	reviews := make([]risk.Review, 0, len(s.reviews))
+	for _, r := range s.reviews {
+		reviews = append(reviews, r)
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].ReviewID < reviews[j].ReviewID })
+	return reviews, nil
+}
+
+func (s *MemoryStore) SaveApproval(a joint.Approval) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.approvals[a.ApprovalID] = a
+	return nil
+}
+
+func (s *MemoryStore) LoadApprovals() ([]joint.Approval, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	approvals := make([]joint.Approval, 0, len(s.approvals))
+	for _, a := range s.approvals {
+		approvals = append(approvals, a)
+	}
+	sort.Slice(approvals, func(i, j int) bool { return approvals[i].ApprovalID < approvals[j].ApprovalID })
+	return approvals, nil
+}
+
+func (s *MemoryStore) SaveClosure(c closure.Closure) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closures[c.ClosureID] = c
+	return nil
+}
+
+func (s *MemoryStore) LoadClosures() ([]closure.Closure, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	closures := make([]closure.Closure, 0, len(s.closures))
+	for _, c := range s.closures {
+		closures = append(closures, c)
+	}
+	sort.Slice(closures, func(i, j int) bool { return closures[i].ClosureID < closures[j].ClosureID })
+	return closures, nil
+}
+
+func (s *MemoryStore) SaveBeneficiary(b beneficiary.Beneficiary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.beneficiaries[b.BeneficiaryID] = b
+	return nil
+}
+
+func (s *MemoryStore) LoadBeneficiaries() ([]beneficiary.Beneficiary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]beneficiary.Beneficiary, 0, len(s.beneficiaries))
+	for _, b := range s.beneficiaries {
+		list = append(list, b)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].BeneficiaryID < list[j].BeneficiaryID })
+	return list, nil
+}
+
+func (s *MemoryStore) SaveInstruction(in standing.Instruction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.instructions[in.InstructionID] = in
+	return nil
+}
+
+func (s *MemoryStore) LoadInstructions() ([]standing.Instruction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]standing.Instruction, 0, len(s.instructions))
+	for _, in := range s.instructions {
+		list = append(list, in)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].InstructionID < list[j].InstructionID })
+	return list, nil
+}
+
+func (s *MemoryStore) AppendExecution(e standing.Execution) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executions = append(s.executions, e)
+	return nil
+}
+
+func (s *MemoryStore) LoadExecutions() ([]standing.Execution, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]standing.Execution(nil), s.executions...), nil
+}
+
+func (s *MemoryStore) SaveEODRun(r eod.Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eodRuns = append(s.eodRuns, r)
+	return nil
+}
+
+func (s *MemoryStore) LoadEODRuns() ([]eod.Run, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]eod.Run(nil), s.eodRuns...), nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"banking-app/account"
+	"banking-app/audit"
+	"banking-app/bank"
+	"banking-app/beneficiary"
+	"banking-app/closure"
+	"banking-app/eod"
+	"banking-app/idempotency"
+	"banking-app/joint"
+	"banking-app/journal"
+	"banking-app/ledger"
+	"banking-app/risk"
+	"banking-app/standing"
+	"time"
+)
+
+// CustomerRecord covers customers and staff alike. IsAdmin is only set by
+// stores written before roles existed; Role supersedes it.
+type CustomerRecord struct {
+	CustomerID int
+	FirstName  string
+	LastName   string
+	IsAdmin    bool
+	Role       string
+	BankIDs    []int
+	IsActive   bool
+	DeceasedOn time.Time
+}
+
+type BankRepository interface {
+	SaveBank(b bank.Bank) error
+	DeleteBank(bankID int) error
+	LoadBanks() ([]bank.Bank, error)
+}
+
+type CustomerRepository interface {
+	SaveCustomer(c CustomerRecord) error
+	LoadCustomers() ([]CustomerRecord, error)
+}
+
+type AccountRepository interface {
+	SaveAccount(a account.Snapshot) error
+	LoadAccounts() ([]account.Snapshot, error)
+}
+
+type LedgerRepository interface {
+	SaveDues(dues []ledger.Due) error
+	LoadDues() ([]ledger.Due, error)
+}
+
+type TransactionRepository interface {
+	AppendTransactions(accountID int, txns []account.Transaction) error
+	LoadTransactions(accountID int) ([]account.Transaction, error)
+}
+
+// CredentialRepository holds password hashes, never plain secrets.
+type CredentialRepository interface {
+	SaveCredential(customerID int, passwordHash string) error
+	LoadCredentials() (map[int]string, error)
+}
+
+// AuditRepository only ever appends; entries are never rewritten. The head
+// is kept where removing entries does not remove it too.
+type AuditRepository interface {
+	AppendAudit(e audit.Entry) error
+	LoadAudit() ([]audit.Entry, error)
+	SaveAuditHead(h audit.Head) error
+	LoadAuditHead() (audit.Head, error)
+}
+
+// SettlementRepository archives settled batches. Saving a batch also clears
+// the stored dues it settled, in the same write, so a crash cannot leave a
+// batch archived with its dues still outstanding.
+type SettlementRepository interface {
+	SaveSettlement(b ledger.SettlementBatch) error
+	LoadSettlements() ([]ledger.SettlementBatch, error)
+}
+
+// JournalRepository only ever appends; entries are never rewritten.
+type JournalRepository interface {
+	AppendJournal(entries []journal.Entry) error
+	LoadJournal() ([]journal.Entry, error)
+}
+
+// IdempotencyRepository keeps the keys of completed requests. Expired keys
+// stay in the store and are ignored once loaded.
+type IdempotencyRepository interface {
+	SaveIdempotencyKey(r idempotency.Record) error
+	LoadIdempotencyKeys() ([]idempotency.Record, error)
+}
+
+type LimitRepository interface {
+	SaveLimitIncrease(i account.LimitIncrease) error
+	LoadLimitIncreases() ([]account.LimitIncrease, error)
+}
+
+// ReviewRepository keeps the transfer review queue. Saving a review again
+// records its decision.
+type ReviewRepository interface {
+	SaveReview(r risk.Review) error
+	LoadReviews() ([]risk.Review, error)
+}
+
+// ApprovalRepository keeps joint account debits waiting for their holders.
+// Saving an approval again records each holder's decision.
+type ApprovalRepository interface {
+	SaveApproval(a joint.Approval) error
+	LoadApprovals() ([]joint.Approval, error)
+}
+
+// ClosureRepository keeps how each closed account was settled.
+type ClosureRepository interface {
+	SaveClosure(c closure.Closure) error
+	LoadClosures() ([]closure.Closure, error)
+}
+
+// BeneficiaryRepository keeps deleted beneficiaries too; saving one again
+// records its new nickname or its deletion.
+type BeneficiaryRepository interface {
+	SaveBeneficiary(b beneficiary.Beneficiary) error
+	LoadBeneficiaries() ([]beneficiary.Beneficiary, error)
+}
+
+// StandingRepository keeps standing instructions, saved again whenever
+// their state changes, and appends the history of their runs.
+type StandingRepository interface {
+	SaveInstruction(in standing.Instruction) error
+	LoadInstructions() ([]standing.Instruction, error)
+	AppendExecution(e standing.Execution) error
+	LoadExecutions() ([]standing.Execution, error)
+}
+
+type EODRepository interface {
+	SaveEODRun(r eod.Run) error
+	LoadEODRuns() ([]eod.Run, error)
+}
+
+// Store bundles every repository the CustomerManager persists through.
+type Store interface {
+	BankRepository
+	CustomerRepository
+	AccountRepository
+	LedgerRepository
+	SettlementRepository
+	TransactionRepository
+	CredentialRepository
+	AuditRepository
+	JournalRepository
+	IdempotencyRepository
+	LimitRepository
+	ReviewRepository
+	ApprovalRepository
+	ClosureRepository
+	BeneficiaryRepository
+	StandingRepository
+	EODRepository
+}
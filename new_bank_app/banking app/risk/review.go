@@ -0,0 +1,35 @@
+package risk
+
+import (
+	"banking-app/money"
+	"time"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"
+	ReviewApproved ReviewStatus = "approved"
+	ReviewRejected ReviewStatus = "rejected"
+)
+
+// Review is a held transfer waiting in, or decided out of, the review
+// queue. Nothing has moved while it is pending.
+type Review struct {
+	ReviewID      int
+	CustomerID    int
+	ToCustomerID  int
+	FromAccountID int
+	ToAccountID   int
+	FromBankID    int
+	Amount        money.Money
+	// IdempotencyKey is the key the transfer was sent with, if any, so a
+	// retry finds this review instead of opening another.
+	IdempotencyKey string
+	Findings       []Finding
+	Status         ReviewStatus
+	HeldAt         time.Time
+	DecidedBy      int
+	DecidedAt      time.Time
+	Note           string
+}
@@ -0,0 +1,184 @@
+package risk
+
+import (
+	"banking-app/account"
+	"banking-app/money"
+	"fmt"
+	"time"
+)
+
+type Decision string
+
+const (
+	Allow Decision = "allow"
+	Hold  Decision = "hold"
+	Block Decision = "block"
+)
+
+var severity = map[Decision]int{Allow: 0, Hold: 1, Block: 2}
+
+// Transfer is what the rules see of a transfer before it moves money.
+// History is the source account's passbook, oldest first.
+type Transfer struct {
+	CustomerID    int
+	FromAccountID int
+	ToAccountID   int
+	Amount        money.Money
+	At            time.Time
+	OpenedAt      time.Time
+	History       []account.Transaction
+}
+
+// Rule is one check in the engine. It returns Allow when it finds nothing;
+// otherwise the reason says what it found.
+type Rule interface {
+	Name() string
+	Evaluate(t Transfer) (Decision, string, error)
+}
+
+// Finding is a rule that did not allow the transfer.
+type Finding struct {
+	Rule     string
+	Decision Decision
+	Reason   string
+}
+
+type Assessment struct {
+	Decision Decision
+	Findings []Finding
+}
+
+// Engine runs every rule and takes the strictest decision any of them
+// reached.
+type Engine struct {
+	rules []Rule
+}
+
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: append([]Rule(nil), rules...)}
+}
+
+func (e *Engine) Evaluate(t Transfer) (Assessment, error) {
+	a := Assessment{Decision: Allow}
+	for _, rule := range e.rules {
+		decision, reason, err := rule.Evaluate(t)
+		if err != nil {
+			return Assessment{}, err
+		}
+		if decision == Allow {
+			continue
+		}
+		a.Findings = append(a.Findings, Finding{Rule: rule.Name(), Decision: decision, Reason: reason})
+		if severity[decision] > severity[a.Decision] {
+			a.Decision = decision
+		}
+	}
+	return a, nil
+}
+
+// DefaultRules hold transfers that are large for the account or that leave
+// a newly opened account, and block bursts of transfers to new
+// beneficiaries.
+func DefaultRules() []Rule {
+	return []Rule{
+		LargeTransfer{Multiple: 5, MinHistory: 3, Decision: Hold},
+		NewBeneficiaryBurst{Window: 10 * time.Minute, Max: 3, Decision: Block},
+		NewAccountTransfer{Within: 24 * time.Hour, Decision: Hold},
+	}
+}
+
+// LargeTransfer flags a transfer more than Multiple times the account's
+// average outgoing payment, once the account has made MinHistory of them.
+type LargeTransfer struct {
+	Multiple   int64
+	MinHistory int
+	Decision   Decision
+}
+
+func (r LargeTransfer) Name() string { return "large-transfer" }
+
+func (r LargeTransfer) Evaluate(t Transfer) (Decision, string, error) {
+	var total money.Money
+	count := 0
+	for _, txn := range t.History {
+		if !isOutgoing(txn.Type) {
+			continue
+		}
+		var err error
+		if total, err = total.Add(txn.Amount); err != nil {
+			return "", "", err
+		}
+		count++
+	}
+	if count == 0 || count < r.MinHistory {
+		return Allow, "", nil
+	}
+	average, err := total.MulRat(1, int64(count), money.RoundHalfUp)
+	if err != nil {
+		return "", "", err
+	}
+	threshold, err := average.MulRat(r.Multiple, 1, money.RoundHalfUp)
+	if err != nil {
+		return "", "", err
+	}
+	over, err := threshold.LessThan(t.Amount)
+	if err != nil || !over {
+		return Allow, "", err
+	}
+	return r.Decision, fmt.Sprintf("%s is more than %d times the average outgoing payment of %s", t.Amount, r.Multiple, average), nil
+}
+
+// NewBeneficiaryBurst flags the transfer that makes more than Max transfers
+// within Window to accounts the customer had never paid before.
+type NewBeneficiaryBurst struct {
+	Window   time.Duration
+	Max      int
+	Decision Decision
+}
+
+func (r NewBeneficiaryBurst) Name() string { return "new-beneficiary-burst" }
+
+func (r NewBeneficiaryBurst) Evaluate(t Transfer) (Decision, string, error) {
+	since := t.At.Add(-r.Window)
+	known := make(map[int]bool)
+	recent := 0
+	for _, txn := range t.History {
+		if txn.Type != account.TxnExternalTransferOut {
+			continue
+		}
+		if txn.Timestamp.Before(since) {
+			known[txn.CounterpartyAccountID] = true
+			continue
+		}
+		if !known[txn.CounterpartyAccountID] {
+			recent++
+		}
+	}
+	if known[t.ToAccountID] {
+		return Allow, "", nil
+	}
+	if recent+1 <= r.Max {
+		return Allow, "", nil
+	}
+	return r.Decision, fmt.Sprintf("%d transfers to new beneficiaries within %s", recent+1, r.Window), nil
+}
+
+// NewAccountTransfer flags money leaving an account within Within of it
+// being opened.
+type NewAccountTransfer struct {
+	Within   time.Duration
+	Decision Decision
+}
+
+func (r NewAccountTransfer) Name() string { return "new-account-transfer" }
+
+func (r NewAccountTransfer) Evaluate(t Transfer) (Decision, string, error) {
+	if t.OpenedAt.IsZero() || !t.At.Before(t.OpenedAt.Add(r.Within)) {
+		return Allow, "", nil
+	}
+	return r.Decision, fmt.Sprintf("account was opened %s ago", t.At.Sub(t.OpenedAt).Round(time.Minute)), nil
+}
+
+func isOutgoing(t account.TransactionType) bool {
+	return t == account.TxnWithdrawal || t == account.TxnExternalTransferOut || t == account.TxnInternalTransferOut
+}
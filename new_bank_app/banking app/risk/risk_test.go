@@ -0,0 +1,176 @@
+package risk
+
+import (
+	"banking-app/account"
+	"banking-app/money"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, time.March, 14, 15, 0, 0, 0, time.UTC)
+
+func inr(major int64) money.Money { return money.MustFromMajor(major, money.INR) }
+
+func out(txnType account.TransactionType, major int64, toAccountID int, at time.Time) account.Transaction {
+	return account.Transaction{Type: txnType, Amount: inr(major), CounterpartyAccountID: toAccountID, Timestamp: at}
+}
+
+func TestLargeTransfer(t *testing.T) {
+	rule := LargeTransfer{Multiple: 5, MinHistory: 3, Decision: Hold}
+	// Outgoing payments average ₹1,000; the deposit does not count.
+	history := []account.Transaction{
+		out(account.TxnDeposit, 100000, 0, now.AddDate(0, -1, 0)),
+		out(account.TxnWithdrawal, 500, 0, now.AddDate(0, 0, -20)),
+		out(account.TxnExternalTransferOut, 1000, 42, now.AddDate(0, 0, -10)),
+		out(account.TxnInternalTransferOut, 1500, 43, now.AddDate(0, 0, -5)),
+	}
+	tests := []struct {
+		name    string
+		amount  money.Money
+		history []account.Transaction
+		want    Decision
+	}{
+		{name: "at the threshold", amount: inr(5000), history: history, want: Allow},
+		{name: "over the threshold", amount: money.New(500001, money.INR), history: history, want: Hold},
+		{name: "too little history", amount: inr(100000), history: history[:3], want: Allow},
+		{name: "no history", amount: inr(100000), want: Allow},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, reason, err := rule.Evaluate(Transfer{Amount: tc.amount, At: now, History: tc.history})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("decision = %s (%s), want %s", got, reason, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewBeneficiaryBurst(t *testing.T) {
+	rule := NewBeneficiaryBurst{Window: 10 * time.Minute, Max: 3, Decision: Block}
+	recently := now.Add(-5 * time.Minute)
+	known := out(account.TxnExternalTransferOut, 100, 42, now.Add(-time.Hour))
+	tests := []struct {
+		name    string
+		to      int
+		history []account.Transaction
+		want    Decision
+	}{
+		{
+			name: "third new beneficiary", to: 3,
+			history: []account.Transaction{out(account.TxnExternalTransferOut, 100, 1, recently), out(account.TxnExternalTransferOut, 100, 2, recently)},
+			want:    Allow,
+		},
+		{
+			name: "fourth new beneficiary", to: 4,
+			history: []account.Transaction{
+				out(account.TxnExternalTransferOut, 100, 1, recently),
+				out(account.TxnExternalTransferOut, 100, 2, recently),
+				out(account.TxnExternalTransferOut, 100, 3, recently),
+			},
+			want: Block,
+		},
+		{
+			name: "paying someone paid before the window", to: 42,
+			history: []account.Transaction{
+				known,
+				out(account.TxnExternalTransferOut, 100, 1, recently),
+				out(account.TxnExternalTransferOut, 100, 2, recently),
+				out(account.TxnExternalTransferOut, 100, 3, recently),
+			},
+			want: Allow,
+		},
+		{
+			name: "payments to known beneficiaries do not count", to: 4,
+			history: []account.Transaction{
+				known,
+				out(account.TxnExternalTransferOut, 100, 42, recently),
+				out(account.TxnExternalTransferOut, 100, 1, recently),
+				out(account.TxnExternalTransferOut, 100, 2, recently),
+			},
+			want: Allow,
+		},
+		{
+			name: "payments before the window do not count", to: 4,
+			history: []account.Transaction{
+				out(account.TxnExternalTransferOut, 100, 1, now.Add(-11*time.Minute)),
+				out(account.TxnExternalTransferOut, 100, 2, recently),
+				out(account.TxnExternalTransferOut, 100, 3, recently),
+			},
+			want: Allow,
+		},
+		{
+			name: "transfers between own accounts do not count", to: 4,
+			history: []account.Transaction{
+				out(account.TxnInternalTransferOut, 100, 1, recently),
+				out(account.TxnExternalTransferOut, 100, 2, recently),
+				out(account.TxnExternalTransferOut, 100, 3, recently),
+			},
+			want: Allow,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, reason, err := rule.Evaluate(Transfer{ToAccountID: tc.to, Amount: inr(100), At: now, History: tc.history})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("decision = %s (%s), want %s", got, reason, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewAccountTransfer(t *testing.T) {
+	rule := NewAccountTransfer{Within: 24 * time.Hour, Decision: Hold}
+	tests := []struct {
+		name     string
+		openedAt time.Time
+		want     Decision
+	}{
+		{name: "opened an hour ago", openedAt: now.Add(-time.Hour), want: Hold},
+		{name: "opened a day ago", openedAt: now.Add(-24 * time.Hour), want: Allow},
+		{name: "opening time unknown", want: Allow},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, reason, err := rule.Evaluate(Transfer{Amount: inr(100), At: now, OpenedAt: tc.openedAt})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("decision = %s (%s), want %s", got, reason, tc.want)
+			}
+		})
+	}
+}
+
+func TestEngineTakesTheStrictestDecision(t *testing.T) {
+	e := NewEngine(DefaultRules()...)
+	history := []account.Transaction{
+		out(account.TxnExternalTransferOut, 100, 1, now.Add(-time.Minute)),
+		out(account.TxnExternalTransferOut, 100, 2, now.Add(-time.Minute)),
+		out(account.TxnExternalTransferOut, 100, 3, now.Add(-time.Minute)),
+	}
+	a, err := e.Evaluate(Transfer{ToAccountID: 4, Amount: inr(100000), At: now, OpenedAt: now.Add(-time.Hour), History: history})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Decision != Block {
+		t.Errorf("decision = %s, want %s", a.Decision, Block)
+	}
+	if len(a.Findings) != 3 {
+		t.Errorf("findings = %v, want one from each rule", a.Findings)
+	}
+
+	a, err = e.Evaluate(Transfer{ToAccountID: 1, Amount: inr(100), At: now, OpenedAt: now.AddDate(0, -1, 0), History: history[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Decision != Allow || len(a.Findings) != 0 {
+		t.Errorf("assessment = %+v, want allow with no findings", a)
+	}
+}
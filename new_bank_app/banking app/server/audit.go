@@ -0,0 +1,55 @@
+package server
+
+import (
+	"banking-app/audit"
+	"banking-app/auth"
+	"net/http"
+)
+
+func (s *Server) handleQueryAudit(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	actorID, err := queryInt(r, "actor_id", 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resourceID, err := queryInt(r, "resource_id", 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	since, err := queryTime(r, "since")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	until, err := queryTime(r, "until")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	entries, err := s.manager.QueryAuditLog(p, audit.Filter{
+		ActorID:    actorID,
+		Action:     audit.Action(r.URL.Query().Get("action")),
+		Resource:   r.URL.Query().Get("resource"),
+		ResourceID: resourceID,
+		Since:      since,
+		Until:      until,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	views := make([]auditEntryView, 0, len(entries))
+	for _, e := range entries {
+		views = append(views, newAuditEntryView(e))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleVerifyAudit(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	if err := s.manager.VerifyAuditLog(p); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"valid": true})
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"banking-app/apperror"
+	"banking-app/auth"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type authedHandler func(w http.ResponseWriter, r *http.Request, p *auth.Principal)
+
+// authenticated resolves the bearer token into a principal before calling
+// next, so handlers never take the acting customer from the request body.
+func (s *Server) authenticated(next authedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeError(w, apperror.NewAuthError("call this endpoint without a bearer token"))
+			return
+		}
+		p, err := s.manager.Authenticate(token)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		next(w, r, p)
+	}
+}
+
+// requireSelf rejects requests whose {customerID} path segment names someone
+// other than the logged-in customer.
+func requireSelf(r *http.Request, p *auth.Principal) error {
+	customerID, err := pathID(r, "customerID")
+	if err != nil {
+		return err
+	}
+	if customerID != p.CustomerID() {
+		return apperror.NewAuthError("act on another customer's resources")
+	}
+	return nil
+}
+
+type loginRequest struct {
+	CustomerID int    `json:"customer_id"`
+	Password   string `json:"password"`
+}
+
+type sessionView struct {
+	Token      string    `json:"token"`
+	CustomerID int       `json:"customer_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type setPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+type changePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	token, p, err := s.manager.Login(req.CustomerID, req.Password)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sessionView{Token: token, CustomerID: p.CustomerID(), ExpiresAt: p.ExpiresAt()})
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	if err := s.manager.Logout(p); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleSetPassword(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	customerID, err := pathID(r, "customerID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req setPasswordRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.SetPassword(p, customerID, req.Password); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	var req changePasswordRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.ChangePassword(p, req.OldPassword, req.NewPassword); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"banking-app/auth"
+	"banking-app/beneficiary"
+	"net/http"
+)
+
+type beneficiaryRequest struct {
+	AccountNumber string `json:"account_number"`
+	Nickname      string `json:"nickname"`
+}
+
+type renameBeneficiaryRequest struct {
+	Nickname string `json:"nickname"`
+}
+
+func (s *Server) handleListBeneficiaries(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	list, err := s.manager.Beneficiaries(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	policy := s.manager.BeneficiaryPolicy()
+	views := make([]beneficiaryView, 0, len(list))
+	for _, b := range list {
+		views = append(views, newBeneficiaryView(b, policy))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleAddBeneficiary(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	var req beneficiaryRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	b, err := s.manager.AddBeneficiary(p, req.AccountNumber, req.Nickname)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.writeBeneficiary(w, http.StatusCreated, b)
+}
+
+func (s *Server) handleGetBeneficiary(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	beneficiaryID, err := pathID(r, "beneficiaryID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	b, err := s.manager.Beneficiary(p, beneficiaryID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.writeBeneficiary(w, http.StatusOK, b)
+}
+
+func (s *Server) handleRenameBeneficiary(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	beneficiaryID, err := pathID(r, "beneficiaryID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req renameBeneficiaryRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	b, err := s.manager.RenameBeneficiary(p, beneficiaryID, req.Nickname)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.writeBeneficiary(w, http.StatusOK, b)
+}
+
+func (s *Server) handleDeleteBeneficiary(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	beneficiaryID, err := pathID(r, "beneficiaryID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.DeleteBeneficiary(p, beneficiaryID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) writeBeneficiary(w http.ResponseWriter, status int, b beneficiary.Beneficiary) {
+	writeJSON(w, status, newBeneficiaryView(b, s.manager.BeneficiaryPolicy()))
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"banking-app/auth"
+	"net/http"
+)
+
+type branchRequest struct {
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
+
+func (s *Server) handleOpenBranch(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	bankID, err := pathID(r, "bankID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req branchRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	branch, err := s.manager.OpenBranch(p, bankID, req.Name, req.Code)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newBranchView(branch))
+}
+
+func (s *Server) handleGetBankByCode(w http.ResponseWriter, r *http.Request) {
+	b, err := s.manager.GetBankByCode(r.PathValue("code"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newBankView(*b))
+}
+
+func (s *Server) handleGetBranchByRoutingCode(w http.ResponseWriter, r *http.Request) {
+	_, branch, err := s.manager.GetBranchByRoutingCode(r.PathValue("routingCode"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newBranchView(branch))
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/auth"
+	"banking-app/closure"
+	"net/http"
+	"time"
+)
+
+type payoutRequest struct {
+	NomineeID       int    `json:"nominee_id"`
+	Method          string `json:"method"`
+	ToAccountNumber string `json:"to_account_number"`
+}
+
+func (req payoutRequest) toPayout() closure.Payout {
+	return closure.Payout{NomineeID: req.NomineeID, Method: closure.Method(req.Method), ToAccountNumber: req.ToAccountNumber}
+}
+
+type nomineeClaimRequest struct {
+	Payouts []payoutRequest `json:"payouts"`
+}
+
+type nomineesRequest struct {
+	Nominees []struct {
+		Name         string `json:"name"`
+		Relationship string `json:"relationship"`
+		SharePercent int    `json:"share_percent"`
+	} `json:"nominees"`
+}
+
+type deathRequest struct {
+	DiedOn string `json:"died_on"`
+}
+
+// handleCloseAccount settles and closes the account, paying out what is
+// left as the request says.
+func (s *Server) handleCloseAccount(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req payoutRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	c, err := s.manager.CloseAccount(p, accountID, req.toPayout())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newClosureView(c))
+}
+
+func (s *Server) handleGetClosure(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	c, err := s.manager.AccountClosure(p, accountID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newClosureView(c))
+}
+
+func (s *Server) handleSetNominees(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req nomineesRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	nominees := make([]account.Nominee, len(req.Nominees))
+	for i, n := range req.Nominees {
+		nominees[i] = account.Nominee{Name: n.Name, Relationship: n.Relationship, SharePercent: n.SharePercent}
+	}
+	if _, err := s.manager.SetNominees(p, accountID, nominees); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.handleGetAccount(w, r, p)
+}
+
+func (s *Server) handleRecordDeath(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	customerID, err := pathID(r, "customerID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req deathRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	diedOn, err := time.Parse(time.DateOnly, req.DiedOn)
+	if err != nil {
+		writeError(w, apperror.NewValidationError("died_on", "must be a date like 2006-01-02"))
+		return
+	}
+	if err := s.manager.RecordDeath(p, customerID, diedOn); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleNomineeClaim(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req nomineeClaimRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	payouts := make([]closure.Payout, len(req.Payouts))
+	for i, po := range req.Payouts {
+		payouts[i] = po.toPayout()
+	}
+	c, err := s.manager.SettleNomineeClaim(p, accountID, payouts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newClosureView(c))
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/auth"
+	"banking-app/eod"
+	"net/http"
+	"time"
+)
+
+type interestRateRequest struct {
+	RateBPS int64 `json:"rate_bps"`
+}
+
+type endOfDayRequest struct {
+	BusinessDate string `json:"business_date"`
+}
+
+func (s *Server) handleSetInterestRate(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	bankID, err := pathID(r, "bankID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	product, err := account.ParseProduct(r.PathValue("product"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req interestRateRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.SetInterestRate(p, bankID, product, req.RateBPS); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.handleGetBank(w, r)
+}
+
+// handleRunEndOfDay closes the requested business date, or every day that
+// is due when none is given.
+func (s *Server) handleRunEndOfDay(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	var req endOfDayRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	var runs []eod.Run
+	if req.BusinessDate == "" {
+		var err error
+		if runs, err = s.manager.CloseDueDays(p); err != nil {
+			writeError(w, err)
+			return
+		}
+	} else {
+		date, err := time.Parse(time.DateOnly, req.BusinessDate)
+		if err != nil {
+			writeError(w, apperror.NewValidationError("business_date", "must be a date like 2006-01-02"))
+			return
+		}
+		run, err := s.manager.RunEndOfDay(p, date)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		runs = append(runs, run)
+	}
+	writeJSON(w, http.StatusOK, newEODRunViews(runs))
+}
+
+func (s *Server) handleListEndOfDayRuns(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	runs, err := s.manager.EndOfDayRuns(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newEODRunViews(runs))
+}
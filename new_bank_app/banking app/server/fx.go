@@ -0,0 +1,15 @@
+package server
+
+import (
+	"banking-app/auth"
+	"net/http"
+)
+
+func (s *Server) handleQuote(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	q, err := s.manager.Quote(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newQuoteView(q))
+}
@@ -0,0 +1,458 @@
+package server
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/auth"
+	"banking-app/customer"
+	"banking-app/money"
+	"net/http"
+	"sort"
+)
+
+type bankRequest struct {
+	Name string `json:"name"`
+	Code string `json:"code"`
+}
+
+type customerRequest struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type openAccountRequest struct {
+	BankID         int           `json:"bank_id"`
+	BranchID       int           `json:"branch_id"`
+	Product        string        `json:"product"`
+	Currency       string        `json:"currency"`
+	OpeningDeposit amountRequest `json:"opening_deposit"`
+}
+
+type externalTransferRequest struct {
+	amountRequest
+	FromAccountID int `json:"from_account_id"`
+	BeneficiaryID int `json:"beneficiary_id"`
+}
+
+type internalTransferRequest struct {
+	amountRequest
+	FromAccountID   int    `json:"from_account_id"`
+	ToAccountNumber string `json:"to_account_number"`
+}
+
+func (s *Server) handleListBanks(w http.ResponseWriter, r *http.Request) {
+	banks := s.manager.GetAllBanks()
+	views := make([]bankView, 0, len(banks))
+	for _, b := range banks {
+		views = append(views, newBankView(b))
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].BankID < views[j].BankID })
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleCreateBank(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	var req bankRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	b, err := s.manager.CreateNewBank(p, req.Name, req.Code)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newBankView(*b))
+}
+
+func (s *Server) handleGetBank(w http.ResponseWriter, r *http.Request) {
+	bankID, err := pathID(r, "bankID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	b, err := s.manager.GetBankById(bankID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newBankView(*b))
+}
+
+func (s *Server) handleRenameBank(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	bankID, err := pathID(r, "bankID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req bankRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Code != "" {
+		writeError(w, apperror.NewValidationError("code", "a bank keeps its code when it is renamed"))
+		return
+	}
+	if err := s.manager.UpdateBankName(p, bankID, req.Name); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.handleGetBank(w, r)
+}
+
+func (s *Server) handleDeleteBank(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	bankID, err := pathID(r, "bankID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.DeleteBank(p, bankID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleBankPosition(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	bankID, err := pathID(r, "bankID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	currency := queryCurrency(r)
+	actual, receivable, owed, err := s.manager.GetBankPosition(p, bankID, currency)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, positionView{
+		BankID:     bankID,
+		Currency:   actual.Currency,
+		Actual:     newMoneyView(actual),
+		Receivable: newMoneyView(receivable),
+		Owed:       newMoneyView(owed),
+	})
+}
+
+func (s *Server) handleLedger(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	dues, err := s.manager.GetDues(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newDueViews(dues))
+}
+
+func (s *Server) handleListCustomers(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	customers, err := s.manager.GetAllCustomers(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	views := make([]customerView, 0, len(customers))
+	for _, c := range customers {
+		views = append(views, newCustomerView(c))
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].CustomerID < views[j].CustomerID })
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleCreateCustomer(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	var req customerRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	c, err := s.manager.CreateNewCustomer(p, req.FirstName, req.LastName)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newCustomerView(*c))
+}
+
+func (s *Server) handleGetCustomer(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	customerID, err := pathID(r, "customerID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	c, err := s.manager.GetCustomerById(p, customerID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newCustomerView(*c))
+}
+
+func (s *Server) handleUpdateCustomer(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	customerID, err := pathID(r, "customerID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req customerRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.UpdateCustomer(p, customerID, req.FirstName, req.LastName); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.handleGetCustomer(w, r, p)
+}
+
+func (s *Server) handleDeleteCustomer(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	customerID, err := pathID(r, "customerID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.DeleteCustomer(p, customerID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleCustomerBalance(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	customerID, err := pathID(r, "customerID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	total, err := s.manager.GetTotalBalanceBy_Customer_Id(p, customerID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newMoneyView(total))
+}
+
+func (s *Server) handleOpenAccount(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	customerID, err := pathID(r, "customerID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req openAccountRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	product, err := account.ParseProduct(req.Product)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = customer.BaseCurrency
+	}
+	if req.OpeningDeposit.Currency == "" {
+		req.OpeningDeposit.Currency = req.Currency
+	}
+	deposit, err := req.OpeningDeposit.toMoney()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	acc, err := s.manager.CreateAccountForCustomer(p, customerID, req.BankID, req.BranchID, req.Currency, product, deposit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newAccountView(acc))
+}
+
+func (s *Server) handleCloseCustomerAccount(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	if err := requireSelf(r, p); err != nil {
+		writeError(w, err)
+		return
+	}
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.DeleteCustomerAccountById(p, accountID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePassbook(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	if err := requireSelf(r, p); err != nil {
+		writeError(w, err)
+		return
+	}
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	page, err := queryInt(r, "page", 1)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	txns, err := s.manager.GetPassBook_ById(p, accountID, page)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	views := make([]transactionView, 0, len(txns))
+	for _, t := range txns {
+		views = append(views, newTransactionView(t))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleGetAccount(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	acc, err := s.manager.ViewAccount(p, accountID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newAccountView(acc))
+}
+
+func (s *Server) handleOverrideBalance(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req amountRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	balance, err := req.toMoney()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.UpdateAccount(p, accountID, balance); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.handleGetAccount(w, r, p)
+}
+
+func (s *Server) handleDeleteAccount(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.DeleteAccountById(p, accountID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeposit(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	s.handleAmount(w, r, p, s.manager.DepositMoney)
+}
+
+func (s *Server) handleWithdraw(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	s.handleAmount(w, r, p, s.manager.WithDrawMoney)
+}
+
+func (s *Server) handleAmount(w http.ResponseWriter, r *http.Request, p *auth.Principal, apply func(p *auth.Principal, amount money.Money, accountID int, idempotencyKey ...string) error) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req amountRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	amount, err := req.toMoney()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := apply(p, amount, accountID, idempotencyKey(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.handleGetAccount(w, r, p)
+}
+
+func (s *Server) handleExternalTransfer(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	var req externalTransferRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	amount, err := req.toMoney()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	b, err := s.manager.Beneficiary(p, req.BeneficiaryID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.TransferToBeneficiary(p, req.FromAccountID, req.BeneficiaryID, amount, idempotencyKey(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.writeTransferResult(w, p, req.FromAccountID, b.AccountID)
+}
+
+func (s *Server) handleInternalTransfer(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	var req internalTransferRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	amount, err := req.toMoney()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.TransferMoneyInternally(p, req.FromAccountID, req.ToAccountNumber, amount, idempotencyKey(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+	to, err := s.manager.GetAccountByNumber(p, req.ToAccountNumber)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.writeTransferResult(w, p, req.FromAccountID, to.AccountID)
+}
+
+// idempotencyKey is the client's Idempotency-Key header. A retry carrying
+// the same key is answered without moving the money again.
+func idempotencyKey(r *http.Request) string {
+	return r.Header.Get("Idempotency-Key")
+}
+
+// writeTransferResult answers a transfer that went through with both
+// accounts, leaving out the one it paid into unless p may view it too.
+func (s *Server) writeTransferResult(w http.ResponseWriter, p *auth.Principal, fromAccountID, toAccountID int) {
+	from, err := s.manager.ViewAccount(p, fromAccountID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	result := map[string]accountView{"from": newAccountView(from)}
+	if to, err := s.manager.ViewAccount(p, toAccountID); err == nil {
+		result["to"] = newAccountView(to)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"banking-app/account"
+	"banking-app/apperror"
+	"banking-app/auth"
+	"banking-app/joint"
+	"net/http"
+)
+
+type holderRequest struct {
+	CustomerID int `json:"customer_id"`
+}
+
+type operatingModeRequest struct {
+	Mode            string `json:"mode"`
+	RequiredHolders int    `json:"required_holders"`
+}
+
+func (s *Server) handleAddAccountHolder(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req holderRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.AddAccountHolder(p, accountID, req.CustomerID); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.handleGetAccount(w, r, p)
+}
+
+func (s *Server) handleSetOperatingMode(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req operatingModeRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	mode, err := account.ParseOperatingMode(req.Mode)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.SetOperatingMode(p, accountID, mode, req.RequiredHolders); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.handleGetAccount(w, r, p)
+}
+
+func (s *Server) handleListApprovals(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	status := joint.Status(r.URL.Query().Get("status"))
+	switch status {
+	case "", joint.StatusPending, joint.StatusApproved, joint.StatusRejected:
+	default:
+		writeError(w, apperror.NewValidationError("status", "must be pending, approved or rejected"))
+		return
+	}
+	approvals, err := s.manager.Approvals(p, status)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	views := make([]approvalView, 0, len(approvals))
+	for _, a := range approvals {
+		views = append(views, newApprovalView(a))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleGetApproval(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	approvalID, err := pathID(r, "approvalID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	approval, err := s.manager.Approval(p, approvalID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newApprovalView(approval))
+}
+
+func (s *Server) handleConfirmApproval(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	s.handleDecideApproval(w, r, p, s.manager.ConfirmApproval)
+}
+
+func (s *Server) handleRejectApproval(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	s.handleDecideApproval(w, r, p, s.manager.RejectApproval)
+}
+
+// handleDecideApproval answers with the approval. A transfer the last
+// approval sent into the review queue is answered with a 202 instead.
+func (s *Server) handleDecideApproval(w http.ResponseWriter, r *http.Request, p *auth.Principal, decide func(p *auth.Principal, approvalID int) (joint.Approval, error)) {
+	approvalID, err := pathID(r, "approvalID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	approval, err := decide(p, approvalID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newApprovalView(approval))
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"banking-app/auth"
+	"net/http"
+)
+
+func (s *Server) handleTrialBalance(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	tbs, err := s.manager.TrialBalances(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	views := make([]trialBalanceView, 0, len(tbs))
+	for _, tb := range tbs {
+		views = append(views, newTrialBalanceView(tb))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleVerifyJournal(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	if err := s.manager.VerifyJournal(p); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"valid": true})
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"banking-app/account"
+	"banking-app/auth"
+	"banking-app/money"
+	"net/http"
+	"time"
+)
+
+// limitsRequest leaves out, or sets to zero, every limit that should not
+// apply.
+type limitsRequest struct {
+	PerTransaction      *amountRequest `json:"per_transaction"`
+	DailyWithdrawal     *amountRequest `json:"daily_withdrawal"`
+	MonthlyWithdrawal   *amountRequest `json:"monthly_withdrawal"`
+	DailyTransfers      int            `json:"daily_transfers"`
+	DailyTransferAmount *amountRequest `json:"daily_transfer_amount"`
+}
+
+func (req limitsRequest) toLimits() (account.Limits, error) {
+	limits := account.Limits{DailyTransfers: req.DailyTransfers}
+	for _, f := range []struct {
+		req *amountRequest
+		to  *money.Money
+	}{
+		{req.PerTransaction, &limits.PerTransaction},
+		{req.DailyWithdrawal, &limits.DailyWithdrawal},
+		{req.MonthlyWithdrawal, &limits.MonthlyWithdrawal},
+		{req.DailyTransferAmount, &limits.DailyTransferAmount},
+	} {
+		if f.req == nil {
+			continue
+		}
+		m, err := f.req.toMoney()
+		if err != nil {
+			return account.Limits{}, err
+		}
+		*f.to = m
+	}
+	return limits, nil
+}
+
+type limitIncreaseRequest struct {
+	limitsRequest
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (s *Server) handleSetLimits(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	bankID, err := pathID(r, "bankID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	product, err := account.ParseProduct(r.PathValue("product"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req limitsRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	limits, err := req.toLimits()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.SetLimits(p, bankID, product, limits); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.handleGetBank(w, r)
+}
+
+func (s *Server) handleGrantLimitIncrease(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req limitIncreaseRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	limits, err := req.toLimits()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	increase, err := s.manager.GrantLimitIncrease(p, accountID, limits, req.ExpiresAt)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newLimitIncreaseView(increase))
+}
+
+func (s *Server) handleAccountLimits(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	limits, err := s.manager.AccountLimits(p, accountID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newAccountLimitsView(limits))
+}
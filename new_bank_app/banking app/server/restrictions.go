@@ -0,0 +1,87 @@
+package server
+
+import (
+	"banking-app/account"
+	"banking-app/auth"
+	"net/http"
+	"time"
+)
+
+type freezeRequest struct {
+	Freeze string `json:"freeze"`
+	Reason string `json:"reason"`
+}
+
+type lienRequest struct {
+	amountRequest
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleFreezeAccount sets what the account is frozen against; an empty
+// freeze lifts it.
+func (s *Server) handleFreezeAccount(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req freezeRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	freeze, err := account.ParseFreeze(req.Freeze)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.FreezeAccount(p, accountID, freeze, req.Reason); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.handleGetAccount(w, r, p)
+}
+
+func (s *Server) handlePlaceLien(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req lienRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	amount, err := req.toMoney()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	lien, err := s.manager.PlaceLien(p, accountID, amount, req.Reason, req.ExpiresAt)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newLienView(lien))
+}
+
+func (s *Server) handleReleaseLien(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	accountID, err := pathID(r, "accountID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	lienID, err := pathID(r, "lienID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	lien, err := s.manager.ReleaseLien(p, accountID, lienID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newLienView(lien))
+}
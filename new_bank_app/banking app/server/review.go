@@ -0,0 +1,73 @@
+package server
+
+import (
+	"banking-app/apperror"
+	"banking-app/auth"
+	"banking-app/risk"
+	"net/http"
+)
+
+type reviewDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+func (s *Server) handleListReviews(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	status := risk.ReviewStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", risk.ReviewPending, risk.ReviewApproved, risk.ReviewRejected:
+	default:
+		writeError(w, apperror.NewValidationError("status", "must be pending, approved or rejected"))
+		return
+	}
+	reviews, err := s.manager.TransferReviews(p, status)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	views := make([]reviewView, 0, len(reviews))
+	for _, rv := range reviews {
+		views = append(views, newReviewView(rv))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleGetReview(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	reviewID, err := pathID(r, "reviewID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	review, err := s.manager.TransferReview(p, reviewID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newReviewView(review))
+}
+
+func (s *Server) handleApproveReview(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	s.handleDecideReview(w, r, p, s.manager.ApproveTransfer)
+}
+
+func (s *Server) handleRejectReview(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	s.handleDecideReview(w, r, p, s.manager.RejectTransfer)
+}
+
+func (s *Server) handleDecideReview(w http.ResponseWriter, r *http.Request, p *auth.Principal, decide func(p *auth.Principal, reviewID int, note string) (risk.Review, error)) {
+	reviewID, err := pathID(r, "reviewID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req reviewDecisionRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	review, err := decide(p, reviewID, req.Note)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newReviewView(review))
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"banking-app/apperror"
+	"banking-app/customer"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// maxBodyBytes bounds every request body. The largest request the API
+// takes is a few hundred bytes of JSON.
+const maxBodyBytes = 64 << 10
+
+type Server struct {
+	manager *customer.CustomerManager
+	mux     *http.ServeMux
+}
+
+func New(manager *customer.CustomerManager) *Server {
+	s := &Server{manager: manager, mux: http.NewServeMux()}
+	s.routes()
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /openapi.yaml", s.handleOpenAPI)
+
+	s.mux.HandleFunc("POST /sessions", s.handleLogin)
+	s.mux.HandleFunc("DELETE /sessions", s.authenticated(s.handleLogout))
+	s.mux.HandleFunc("PUT /me/password", s.authenticated(s.handleChangePassword))
+
+	s.mux.HandleFunc("GET /banks", s.handleListBanks)
+	s.mux.HandleFunc("POST /banks", s.authenticated(s.handleCreateBank))
+	s.mux.HandleFunc("GET /banks/{bankID}", s.handleGetBank)
+	s.mux.HandleFunc("PATCH /banks/{bankID}", s.authenticated(s.handleRenameBank))
+	s.mux.HandleFunc("DELETE /banks/{bankID}", s.authenticated(s.handleDeleteBank))
+	s.mux.HandleFunc("POST /banks/{bankID}/branches", s.authenticated(s.handleOpenBranch))
+	s.mux.HandleFunc("GET /bank-codes/{code}", s.handleGetBankByCode)
+	s.mux.HandleFunc("GET /routing-codes/{routingCode}", s.handleGetBranchByRoutingCode)
+	s.mux.HandleFunc("GET /banks/{bankID}/position", s.authenticated(s.handleBankPosition))
+	s.mux.HandleFunc("PUT /banks/{bankID}/interest-rates/{product}", s.authenticated(s.handleSetInterestRate))
+	s.mux.HandleFunc("PUT /banks/{bankID}/limits/{product}", s.authenticated(s.handleSetLimits))
+	s.mux.HandleFunc("GET /banks/{bankID}/reconciliation", s.authenticated(s.handleReconcileBank))
+	s.mux.HandleFunc("GET /ledger", s.authenticated(s.handleLedger))
+	s.mux.HandleFunc("GET /settlements", s.authenticated(s.handleListSettlements))
+	s.mux.HandleFunc("POST /settlements", s.authenticated(s.handleSettle))
+	s.mux.HandleFunc("GET /settlements/{batchID}", s.authenticated(s.handleGetSettlement))
+
+	s.mux.HandleFunc("GET /customers", s.authenticated(s.handleListCustomers))
+	s.mux.HandleFunc("POST /customers", s.authenticated(s.handleCreateCustomer))
+	s.mux.HandleFunc("GET /customers/{customerID}", s.authenticated(s.handleGetCustomer))
+	s.mux.HandleFunc("PATCH /customers/{customerID}", s.authenticated(s.handleUpdateCustomer))
+	s.mux.HandleFunc("DELETE /customers/{customerID}", s.authenticated(s.handleDeleteCustomer))
+	s.mux.HandleFunc("POST /customers/{customerID}/death", s.authenticated(s.handleRecordDeath))
+	s.mux.HandleFunc("GET /customers/{customerID}/balance", s.authenticated(s.handleCustomerBalance))
+	s.mux.HandleFunc("PUT /customers/{customerID}/password", s.authenticated(s.handleSetPassword))
+	s.mux.HandleFunc("POST /customers/{customerID}/accounts", s.authenticated(s.handleOpenAccount))
+	s.mux.HandleFunc("DELETE /customers/{customerID}/accounts/{accountID}", s.authenticated(s.handleCloseCustomerAccount))
+	s.mux.HandleFunc("GET /customers/{customerID}/accounts/{accountID}/passbook", s.authenticated(s.handlePassbook))
+
+	s.mux.HandleFunc("POST /staff", s.authenticated(s.handleCreateStaff))
+	s.mux.HandleFunc("PUT /staff/{staffID}/role", s.authenticated(s.handleUpdateStaffRole))
+
+	s.mux.HandleFunc("GET /accounts/{accountID}", s.authenticated(s.handleGetAccount))
+	s.mux.HandleFunc("PUT /accounts/{accountID}/balance", s.authenticated(s.handleOverrideBalance))
+	s.mux.HandleFunc("DELETE /accounts/{accountID}", s.authenticated(s.handleDeleteAccount))
+	s.mux.HandleFunc("POST /accounts/{accountID}/deposits", s.authenticated(s.handleDeposit))
+	s.mux.HandleFunc("POST /accounts/{accountID}/withdrawals", s.authenticated(s.handleWithdraw))
+	s.mux.HandleFunc("GET /accounts/{accountID}/limits", s.authenticated(s.handleAccountLimits))
+	s.mux.HandleFunc("POST /accounts/{accountID}/limit-increases", s.authenticated(s.handleGrantLimitIncrease))
+	s.mux.HandleFunc("PUT /accounts/{accountID}/freeze", s.authenticated(s.handleFreezeAccount))
+	s.mux.HandleFunc("POST /accounts/{accountID}/liens", s.authenticated(s.handlePlaceLien))
+	s.mux.HandleFunc("POST /accounts/{accountID}/liens/{lienID}/release", s.authenticated(s.handleReleaseLien))
+	s.mux.HandleFunc("POST /accounts/{accountID}/holders", s.authenticated(s.handleAddAccountHolder))
+	s.mux.HandleFunc("PUT /accounts/{accountID}/operating-mode", s.authenticated(s.handleSetOperatingMode))
+	s.mux.HandleFunc("PUT /accounts/{accountID}/nominees", s.authenticated(s.handleSetNominees))
+	s.mux.HandleFunc("POST /accounts/{accountID}/closure", s.authenticated(s.handleCloseAccount))
+	s.mux.HandleFunc("GET /accounts/{accountID}/closure", s.authenticated(s.handleGetClosure))
+	s.mux.HandleFunc("POST /accounts/{accountID}/nominee-claim", s.authenticated(s.handleNomineeClaim))
+
+	s.mux.HandleFunc("GET /approvals", s.authenticated(s.handleListApprovals))
+	s.mux.HandleFunc("GET /approvals/{approvalID}", s.authenticated(s.handleGetApproval))
+	s.mux.HandleFunc("POST /approvals/{approvalID}/approve", s.authenticated(s.handleConfirmApproval))
+	s.mux.HandleFunc("POST /approvals/{approvalID}/reject", s.authenticated(s.handleRejectApproval))
+
+	s.mux.HandleFunc("GET /beneficiaries", s.authenticated(s.handleListBeneficiaries))
+	s.mux.HandleFunc("POST /beneficiaries", s.authenticated(s.handleAddBeneficiary))
+	s.mux.HandleFunc("GET /beneficiaries/{beneficiaryID}", s.authenticated(s.handleGetBeneficiary))
+	s.mux.HandleFunc("PATCH /beneficiaries/{beneficiaryID}", s.authenticated(s.handleRenameBeneficiary))
+	s.mux.HandleFunc("DELETE /beneficiaries/{beneficiaryID}", s.authenticated(s.handleDeleteBeneficiary))
+
+	s.mux.HandleFunc("POST /transfers", s.authenticated(s.handleExternalTransfer))
+	s.mux.HandleFunc("POST /transfers/internal", s.authenticated(s.handleInternalTransfer))
+
+	s.mux.HandleFunc("GET /standing-instructions", s.authenticated(s.handleListStandingInstructions))
+	s.mux.HandleFunc("POST /standing-instructions", s.authenticated(s.handleCreateStandingInstruction))
+	s.mux.HandleFunc("POST /standing-instructions/run", s.authenticated(s.handleRunStandingInstructions))
+	s.mux.HandleFunc("GET /standing-instructions/{instructionID}", s.authenticated(s.handleGetStandingInstruction))
+	s.mux.HandleFunc("GET /standing-instructions/{instructionID}/executions", s.authenticated(s.handleStandingExecutions))
+	s.mux.HandleFunc("POST /standing-instructions/{instructionID}/cancel", s.authenticated(s.handleCancelStandingInstruction))
+	s.mux.HandleFunc("POST /standing-instructions/{instructionID}/resume", s.authenticated(s.handleResumeStandingInstruction))
+
+	s.mux.HandleFunc("GET /reviews", s.authenticated(s.handleListReviews))
+	s.mux.HandleFunc("GET /reviews/{reviewID}", s.authenticated(s.handleGetReview))
+	s.mux.HandleFunc("POST /reviews/{reviewID}/approve", s.authenticated(s.handleApproveReview))
+	s.mux.HandleFunc("POST /reviews/{reviewID}/reject", s.authenticated(s.handleRejectReview))
+
+	s.mux.HandleFunc("GET /eod", s.authenticated(s.handleListEndOfDayRuns))
+	s.mux.HandleFunc("POST /eod", s.authenticated(s.handleRunEndOfDay))
+
+	s.mux.HandleFunc("GET /audit", s.authenticated(s.handleQueryAudit))
+	s.mux.HandleFunc("GET /audit/verify", s.authenticated(s.handleVerifyAudit))
+
+	s.mux.HandleFunc("GET /fx/quote", s.authenticated(s.handleQuote))
+
+	s.mux.HandleFunc("GET /journal/trial-balance", s.authenticated(s.handleTrialBalance))
+	s.mux.HandleFunc("GET /journal/verify", s.authenticated(s.handleVerifyJournal))
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPISpec)
+}
+
+type errorResponse struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+	// ResetsAt tells a client refused by a limit when to try again.
+	ResetsAt *time.Time `json:"resets_at,omitempty"`
+	// ReviewID is set on 202 responses for transfers held for review.
+	ReviewID int `json:"review_id,omitempty"`
+	// ApprovalID is set on 202 responses for debits from joint accounts
+	// waiting for the other holders.
+	ApprovalID int `json:"approval_id,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if body != nil {
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+// writeError answers with err's status and message. An error that carries
+// no status is a fault on our side: it is logged, and the client only
+// learns that something went wrong.
+func writeError(w http.ResponseWriter, err error) {
+	status := apperror.StatusCode(err)
+	resp := errorResponse{Status: status, Error: err.Error()}
+	if status == http.StatusInternalServerError {
+		log.Printf("internal error: %v", err)
+		resp.Error = "internal server error"
+	}
+	var limitErr *apperror.LimitExceededError
+	if errors.As(err, &limitErr) && !limitErr.ResetsAt.IsZero() {
+		resp.ResetsAt = &limitErr.ResetsAt
+	}
+	var heldErr *apperror.HeldForReviewError
+	if errors.As(err, &heldErr) {
+		resp.ReviewID = heldErr.ReviewID
+	}
+	var pendingErr *apperror.PendingApprovalError
+	if errors.As(err, &pendingErr) {
+		resp.ApprovalID = pendingErr.ApprovalID
+	}
+	writeJSON(w, status, resp)
+}
+
+func decodeBody(r *http.Request, dst interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return apperror.NewTooLargeError("body", tooLarge.Limit, err)
+		}
+		return apperror.NewValidationError("body", "must be valid JSON for this resource", err)
+	}
+	return nil
+}
+
+func pathID(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || id <= 0 {
+		return 0, apperror.NewValidationError(name, "must be a positive integer")
+	}
+	return id, nil
+}
+
+func pathIDs(r *http.Request, names ...string) ([]int, error) {
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		id, err := pathID(r, name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, apperror.NewValidationError(name, "must be an integer")
+	}
+	return v, nil
+}
+
+// queryCurrency is the currency query parameter, or customer.BaseCurrency
+// if there isn't one.
+func queryCurrency(r *http.Request) string {
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		return currency
+	}
+	return customer.BaseCurrency
+}
+
+func queryTime(r *http.Request, name string) (time.Time, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, apperror.NewValidationError(name, "must be an RFC 3339 timestamp")
+	}
+	return t, nil
+}
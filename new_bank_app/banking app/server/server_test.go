@@ -0,0 +1,197 @@
+package server
+
+import (
+	"banking-app/account"
+	"banking-app/auth"
+	"banking-app/beneficiary"
+	"banking-app/customer"
+	"banking-app/money"
+	"banking-app/repository"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testPassword = "Test@1234"
+
+// Accounts live in one registry per process, so the tests share a single
+// manager and the server in front of it.
+var (
+	manager *customer.CustomerManager
+	admin   *auth.Principal
+	server  *httptest.Server
+)
+
+func TestMain(m *testing.M) {
+	var err error
+	if manager, err = customer.NewCustomerManager(repository.NewMemoryStore(), "Test", "Admin", testPassword); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if _, admin, err = manager.Login(manager.SuperAdminID(), testPassword); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	server = httptest.NewServer(New(manager))
+	code := m.Run()
+	server.Close()
+	os.Exit(code)
+}
+
+// call sends body as JSON and decodes the response into out, if given.
+func call(t *testing.T, method, path, token string, body, out any) int {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, server.URL+path, &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode %d response: %v", method, path, resp.StatusCode, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// onboard opens a customer with a savings account at a new bank and logs
+// them in over HTTP.
+func onboard(t *testing.T, name, bankCode string, rupees int64) (*customer.Customer, *account.Account, string) {
+	t.Helper()
+	b, err := manager.CreateNewBank(admin, name+"'s Bank", bankCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := manager.CreateNewCustomer(admin, name, "Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.SetPassword(admin, c.CustomerID, testPassword); err != nil {
+		t.Fatal(err)
+	}
+	acc, err := manager.CreateAccountForCustomer(admin, c.CustomerID, b.BankID, 0, money.INR, account.ProductSavings, money.MustFromMajor(rupees, money.INR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var session sessionView
+	if status := call(t, "POST", "/sessions", "", loginRequest{CustomerID: c.CustomerID, Password: testPassword}, &session); status != http.StatusCreated {
+		t.Fatalf("login: status %d, want 201", status)
+	}
+	if session.CustomerID != c.CustomerID || session.Token == "" {
+		t.Fatalf("login: session %+v for customer %d", session, c.CustomerID)
+	}
+	return c, acc, session.Token
+}
+
+func TestLoginDepositAndTransfer(t *testing.T) {
+	if err := manager.SetBeneficiaryPolicy(beneficiary.Policy{}); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetRiskEngine(nil)
+	_, from, token := onboard(t, "Riya", "SBIN", 10000)
+	_, to, payeeToken := onboard(t, "Shruti", "BARB", 1000)
+
+	var deposited accountView
+	path := "/accounts/" + strconv.Itoa(from.AccountID) + "/deposits"
+	if status := call(t, "POST", path, token, amountRequest{Amount: "250.50"}, &deposited); status != http.StatusOK {
+		t.Fatalf("deposit: status %d, want 200", status)
+	}
+	if deposited.Balance.Amount != "10250.50" {
+		t.Errorf("balance after deposit = %s, want 10250.50", deposited.Balance.Amount)
+	}
+
+	var payee beneficiaryView
+	if status := call(t, "POST", "/beneficiaries", token, beneficiaryRequest{AccountNumber: to.Number, Nickname: "Shruti"}, &payee); status != http.StatusCreated {
+		t.Fatalf("add beneficiary: status %d, want 201", status)
+	}
+	var moved map[string]accountView
+	req := externalTransferRequest{amountRequest: amountRequest{Amount: "2000"}, FromAccountID: from.AccountID, BeneficiaryID: payee.BeneficiaryID}
+	if status := call(t, "POST", "/transfers", token, req, &moved); status != http.StatusOK {
+		t.Fatalf("transfer: status %d, want 200", status)
+	}
+	if got := moved["from"].Balance.Amount; got != "8250.50" {
+		t.Errorf("sender balance = %s, want 8250.50", got)
+	}
+	if _, ok := moved["to"]; ok {
+		t.Error("transfer showed the sender the beneficiary's account")
+	}
+	var received accountView
+	if status := call(t, "GET", "/accounts/"+strconv.Itoa(to.AccountID), payeeToken, nil, &received); status != http.StatusOK {
+		t.Fatalf("receiver's account: status %d, want 200", status)
+	}
+	if got := received.Balance.Amount; got != "3000.00" {
+		t.Errorf("receiver balance = %s, want 3000.00", got)
+	}
+}
+
+func TestErrorsMapToStatusCodes(t *testing.T) {
+	_, acc, token := onboard(t, "Meera", "HDFC", 1000)
+	deposits := "/accounts/" + strconv.Itoa(acc.AccountID) + "/deposits"
+	withdrawals := "/accounts/" + strconv.Itoa(acc.AccountID) + "/withdrawals"
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   any
+		want   int
+	}{
+		{"wrong password", "POST", "/sessions", "", loginRequest{CustomerID: acc.OwnerID, Password: "Wrong@123"}, http.StatusUnauthorized},
+		{"no token", "POST", deposits, "", amountRequest{Amount: "10"}, http.StatusUnauthorized},
+		{"bad token", "POST", deposits, "not-a-token", amountRequest{Amount: "10"}, http.StatusUnauthorized},
+		{"extra precision", "POST", deposits, token, amountRequest{Amount: "10.005"}, http.StatusBadRequest},
+		{"unknown field", "POST", deposits, token, map[string]string{"amount": "10", "memo": "x"}, http.StatusBadRequest},
+		{"bad path ID", "POST", "/accounts/abc/deposits", token, amountRequest{Amount: "10"}, http.StatusBadRequest},
+		{"unknown account", "POST", "/accounts/99999999/deposits", token, amountRequest{Amount: "10"}, http.StatusNotFound},
+		{"insufficient funds", "POST", withdrawals, token, amountRequest{Amount: "900"}, http.StatusUnprocessableEntity},
+		{"missing permission", "POST", "/banks", token, bankRequest{Name: "Meera's Other Bank", Code: "ICIC"}, http.StatusForbidden},
+		{"oversized body", "POST", "/sessions", "", loginRequest{CustomerID: acc.OwnerID, Password: strings.Repeat("x", maxBodyBytes)}, http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var resp errorResponse
+			status := call(t, tc.method, tc.path, tc.token, tc.body, &resp)
+			if status != tc.want {
+				t.Fatalf("status %d (%s), want %d", status, resp.Error, tc.want)
+			}
+			if resp.Status != status || resp.Error == "" {
+				t.Errorf("error body %+v does not describe status %d", resp, status)
+			}
+		})
+	}
+	if got := acc.GetBalance(); got != money.MustFromMajor(1000, money.INR) {
+		t.Errorf("balance = %s after refused requests, want INR 1000.00", got)
+	}
+}
+
+func TestUnknownErrorsAreInternal(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeError(w, errors.New("disk on fire"))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status %d for an untyped error, want 500", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "disk on fire") {
+		t.Errorf("body %s repeats the internal error", w.Body.String())
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"banking-app/auth"
+	"net/http"
+)
+
+func (s *Server) handleSettle(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	batch, err := s.manager.SettleInterbank(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newSettlementView(batch))
+}
+
+func (s *Server) handleListSettlements(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	batches, err := s.manager.SettlementBatches(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	views := make([]settlementView, 0, len(batches))
+	for _, b := range batches {
+		views = append(views, newSettlementView(b))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleGetSettlement(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	batchID, err := pathID(r, "batchID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	batch, err := s.manager.SettlementBatch(p, batchID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newSettlementView(batch))
+}
+
+func (s *Server) handleReconcileBank(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	bankID, err := pathID(r, "bankID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	rec, err := s.manager.ReconcileBankPosition(p, bankID, queryCurrency(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reconciliationView{
+		BankID:      rec.BankID,
+		Currency:    rec.Currency,
+		Settled:     newMoneyView(rec.Settled),
+		Outstanding: newMoneyView(rec.Outstanding),
+		PassbookNet: newMoneyView(rec.PassbookNet),
+		Balanced:    rec.Balanced,
+	})
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"banking-app/auth"
+	"net/http"
+)
+
+type staffRequest struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	roleRequest
+}
+
+type roleRequest struct {
+	Role    string `json:"role"`
+	BankIDs []int  `json:"bank_ids"`
+}
+
+func (s *Server) handleCreateStaff(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	var req staffRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	role, err := auth.ParseRole(req.Role)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	c, err := s.manager.CreateStaff(p, req.FirstName, req.LastName, role, req.BankIDs...)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newCustomerView(*c))
+}
+
+func (s *Server) handleUpdateStaffRole(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	staffID, err := pathID(r, "staffID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req roleRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	role, err := auth.ParseRole(req.Role)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.manager.UpdateStaffRole(p, staffID, role, req.BankIDs...); err != nil {
+		writeError(w, err)
+		return
+	}
+	c, err := s.manager.GetCustomerById(p, staffID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newCustomerView(*c))
+}
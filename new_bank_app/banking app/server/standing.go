@@ -0,0 +1,169 @@
+package server
+
+import (
+	"banking-app/apperror"
+	"banking-app/auth"
+	"banking-app/standing"
+	"net/http"
+	"time"
+)
+
+type standingInstructionRequest struct {
+	amountRequest
+	Name            string          `json:"name"`
+	FromAccountID   int             `json:"from_account_id"`
+	ToAccountNumber string          `json:"to_account_number"`
+	BeneficiaryID   int             `json:"beneficiary_id"`
+	Schedule        scheduleRequest `json:"schedule"`
+	Retry           retryRequest    `json:"retry"`
+}
+
+type scheduleRequest struct {
+	Frequency  string `json:"frequency"`
+	StartDate  string `json:"start_date"`
+	EndDate    string `json:"end_date"`
+	DayOfMonth int    `json:"day_of_month"`
+}
+
+type retryRequest struct {
+	Attempts      int `json:"attempts"`
+	IntervalHours int `json:"interval_hours"`
+}
+
+func (req standingInstructionRequest) toSpec() (standing.Spec, error) {
+	amount, err := req.toMoney()
+	if err != nil {
+		return standing.Spec{}, err
+	}
+	frequency, err := standing.ParseFrequency(req.Schedule.Frequency)
+	if err != nil {
+		return standing.Spec{}, err
+	}
+	start, err := time.Parse(time.DateOnly, req.Schedule.StartDate)
+	if err != nil {
+		return standing.Spec{}, apperror.NewValidationError("start_date", "must be a date like 2006-01-02")
+	}
+	var end time.Time
+	if req.Schedule.EndDate != "" {
+		if end, err = time.Parse(time.DateOnly, req.Schedule.EndDate); err != nil {
+			return standing.Spec{}, apperror.NewValidationError("end_date", "must be a date like 2006-01-02")
+		}
+	}
+	return standing.Spec{
+		Name:          req.Name,
+		FromAccountID: req.FromAccountID,
+		BeneficiaryID: req.BeneficiaryID,
+		Amount:        amount,
+		Schedule: standing.Schedule{
+			Frequency:  frequency,
+			StartDate:  start,
+			EndDate:    end,
+			DayOfMonth: req.Schedule.DayOfMonth,
+		},
+		Retry: standing.Retry{
+			Attempts: req.Retry.Attempts,
+			Interval: time.Duration(req.Retry.IntervalHours) * time.Hour,
+		},
+	}, nil
+}
+
+func (s *Server) handleListStandingInstructions(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	list, err := s.manager.StandingInstructions(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	views := make([]standingInstructionView, 0, len(list))
+	for _, in := range list {
+		views = append(views, newStandingInstructionView(in))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) handleCreateStandingInstruction(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	var req standingInstructionRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	spec, err := req.toSpec()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.ToAccountNumber != "" {
+		to, err := s.manager.GetAccountByNumber(p, req.ToAccountNumber)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		spec.ToAccountID = to.AccountID
+	}
+	in, err := s.manager.CreateStandingInstruction(p, spec)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, newStandingInstructionView(in))
+}
+
+func (s *Server) handleGetStandingInstruction(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	instructionID, err := pathID(r, "instructionID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	in, err := s.manager.StandingInstruction(p, instructionID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newStandingInstructionView(in))
+}
+
+func (s *Server) handleStandingExecutions(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	instructionID, err := pathID(r, "instructionID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	executions, err := s.manager.StandingExecutions(p, instructionID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newExecutionViews(executions))
+}
+
+func (s *Server) handleCancelStandingInstruction(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	s.handleChangeStandingInstruction(w, r, p, s.manager.CancelStandingInstruction)
+}
+
+func (s *Server) handleResumeStandingInstruction(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	s.handleChangeStandingInstruction(w, r, p, s.manager.ResumeStandingInstruction)
+}
+
+func (s *Server) handleChangeStandingInstruction(w http.ResponseWriter, r *http.Request, p *auth.Principal, change func(p *auth.Principal, instructionID int) (standing.Instruction, error)) {
+	instructionID, err := pathID(r, "instructionID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	in, err := change(p, instructionID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newStandingInstructionView(in))
+}
+
+// handleRunStandingInstructions runs every instruction that is due now,
+// without waiting for the scheduler.
+func (s *Server) handleRunStandingInstructions(w http.ResponseWriter, r *http.Request, p *auth.Principal) {
+	executions, err := s.manager.RunStandingInstructions(p)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newExecutionViews(executions))
+}
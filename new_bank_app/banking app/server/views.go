@@ -0,0 +1,755 @@
+package server
+
+import (
+	"banking-app/account"
+	"banking-app/audit"
+	"banking-app/bank"
+	"banking-app/beneficiary"
+	"banking-app/closure"
+	"banking-app/customer"
+	"banking-app/eod"
+	"banking-app/fx"
+	"banking-app/joint"
+	"banking-app/journal"
+	"banking-app/ledger"
+	"banking-app/money"
+	"banking-app/risk"
+	"banking-app/standing"
+	"sort"
+	"time"
+)
+
+type moneyView struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func newMoneyView(m money.Money) moneyView {
+	currency := m.Currency
+	if currency == "" {
+		currency = money.INR
+	}
+	return moneyView{Amount: m.Decimal(), Currency: currency}
+}
+
+type amountRequest struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (a amountRequest) toMoney() (money.Money, error) {
+	currency := a.Currency
+	if currency == "" {
+		currency = money.INR
+	}
+	return money.Parse(a.Amount, currency)
+}
+
+type bankView struct {
+	BankID        int                   `json:"bank_id"`
+	Name          string                `json:"name"`
+	Code          string                `json:"code"`
+	IsActive      bool                  `json:"is_active"`
+	InterestRates map[string]int64      `json:"interest_rates_bps,omitempty"`
+	Limits        map[string]limitsView `json:"limits,omitempty"`
+	Branches      []branchView          `json:"branches"`
+}
+
+func newBankView(b bank.Bank) bankView {
+	view := bankView{BankID: b.BankID, Name: b.Name, Code: b.Code, IsActive: b.IsActive, Branches: make([]branchView, 0, len(b.Branches))}
+	for _, branch := range b.Branches {
+		view.Branches = append(view.Branches, newBranchView(branch))
+	}
+	if len(b.InterestRates) > 0 {
+		view.InterestRates = make(map[string]int64, len(b.InterestRates))
+		for product, rate := range b.InterestRates {
+			view.InterestRates[string(product)] = rate
+		}
+	}
+	if len(b.ProductLimits) > 0 {
+		view.Limits = make(map[string]limitsView, len(b.ProductLimits))
+		for product, limits := range b.ProductLimits {
+			view.Limits[string(product)] = newLimitsView(limits)
+		}
+	}
+	return view
+}
+
+type branchView struct {
+	BranchID    int    `json:"branch_id"`
+	BankID      int    `json:"bank_id"`
+	Name        string `json:"name"`
+	Code        string `json:"code"`
+	RoutingCode string `json:"routing_code"`
+	IsActive    bool   `json:"is_active"`
+}
+
+func newBranchView(b bank.Branch) branchView {
+	return branchView{BranchID: b.BranchID, BankID: b.BankID, Name: b.Name, Code: b.Code, RoutingCode: b.RoutingCode, IsActive: b.IsActive}
+}
+
+// limitsView leaves out the limits that do not apply.
+type limitsView struct {
+	PerTransaction      *moneyView `json:"per_transaction,omitempty"`
+	DailyWithdrawal     *moneyView `json:"daily_withdrawal,omitempty"`
+	MonthlyWithdrawal   *moneyView `json:"monthly_withdrawal,omitempty"`
+	DailyTransfers      int        `json:"daily_transfers,omitempty"`
+	DailyTransferAmount *moneyView `json:"daily_transfer_amount,omitempty"`
+}
+
+func newLimitsView(l account.Limits) limitsView {
+	optional := func(m money.Money) *moneyView {
+		if m.IsZero() {
+			return nil
+		}
+		view := newMoneyView(m)
+		return &view
+	}
+	return limitsView{
+		PerTransaction:      optional(l.PerTransaction),
+		DailyWithdrawal:     optional(l.DailyWithdrawal),
+		MonthlyWithdrawal:   optional(l.MonthlyWithdrawal),
+		DailyTransfers:      l.DailyTransfers,
+		DailyTransferAmount: optional(l.DailyTransferAmount),
+	}
+}
+
+type limitIncreaseView struct {
+	AccountID int        `json:"account_id"`
+	Limits    limitsView `json:"limits"`
+	GrantedBy int        `json:"granted_by"`
+	GrantedAt time.Time  `json:"granted_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
+
+func newLimitIncreaseView(i account.LimitIncrease) limitIncreaseView {
+	return limitIncreaseView{
+		AccountID: i.AccountID,
+		Limits:    newLimitsView(i.Limits),
+		GrantedBy: i.GrantedBy,
+		GrantedAt: i.GrantedAt,
+		ExpiresAt: i.ExpiresAt,
+	}
+}
+
+type accountLimitsView struct {
+	Limits   limitsView         `json:"limits"`
+	Increase *limitIncreaseView `json:"increase,omitempty"`
+	Usage    usageView          `json:"usage"`
+}
+
+type usageView struct {
+	WithdrawnToday     moneyView `json:"withdrawn_today"`
+	WithdrawnThisMonth moneyView `json:"withdrawn_this_month"`
+	TransfersToday     int       `json:"transfers_today"`
+	TransferredToday   moneyView `json:"transferred_today"`
+}
+
+func newAccountLimitsView(l customer.AccountLimits) accountLimitsView {
+	view := accountLimitsView{
+		Limits: newLimitsView(l.Limits),
+		Usage: usageView{
+			WithdrawnToday:     newMoneyView(l.Usage.WithdrawnToday),
+			WithdrawnThisMonth: newMoneyView(l.Usage.WithdrawnThisMonth),
+			TransfersToday:     l.Usage.TransfersToday,
+			TransferredToday:   newMoneyView(l.Usage.TransferredToday),
+		},
+	}
+	if l.Increase != nil {
+		increase := newLimitIncreaseView(*l.Increase)
+		view.Increase = &increase
+	}
+	return view
+}
+
+type accountView struct {
+	AccountID int       `json:"account_id"`
+	Number    string    `json:"account_number"`
+	BankID    int       `json:"bank_id"`
+	BranchID  int       `json:"branch_id"`
+	OwnerID   int       `json:"owner_id"`
+	Product   string    `json:"product"`
+	Currency  string    `json:"currency"`
+	Balance   moneyView `json:"balance"`
+	Terms     termsView `json:"terms"`
+	OpenedAt  time.Time `json:"opened_at"`
+	IsActive  bool      `json:"is_active"`
+
+	AccruedInterest *moneyView `json:"accrued_interest,omitempty"`
+
+	AvailableBalance *moneyView `json:"available_balance,omitempty"`
+	Freeze           string     `json:"freeze,omitempty"`
+	FreezeReason     string     `json:"freeze_reason,omitempty"`
+	Liens            []lienView `json:"liens,omitempty"`
+
+	Holders         []int  `json:"holders"`
+	OperatingMode   string `json:"operating_mode"`
+	RequiredHolders int    `json:"required_holders,omitempty"`
+
+	Nominees []nomineeView `json:"nominees,omitempty"`
+}
+
+type nomineeView struct {
+	NomineeID    int    `json:"nominee_id"`
+	Name         string `json:"name"`
+	Relationship string `json:"relationship"`
+	SharePercent int    `json:"share_percent"`
+}
+
+type lienView struct {
+	LienID     int        `json:"lien_id"`
+	Amount     moneyView  `json:"amount"`
+	Reason     string     `json:"reason"`
+	PlacedBy   int        `json:"placed_by"`
+	PlacedAt   time.Time  `json:"placed_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	ReleasedBy int        `json:"released_by,omitempty"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+}
+
+func newLienView(l account.Lien) lienView {
+	view := lienView{
+		LienID:     l.LienID,
+		Amount:     newMoneyView(l.Amount),
+		Reason:     l.Reason,
+		PlacedBy:   l.PlacedBy,
+		PlacedAt:   l.PlacedAt,
+		ReleasedBy: l.ReleasedBy,
+	}
+	if !l.ExpiresAt.IsZero() {
+		view.ExpiresAt = &l.ExpiresAt
+	}
+	if !l.ReleasedAt.IsZero() {
+		view.ReleasedAt = &l.ReleasedAt
+	}
+	return view
+}
+
+type termsView struct {
+	MinimumBalance  *moneyView `json:"minimum_balance,omitempty"`
+	OverdraftLimit  *moneyView `json:"overdraft_limit,omitempty"`
+	InterestRateBPS int64      `json:"interest_rate_bps"`
+	MaturesAt       *time.Time `json:"matures_at,omitempty"`
+	PenaltyBPS      int64      `json:"penalty_bps,omitempty"`
+}
+
+func newAccountView(a *account.Account) accountView {
+	s := a.Snapshot()
+	view := accountView{
+		AccountID: s.AccountID,
+		Number:    s.Number,
+		BankID:    s.BankID,
+		BranchID:  s.BranchID,
+		OwnerID:   s.OwnerID,
+		Product:   string(s.Terms.Product),
+		Currency:  s.Currency,
+		Balance:   newMoneyView(s.Balance),
+		Terms:     termsView{InterestRateBPS: s.Terms.InterestRateBPS, PenaltyBPS: s.Terms.PenaltyBPS},
+		OpenedAt:  s.OpenedAt,
+		IsActive:  s.IsActive,
+
+		Freeze:       string(s.Freeze),
+		FreezeReason: s.FreezeReason,
+
+		Holders:         a.Holders(),
+		OperatingMode:   string(s.Mode),
+		RequiredHolders: s.RequiredHolders,
+	}
+	if s.Mode == account.ModeSole {
+		view.OperatingMode = "sole"
+	}
+	if available, err := a.AvailableBalance(); err == nil {
+		v := newMoneyView(available)
+		view.AvailableBalance = &v
+	}
+	for _, l := range s.Liens {
+		view.Liens = append(view.Liens, newLienView(l))
+	}
+	for _, n := range s.Nominees {
+		view.Nominees = append(view.Nominees, nomineeView{NomineeID: n.NomineeID, Name: n.Name, Relationship: n.Relationship, SharePercent: n.SharePercent})
+	}
+	switch s.Terms.Product {
+	case account.ProductSavings:
+		minimum := newMoneyView(s.Terms.MinimumBalance)
+		view.Terms.MinimumBalance = &minimum
+		accrued := newMoneyView(s.AccruedInterest)
+		view.AccruedInterest = &accrued
+	case account.ProductCurrent:
+		limit := newMoneyView(s.Terms.OverdraftLimit)
+		view.Terms.OverdraftLimit = &limit
+	case account.ProductFixedDeposit:
+		maturesAt := a.MaturesAt()
+		view.Terms.MaturesAt = &maturesAt
+	}
+	return view
+}
+
+type customerView struct {
+	CustomerID int           `json:"customer_id"`
+	FirstName  string        `json:"first_name"`
+	LastName   string        `json:"last_name"`
+	Role       string        `json:"role"`
+	BankIDs    []int         `json:"bank_ids,omitempty"`
+	IsActive   bool          `json:"is_active"`
+	DeceasedOn string        `json:"deceased_on,omitempty"`
+	Accounts   []accountView `json:"accounts"`
+}
+
+func newCustomerView(c customer.Customer) customerView {
+	view := customerView{
+		CustomerID: c.CustomerID,
+		FirstName:  c.FirstName,
+		LastName:   c.LastName,
+		Role:       string(c.Role),
+		BankIDs:    c.BankIDs,
+		IsActive:   c.IsActive,
+		Accounts:   make([]accountView, 0, len(c.Accounts)),
+	}
+	if !c.DeceasedOn.IsZero() {
+		view.DeceasedOn = c.DeceasedOn.Format(time.DateOnly)
+	}
+	for _, acc := range c.Accounts {
+		view.Accounts = append(view.Accounts, newAccountView(acc))
+	}
+	sort.Slice(view.Accounts, func(i, j int) bool { return view.Accounts[i].AccountID < view.Accounts[j].AccountID })
+	return view
+}
+
+type transactionView struct {
+	ReferenceID           string    `json:"reference_id"`
+	Type                  string    `json:"type"`
+	Timestamp             time.Time `json:"timestamp"`
+	CounterpartyAccountID int       `json:"counterparty_account_id,omitempty"`
+	Amount                moneyView `json:"amount"`
+	Balance               moneyView `json:"balance"`
+
+	FX                 *quoteView `json:"fx,omitempty"`
+	CounterpartyAmount *moneyView `json:"counterparty_amount,omitempty"`
+}
+
+func newTransactionView(t account.Transaction) transactionView {
+	view := transactionView{
+		ReferenceID:           t.ReferenceID,
+		Type:                  string(t.Type),
+		Timestamp:             t.Timestamp,
+		CounterpartyAccountID: t.CounterpartyAccountID,
+		Amount:                newMoneyView(t.Amount),
+		Balance:               newMoneyView(t.Balance),
+	}
+	if t.FX != nil {
+		quote := newQuoteView(*t.FX)
+		view.FX = &quote
+		amount := newMoneyView(t.CounterpartyAmount)
+		view.CounterpartyAmount = &amount
+	}
+	return view
+}
+
+type quoteView struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	MidRate   string `json:"mid_rate"`
+	SpreadBPS int64  `json:"spread_bps"`
+	Rate      string `json:"rate"`
+}
+
+func newQuoteView(q fx.Quote) quoteView {
+	return quoteView{From: q.From, To: q.To, MidRate: q.Mid.String(), SpreadBPS: q.SpreadBPS, Rate: q.Rate.String()}
+}
+
+type dueView struct {
+	FromBankID int       `json:"from_bank_id"`
+	ToBankID   int       `json:"to_bank_id"`
+	Amount     moneyView `json:"amount"`
+}
+
+func newDueViews(dues []ledger.Due) []dueView {
+	views := make([]dueView, 0, len(dues))
+	for _, d := range dues {
+		views = append(views, dueView{FromBankID: d.FromBankID, ToBankID: d.ToBankID, Amount: newMoneyView(d.Amount)})
+	}
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].FromBankID != views[j].FromBankID {
+			return views[i].FromBankID < views[j].FromBankID
+		}
+		return views[i].ToBankID < views[j].ToBankID
+	})
+	return views
+}
+
+type positionView struct {
+	BankID     int       `json:"bank_id"`
+	Currency   string    `json:"currency"`
+	Actual     moneyView `json:"actual"`
+	Receivable moneyView `json:"receivable"`
+	Owed       moneyView `json:"owed"`
+}
+
+type settlementView struct {
+	BatchID      int               `json:"batch_id"`
+	WindowStart  *time.Time        `json:"window_start,omitempty"`
+	ClosedAt     time.Time         `json:"closed_at"`
+	Dues         []dueView         `json:"dues"`
+	NetPositions []netPositionView `json:"net_positions"`
+	Payments     []dueView         `json:"payments"`
+}
+
+type netPositionView struct {
+	BankID int       `json:"bank_id"`
+	Net    moneyView `json:"net"`
+}
+
+func newSettlementView(b ledger.SettlementBatch) settlementView {
+	view := settlementView{
+		BatchID:      b.BatchID,
+		ClosedAt:     b.ClosedAt,
+		Dues:         newDueViews(b.Dues),
+		NetPositions: make([]netPositionView, 0, len(b.NetPositions)),
+		Payments:     make([]dueView, 0, len(b.Payments)),
+	}
+	if !b.WindowStart.IsZero() {
+		view.WindowStart = &b.WindowStart
+	}
+	for _, pos := range b.NetPositions {
+		view.NetPositions = append(view.NetPositions, netPositionView{BankID: pos.BankID, Net: newMoneyView(pos.Net)})
+	}
+	for _, pay := range b.Payments {
+		view.Payments = append(view.Payments, dueView{FromBankID: pay.FromBankID, ToBankID: pay.ToBankID, Amount: newMoneyView(pay.Amount)})
+	}
+	return view
+}
+
+type trialBalanceView struct {
+	Currency    string                `json:"currency"`
+	Rows        []trialBalanceRowView `json:"rows"`
+	TotalDebit  moneyView             `json:"total_debit"`
+	TotalCredit moneyView             `json:"total_credit"`
+	Balanced    bool                  `json:"balanced"`
+}
+
+type trialBalanceRowView struct {
+	Account string    `json:"account"`
+	Debit   moneyView `json:"debit"`
+	Credit  moneyView `json:"credit"`
+}
+
+func newTrialBalanceView(tb journal.TrialBalance) trialBalanceView {
+	view := trialBalanceView{
+		Currency:    tb.Currency,
+		Rows:        make([]trialBalanceRowView, 0, len(tb.Rows)),
+		TotalDebit:  newMoneyView(tb.TotalDebit),
+		TotalCredit: newMoneyView(tb.TotalCredit),
+		Balanced:    tb.Balanced(),
+	}
+	for _, row := range tb.Rows {
+		view.Rows = append(view.Rows, trialBalanceRowView{
+			Account: string(row.Account),
+			Debit:   newMoneyView(row.Debit),
+			Credit:  newMoneyView(row.Credit),
+		})
+	}
+	return view
+}
+
+type reviewView struct {
+	ReviewID      int           `json:"review_id"`
+	Status        string        `json:"status"`
+	CustomerID    int           `json:"customer_id"`
+	ToCustomerID  int           `json:"to_customer_id"`
+	FromAccountID int           `json:"from_account_id"`
+	ToAccountID   int           `json:"to_account_id"`
+	Amount        moneyView     `json:"amount"`
+	Findings      []findingView `json:"findings"`
+	HeldAt        time.Time     `json:"held_at"`
+	DecidedBy     int           `json:"decided_by,omitempty"`
+	DecidedAt     *time.Time    `json:"decided_at,omitempty"`
+	Note          string        `json:"note,omitempty"`
+}
+
+type findingView struct {
+	Rule     string `json:"rule"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
+
+func newReviewView(r risk.Review) reviewView {
+	view := reviewView{
+		ReviewID:      r.ReviewID,
+		Status:        string(r.Status),
+		CustomerID:    r.CustomerID,
+		ToCustomerID:  r.ToCustomerID,
+		FromAccountID: r.FromAccountID,
+		ToAccountID:   r.ToAccountID,
+		Amount:        newMoneyView(r.Amount),
+		Findings:      make([]findingView, 0, len(r.Findings)),
+		HeldAt:        r.HeldAt,
+		DecidedBy:     r.DecidedBy,
+		Note:          r.Note,
+	}
+	for _, f := range r.Findings {
+		view.Findings = append(view.Findings, findingView{Rule: f.Rule, Decision: string(f.Decision), Reason: f.Reason})
+	}
+	if !r.DecidedAt.IsZero() {
+		view.DecidedAt = &r.DecidedAt
+	}
+	return view
+}
+
+type approvalView struct {
+	ApprovalID    int        `json:"approval_id"`
+	Status        string     `json:"status"`
+	Operation     string     `json:"operation"`
+	AccountID     int        `json:"account_id"`
+	ToAccountID   int        `json:"to_account_id,omitempty"`
+	BeneficiaryID int        `json:"beneficiary_id,omitempty"`
+	Amount        moneyView  `json:"amount"`
+	RequestedBy   int        `json:"requested_by"`
+	RequestedAt   time.Time  `json:"requested_at"`
+	Required      int        `json:"required"`
+	ApprovedBy    []int      `json:"approved_by"`
+	DecidedBy     int        `json:"decided_by,omitempty"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty"`
+}
+
+func newApprovalView(a joint.Approval) approvalView {
+	view := approvalView{
+		ApprovalID:    a.ApprovalID,
+		Status:        string(a.Status),
+		Operation:     string(a.Operation),
+		AccountID:     a.AccountID,
+		ToAccountID:   a.ToAccountID,
+		BeneficiaryID: a.BeneficiaryID,
+		Amount:        newMoneyView(a.Amount),
+		RequestedBy:   a.RequestedBy,
+		RequestedAt:   a.RequestedAt,
+		Required:      a.Required,
+		ApprovedBy:    a.ApprovedBy,
+		DecidedBy:     a.DecidedBy,
+	}
+	if !a.DecidedAt.IsZero() {
+		view.DecidedAt = &a.DecidedAt
+	}
+	return view
+}
+
+type closureView struct {
+	ClosureID   int          `json:"closure_id"`
+	AccountID   int          `json:"account_id"`
+	Reason      string       `json:"reason"`
+	ReferenceID string       `json:"reference_id"`
+	Interest    moneyView    `json:"interest"`
+	Charges     moneyView    `json:"charges"`
+	Payouts     []payoutView `json:"payouts"`
+	DeceasedID  int          `json:"deceased_id,omitempty"`
+	ClosedBy    int          `json:"closed_by"`
+	ClosedAt    time.Time    `json:"closed_at"`
+}
+
+type payoutView struct {
+	Method          string     `json:"method"`
+	Amount          moneyView  `json:"amount"`
+	ToAccountNumber string     `json:"to_account_number,omitempty"`
+	Credited        *moneyView `json:"credited,omitempty"`
+	NomineeID       int        `json:"nominee_id,omitempty"`
+	NomineeName     string     `json:"nominee_name,omitempty"`
+}
+
+func newClosureView(c closure.Closure) closureView {
+	view := closureView{
+		ClosureID:   c.ClosureID,
+		AccountID:   c.AccountID,
+		Reason:      string(c.Reason),
+		ReferenceID: c.ReferenceID,
+		Interest:    newMoneyView(c.Interest),
+		Charges:     newMoneyView(c.Charges),
+		Payouts:     make([]payoutView, 0, len(c.Payouts)),
+		DeceasedID:  c.DeceasedID,
+		ClosedBy:    c.ClosedBy,
+		ClosedAt:    c.ClosedAt,
+	}
+	for _, po := range c.Payouts {
+		pv := payoutView{
+			Method:          string(po.Method),
+			Amount:          newMoneyView(po.Amount),
+			ToAccountNumber: po.ToAccountNumber,
+			NomineeID:       po.NomineeID,
+			NomineeName:     po.NomineeName,
+		}
+		if po.Method == closure.MethodTransfer {
+			credited := newMoneyView(po.Credited)
+			pv.Credited = &credited
+		}
+		view.Payouts = append(view.Payouts, pv)
+	}
+	return view
+}
+
+type beneficiaryView struct {
+	BeneficiaryID int       `json:"beneficiary_id"`
+	Nickname      string    `json:"nickname"`
+	AccountID     int       `json:"account_id"`
+	AccountNumber string    `json:"account_number"`
+	BankID        int       `json:"bank_id"`
+	AddedAt       time.Time `json:"added_at"`
+	// CoolsOffAt is when the beneficiary may receive more than the
+	// cooling-off limit.
+	CoolsOffAt time.Time `json:"cools_off_at"`
+}
+
+func newBeneficiaryView(b beneficiary.Beneficiary, policy beneficiary.Policy) beneficiaryView {
+	return beneficiaryView{
+		BeneficiaryID: b.BeneficiaryID,
+		Nickname:      b.Nickname,
+		AccountID:     b.AccountID,
+		AccountNumber: b.AccountNumber,
+		BankID:        b.BankID,
+		AddedAt:       b.AddedAt,
+		CoolsOffAt:    policy.CoolsOffAt(b),
+	}
+}
+
+type standingInstructionView struct {
+	InstructionID       int          `json:"instruction_id"`
+	Name                string       `json:"name,omitempty"`
+	Status              string       `json:"status"`
+	FromAccountID       int          `json:"from_account_id"`
+	ToAccountID         int          `json:"to_account_id,omitempty"`
+	BeneficiaryID       int          `json:"beneficiary_id,omitempty"`
+	Amount              moneyView    `json:"amount"`
+	Schedule            scheduleView `json:"schedule"`
+	Retry               retryView    `json:"retry"`
+	NextRunAt           *time.Time   `json:"next_run_at,omitempty"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	CreatedAt           time.Time    `json:"created_at"`
+}
+
+type scheduleView struct {
+	Frequency  string `json:"frequency"`
+	StartDate  string `json:"start_date"`
+	EndDate    string `json:"end_date,omitempty"`
+	DayOfMonth int    `json:"day_of_month,omitempty"`
+}
+
+type retryView struct {
+	Attempts      int `json:"attempts"`
+	IntervalHours int `json:"interval_hours,omitempty"`
+}
+
+func newStandingInstructionView(in standing.Instruction) standingInstructionView {
+	view := standingInstructionView{
+		InstructionID: in.InstructionID,
+		Name:          in.Name,
+		Status:        string(in.Status),
+		FromAccountID: in.FromAccountID,
+		ToAccountID:   in.ToAccountID,
+		BeneficiaryID: in.BeneficiaryID,
+		Amount:        newMoneyView(in.Amount),
+		Schedule: scheduleView{
+			Frequency:  string(in.Schedule.Frequency),
+			StartDate:  in.Schedule.StartDate.Format(time.DateOnly),
+			DayOfMonth: in.Schedule.DayOfMonth,
+		},
+		Retry: retryView{
+			Attempts:      in.Retry.Attempts,
+			IntervalHours: int(in.Retry.Interval / time.Hour),
+		},
+		ConsecutiveFailures: in.ConsecutiveFailures,
+		CreatedAt:           in.CreatedAt,
+	}
+	if !in.Schedule.EndDate.IsZero() {
+		view.Schedule.EndDate = in.Schedule.EndDate.Format(time.DateOnly)
+	}
+	if in.Status == standing.StatusActive {
+		view.NextRunAt = &in.NextRunAt
+	}
+	return view
+}
+
+type executionView struct {
+	InstructionID int       `json:"instruction_id"`
+	Sequence      int       `json:"sequence"`
+	DueDate       string    `json:"due_date"`
+	Attempt       int       `json:"attempt"`
+	At            time.Time `json:"at"`
+	Outcome       string    `json:"outcome"`
+	Error         string    `json:"error,omitempty"`
+	ReviewID      int       `json:"review_id,omitempty"`
+}
+
+func newExecutionViews(executions []standing.Execution) []executionView {
+	views := make([]executionView, 0, len(executions))
+	for _, e := range executions {
+		views = append(views, executionView{
+			InstructionID: e.InstructionID,
+			Sequence:      e.Sequence,
+			DueDate:       e.DueDate.Format(time.DateOnly),
+			Attempt:       e.Attempt,
+			At:            e.At,
+			Outcome:       string(e.Outcome),
+			Error:         e.Error,
+			ReviewID:      e.ReviewID,
+		})
+	}
+	return views
+}
+
+type reconciliationView struct {
+	BankID      int       `json:"bank_id"`
+	Currency    string    `json:"currency"`
+	Settled     moneyView `json:"settled"`
+	Outstanding moneyView `json:"outstanding"`
+	PassbookNet moneyView `json:"passbook_net"`
+	Balanced    bool      `json:"balanced"`
+}
+
+type eodRunView struct {
+	BusinessDate     string    `json:"business_date"`
+	CompletedAt      time.Time `json:"completed_at"`
+	AccountsAccrued  int       `json:"accounts_accrued"`
+	InterestAccrued  moneyView `json:"interest_accrued"`
+	AccountsCredited int       `json:"accounts_credited"`
+	InterestCredited moneyView `json:"interest_credited"`
+}
+
+func newEODRunViews(runs []eod.Run) []eodRunView {
+	views := make([]eodRunView, 0, len(runs))
+	for _, run := range runs {
+		views = append(views, eodRunView{
+			BusinessDate:     run.BusinessDate.Format(time.DateOnly),
+			CompletedAt:      run.CompletedAt,
+			AccountsAccrued:  run.AccountsAccrued,
+			InterestAccrued:  newMoneyView(run.InterestAccrued),
+			AccountsCredited: run.AccountsCredited,
+			InterestCredited: newMoneyView(run.InterestCredited),
+		})
+	}
+	return views
+}
+
+type auditEntryView struct {
+	Sequence   int               `json:"sequence"`
+	Timestamp  time.Time         `json:"timestamp"`
+	ActorID    int               `json:"actor_id"`
+	Action     string            `json:"action"`
+	Resource   string            `json:"resource"`
+	ResourceID int               `json:"resource_id"`
+	Before     map[string]string `json:"before,omitempty"`
+	After      map[string]string `json:"after,omitempty"`
+	PrevHash   string            `json:"prev_hash"`
+	Hash       string            `json:"hash"`
+}
+
+func newAuditEntryView(e audit.Entry) auditEntryView {
+	return auditEntryView{
+		Sequence:   e.Sequence,
+		Timestamp:  e.Timestamp,
+		ActorID:    e.ActorID,
+		Action:     string(e.Action),
+		Resource:   e.Resource,
+		ResourceID: e.ResourceID,
+		Before:     e.Before,
+		After:      e.After,
+		PrevHash:   e.PrevHash,
+		Hash:       e.Hash,
+	}
+}
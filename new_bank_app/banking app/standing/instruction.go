@@ -0,0 +1,181 @@
+package standing
+
+import (
+	"banking-app/apperror"
+	"banking-app/money"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// MaxRetries bounds how often a run refused for insufficient funds may
+	// be tried again.
+	MaxRetries = 5
+	// MaxConsecutiveFailures is how many runs in a row may fail before the
+	// instruction is suspended.
+	MaxConsecutiveFailures = 3
+)
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusSuspended Status = "suspended"
+	StatusCompleted Status = "completed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Spec is what the customer asks for. Exactly one of ToAccountID, another
+// of their own accounts, and BeneficiaryID is set.
+type Spec struct {
+	Name          string
+	FromAccountID int
+	ToAccountID   int
+	BeneficiaryID int
+	Amount        money.Money
+	Schedule      Schedule
+	Retry         Retry
+}
+
+// Retry is how a run refused for insufficient funds is tried again:
+// Attempts more times, Interval apart. Other failures are not retried.
+type Retry struct {
+	Attempts int
+	Interval time.Duration
+}
+
+func (s Spec) Validate() error {
+	if (s.ToAccountID == 0) == (s.BeneficiaryID == 0) {
+		return apperror.NewValidationError("target", "set exactly one of toAccountID and beneficiaryID")
+	}
+	if !s.Amount.IsPositive() {
+		return apperror.NewValidationError("amount", "must be greater than 0")
+	}
+	if len([]rune(s.Name)) > 50 {
+		return apperror.NewValidationError("name", "must be at most 50 characters")
+	}
+	if s.Retry.Attempts < 0 || s.Retry.Attempts > MaxRetries {
+		return apperror.NewValidationError("retry", fmt.Sprintf("attempts must be between 0 and %d", MaxRetries))
+	}
+	if s.Retry.Attempts > 0 && s.Retry.Interval <= 0 {
+		return apperror.NewValidationError("retry", "interval must be positive")
+	}
+	return s.Schedule.Validate()
+}
+
+// Instruction is a standing instruction and where its schedule has got to.
+// The executor runs it once NextRunAt has passed: on DueDate, or later when
+// a run is being retried.
+type Instruction struct {
+	Spec
+	InstructionID       int
+	CustomerID          int
+	Status              Status
+	DueDate             time.Time
+	NextRunAt           time.Time
+	Attempt             int
+	ConsecutiveFailures int
+	CreatedAt           time.Time
+}
+
+// New starts an instruction at its first scheduled run on or after today.
+func New(spec Spec, instructionID, customerID int, now time.Time) Instruction {
+	in := Instruction{
+		Spec:          spec,
+		InstructionID: instructionID,
+		CustomerID:    customerID,
+		Status:        StatusActive,
+		CreatedAt:     now,
+	}
+	in.scheduleFrom(now)
+	return in
+}
+
+func (in Instruction) DueAt(now time.Time) bool {
+	return in.Status == StatusActive && !now.Before(in.NextRunAt)
+}
+
+// Resume reactivates a suspended instruction from its next scheduled run
+// on or after today. Runs missed while suspended are skipped.
+func (in *Instruction) Resume(now time.Time) {
+	in.Status = StatusActive
+	in.ConsecutiveFailures = 0
+	in.scheduleFrom(now)
+}
+
+func (in *Instruction) scheduleFrom(date time.Time) {
+	in.Attempt = 0
+	next, ok := in.Schedule.Next(date)
+	if !ok {
+		in.Status = StatusCompleted
+		in.DueDate, in.NextRunAt = time.Time{}, time.Time{}
+		return
+	}
+	in.DueDate, in.NextRunAt = next, next
+}
+
+type Outcome string
+
+const (
+	OutcomeSucceeded Outcome = "succeeded"
+	OutcomeHeld      Outcome = "held"
+	OutcomeRetrying  Outcome = "retrying"
+	OutcomeFailed    Outcome = "failed"
+)
+
+// Execution is one attempt at running an instruction.
+type Execution struct {
+	InstructionID int
+	Sequence      int
+	DueDate       time.Time
+	Attempt       int
+	At            time.Time
+	Outcome       Outcome
+	Error         string
+	// ReviewID is the review a held transfer waits in.
+	ReviewID int
+}
+
+// Record updates in with the result of running it at at and returns the
+// execution to keep in its history. A held transfer counts as run: the
+// review decides whether it moves.
+func (in *Instruction) Record(at time.Time, err error) Execution {
+	in.Attempt++
+	e := Execution{
+		InstructionID: in.InstructionID,
+		DueDate:       in.DueDate,
+		Attempt:       in.Attempt,
+		At:            at,
+	}
+	var held *apperror.HeldForReviewError
+	switch {
+	case err == nil:
+		e.Outcome = OutcomeSucceeded
+	case errors.As(err, &held):
+		e.Outcome = OutcomeHeld
+		e.ReviewID = held.ReviewID
+	case errors.Is(err, apperror.ErrInsufficientFunds) && in.Attempt <= in.Retry.Attempts:
+		e.Outcome = OutcomeRetrying
+	default:
+		e.Outcome = OutcomeFailed
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	switch e.Outcome {
+	case OutcomeRetrying:
+		in.NextRunAt = at.Add(in.Retry.Interval)
+		return e
+	case OutcomeFailed:
+		in.ConsecutiveFailures++
+	default:
+		in.ConsecutiveFailures = 0
+	}
+	in.scheduleFrom(in.DueDate.AddDate(0, 0, 1))
+	if in.ConsecutiveFailures >= MaxConsecutiveFailures && in.Status == StatusActive {
+		in.Status = StatusSuspended
+	}
+	return e
+}
@@ -0,0 +1,108 @@
+package standing
+
+import (
+	"banking-app/apperror"
+	"banking-app/eod"
+	"fmt"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Once     Frequency = "once"
+	Weekly   Frequency = "weekly"
+	Monthly  Frequency = "monthly"
+	MonthEnd Frequency = "month-end"
+)
+
+func ParseFrequency(s string) (Frequency, error) {
+	switch f := Frequency(s); f {
+	case Once, Weekly, Monthly, MonthEnd:
+		return f, nil
+	}
+	return "", apperror.NewValidationError("frequency", fmt.Sprintf("unknown frequency %q", s))
+}
+
+// Schedule says on which business days an instruction runs. A one-off
+// instruction runs on StartDate; weekly ones on StartDate and every seventh
+// day after it; monthly ones on DayOfMonth, or the last day of shorter
+// months; month-end ones on the last day of every month. Nothing runs after
+// EndDate, unless it is zero.
+type Schedule struct {
+	Frequency  Frequency
+	StartDate  time.Time
+	EndDate    time.Time
+	DayOfMonth int
+}
+
+func (s Schedule) Validate() error {
+	if _, err := ParseFrequency(string(s.Frequency)); err != nil {
+		return err
+	}
+	if s.StartDate.IsZero() {
+		return apperror.NewValidationError("startDate", "is required")
+	}
+	if !s.EndDate.IsZero() && eod.BusinessDate(s.EndDate).Before(eod.BusinessDate(s.StartDate)) {
+		return apperror.NewValidationError("endDate", "cannot be before the start date")
+	}
+	switch {
+	case s.Frequency == Monthly && (s.DayOfMonth < 1 || s.DayOfMonth > 31):
+		return apperror.NewValidationError("dayOfMonth", "must be between 1 and 31")
+	case s.Frequency != Monthly && s.DayOfMonth != 0:
+		return apperror.NewValidationError("dayOfMonth", "only applies to monthly instructions")
+	}
+	if _, ok := s.Next(s.StartDate); !ok {
+		return apperror.NewValidationError("endDate", "leaves no day for the instruction to run")
+	}
+	return nil
+}
+
+// Next is the first run date on or after date, or false once the schedule
+// has no more.
+func (s Schedule) Next(date time.Time) (time.Time, bool) {
+	start := eod.BusinessDate(s.StartDate)
+	date = eod.BusinessDate(date)
+	if date.Before(start) {
+		date = start
+	}
+
+	var next time.Time
+	switch s.Frequency {
+	case Once:
+		if !date.Equal(start) {
+			return time.Time{}, false
+		}
+		next = start
+	case Weekly:
+		weeks := (int(date.Sub(start).Hours()/24) + 6) / 7
+		next = start.AddDate(0, 0, 7*weeks)
+	case Monthly:
+		next = dayInMonth(date, s.DayOfMonth)
+		if next.Before(date) {
+			next = dayInMonth(firstOfNextMonth(date), s.DayOfMonth)
+		}
+	case MonthEnd:
+		next = firstOfNextMonth(date).AddDate(0, 0, -1)
+	default:
+		return time.Time{}, false
+	}
+	if !s.EndDate.IsZero() && next.After(eod.BusinessDate(s.EndDate)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func firstOfNextMonth(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// dayInMonth is day of date's month, or the month's last day if it is
+// shorter.
+func dayInMonth(date time.Time, day int) time.Time {
+	last := firstOfNextMonth(date).AddDate(0, 0, -1).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(date.Year(), date.Month(), day, 0, 0, 0, 0, time.UTC)
+}
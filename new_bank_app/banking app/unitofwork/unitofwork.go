@@ -0,0 +1,81 @@
+package unitofwork
+
+import (
+	"banking-app/apperror"
+)
+
+// Participant is one staged mutation. Prepare takes whatever locks it needs
+// and validates the change without making it visible. Commit applies the
+// change and releases the locks and must not fail. Abort releases the locks
+// without applying anything.
+type Participant interface {
+	Prepare() error
+	Commit()
+	Abort()
+}
+
+type Phase string
+
+const (
+	PhasePrepare Phase = "prepare"
+)
+
+// FailureInjector is called after each participant prepares. Returning an
+// error aborts the whole unit of work, which lets callers simulate a failure
+// at any step.
+type FailureInjector func(phase Phase, step int) error
+
+type UnitOfWork struct {
+	participants []Participant
+	inject       FailureInjector
+	done         bool
+}
+
+func New() *UnitOfWork {
+	return &UnitOfWork{}
+}
+
+func (u *UnitOfWork) WithFailureInjector(inject FailureInjector) *UnitOfWork {
+	u.inject = inject
+	return u
+}
+
+func (u *UnitOfWork) Enlist(p Participant) {
+	u.participants = append(u.participants, p)
+}
+
+func (u *UnitOfWork) Len() int {
+	return len(u.participants)
+}
+
+// Commit prepares every participant in enlistment order and commits all of
+// them only if every prepare succeeded. On any failure the participants that
+// already prepared are aborted in reverse order and nothing is applied.
+func (u *UnitOfWork) Commit() error {
+	if u.done {
+		return apperror.NewBankError("commit", "unit of work already completed")
+	}
+	u.done = true
+
+	prepared := 0
+	for i, p := range u.participants {
+		err := p.Prepare()
+		if err == nil {
+			prepared++
+			if u.inject != nil {
+				err = u.inject(PhasePrepare, i)
+			}
+		}
+		if err != nil {
+			for j := prepared - 1; j >= 0; j-- {
+				u.participants[j].Abort()
+			}
+			return err
+		}
+	}
+
+	for _, p := range u.participants {
+		p.Commit()
+	}
+	return nil
+}